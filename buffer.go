@@ -0,0 +1,113 @@
+package gorpc
+
+import (
+	"bytes"
+	"encoding"
+	"errors"
+	"io"
+	"slices"
+	"sync"
+	"sync/atomic"
+
+	"github.com/samborkent/gorpc/goc"
+)
+
+// Buffers larger than this are not returned to the pool, so a single large payload does not pin its memory.
+const maxPooledBufferSize = 64 << 10
+
+// bufferPool holds buffers for encoding requests and responses.
+var bufferPool = sync.Pool{
+	New: func() any {
+		return new([]byte)
+	},
+}
+
+func getBuffer() *[]byte {
+	return bufferPool.Get().(*[]byte)
+}
+
+func putBuffer(buf *[]byte) {
+	if cap(*buf) > maxPooledBufferSize {
+		return
+	}
+
+	*buf = (*buf)[:0]
+	bufferPool.Put(buf)
+}
+
+// encodePayload encodes v into the pooled buffer, which is first grown to the exact encoded size of v.
+func encodePayload[T any](buf *[]byte, v T, options ...goc.Option) ([]byte, error) {
+	b := (*buf)[:0]
+
+	// Values with an encoding method that cannot report their size are not sized, as that would encode them twice.
+	switch any(v).(type) {
+	case goc.Sizer:
+	case goc.EncodeWriter, goc.Encoder, encoding.BinaryMarshaler:
+		return goc.Append(b, v, options...)
+	}
+
+	if size, err := goc.SizeOf(v, options...); err == nil {
+		b = slices.Grow(b, size)
+	}
+
+	return goc.Append(b, v, options...)
+}
+
+// requestBody is an HTTP request body held in a pooled buffer.
+// The transport may read and close a body in another goroutine after the response is returned,
+// and calls GetBody for another reader to resend the body on redirects and retries.
+// The buffer is only returned to the pool once the client and every reader are done with it.
+type requestBody struct {
+	buf  *[]byte
+	refs atomic.Int32
+}
+
+// newRequestBody returns a body reading from buf, holding a reference for the caller that is dropped with release.
+func newRequestBody(buf *[]byte) *requestBody {
+	body := &requestBody{buf: buf}
+	body.refs.Store(1)
+
+	return body
+}
+
+// reader returns a new reader of the body, which drops its reference on Close.
+func (b *requestBody) reader() (io.ReadCloser, error) {
+	for {
+		refs := b.refs.Load()
+		if refs == 0 {
+			return nil, errors.New("request body already released")
+		}
+
+		if b.refs.CompareAndSwap(refs, refs+1) {
+			break
+		}
+	}
+
+	r := &requestBodyReader{body: b}
+	r.Reset(*b.buf)
+
+	return r, nil
+}
+
+// release drops a reference, returning the buffer to the pool once no references are left.
+func (b *requestBody) release() {
+	if b.refs.Add(-1) == 0 {
+		putBuffer(b.buf)
+	}
+}
+
+// requestBodyReader reads a [requestBody].
+type requestBodyReader struct {
+	bytes.Reader
+
+	body   *requestBody
+	closed atomic.Bool
+}
+
+func (r *requestBodyReader) Close() error {
+	if r.closed.CompareAndSwap(false, true) {
+		r.body.release()
+	}
+
+	return nil
+}
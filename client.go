@@ -1,10 +1,10 @@
 package gorpc
 
 import (
-	"bytes"
 	"context"
 	"fmt"
 	"hash/maphash"
+	"io"
 	"net/http"
 	"strings"
 	"weak"
@@ -19,6 +19,7 @@ type Client[Request, Response any] struct {
 	cache                   isync.Map[uint64, weak.Pointer[Response]]
 	seed                    maphash.Seed
 	cacheResponse, validate bool
+	encodeOptions           []goc.Option
 }
 
 func NewClient[Request, Response any](addr string, options ...ClientOption) (*Client[Request, Response], error) {
@@ -41,6 +42,12 @@ func NewClient[Request, Response any](addr string, options ...ClientOption) (*Cl
 		}
 	}
 
+	var encodeOptions []goc.Option
+	if cfg.cacheResponse {
+		// Cached responses are keyed by the hash of the encoded request, so equal requests must encode identically.
+		encodeOptions = append(encodeOptions, goc.WithCanonical())
+	}
+
 	return &Client[Request, Response]{
 		client:        client,
 		addr:          strings.TrimRight(addr, "/") + "/" + hash,
@@ -48,6 +55,7 @@ func NewClient[Request, Response any](addr string, options ...ClientOption) (*Cl
 		seed:          maphash.MakeSeed(),
 		cacheResponse: cfg.cacheResponse,
 		validate:      cfg.validate,
+		encodeOptions: encodeOptions,
 	}, nil
 }
 
@@ -64,12 +72,16 @@ func (c *Client[Request, Response]) Do(ctx context.Context, req *Request) (*Resp
 }
 
 func (c *Client[Request, Response]) do(ctx context.Context, req *Request) (*Response, error) {
-	// TODO: use []byte pool
-	data, err := goc.Encode(req)
+	buf := getBuffer()
+
+	data, err := encodePayload(buf, req, c.encodeOptions...)
 	if err != nil {
+		putBuffer(buf)
 		return nil, fmt.Errorf("encoding request: %w", err)
 	}
 
+	*buf = data
+
 	var (
 		cachedResponse weak.Pointer[Response]
 		payloadHash    uint64
@@ -82,16 +94,40 @@ func (c *Client[Request, Response]) do(ctx context.Context, req *Request) (*Resp
 
 		cachedResponse, ok = c.cache.Load(payloadHash)
 		if ok && cachedResponse.Value() != nil {
+			putBuffer(buf)
+
 			// TODO: resolve race-condition
 			return cachedResponse.Value(), nil
 		}
 	}
 
-	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.addr, bytes.NewReader(data))
+	// The buffer is returned to the pool once this call and the transport are done with it.
+	var body io.ReadCloser = http.NoBody
+
+	var getBody func() (io.ReadCloser, error)
+
+	if len(data) > 0 {
+		pooled := newRequestBody(buf)
+		defer pooled.release()
+
+		// The first reader cannot fail, as the reference of this call is held.
+		body, _ = pooled.reader()
+		getBody = pooled.reader
+	} else {
+		putBuffer(buf)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.addr, body)
 	if err != nil {
+		_ = body.Close()
 		return nil, fmt.Errorf("initializing request: %w", err)
 	}
 
+	// Redirects and retries resend the body, which http.NewRequest only supports for its own reader types.
+	if getBody != nil {
+		httpReq.GetBody = getBody
+	}
+
 	httpReq.Header.Add(HeaderAccept, MIMEType)
 	httpReq.Header.Add(HeaderContentType, MIMEType)
 	httpReq.Header.Add(HeaderMethodHash, c.hash)
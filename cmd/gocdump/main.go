@@ -0,0 +1,177 @@
+// Gocdump prints an annotated tree of a goc payload, to inspect payloads that fail to decode.
+// It shows the offset, bytes, path, type and decoded value of every part of the payload,
+// and marks where decoding diverged if the payload does not match the type, see [goc.Dump].
+//
+// Usage:
+//
+//	gocdump -type='struct{ID uint64; Password string}' payload.bin
+//
+// The payload is read from the file given as argument, or from standard input if there is none or it is "-".
+//
+// Flags:
+//
+//	-type    Go type expression of the payload, such as []string or struct{Name string `goc:"1"`}.
+//	         Supported are predeclared types, any, pointers, arrays, slices, maps, structs with tags,
+//	         and the standard library types with dedicated goc encodings, such as time.Time.
+//	-http    the input is a captured HTTP request or response, its body is the payload
+//	-hex     the input is hex encoded, whitespace is ignored
+//	-varint  decode integers as with [goc.WithVarint]
+//	-schema  the payload starts with a schema header, see [goc.WithSchemaHeader]
+//	-strict  mark input that is not canonical, see [goc.WithStrict]
+//	-refs    pointers may refer to earlier pointers, see [goc.WithReferences]
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"unicode"
+
+	"github.com/samborkent/gorpc/goc"
+)
+
+// config holds the flags of a gocdump run.
+type config struct {
+	typeExpr string
+	http     bool
+	hex      bool
+	varint   bool
+	schema   bool
+	strict   bool
+	refs     bool
+}
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("gocdump: ")
+
+	var cfg config
+
+	flag.StringVar(&cfg.typeExpr, "type", "", "Go type expression of the payload")
+	flag.BoolVar(&cfg.http, "http", false, "the input is a captured HTTP request or response")
+	flag.BoolVar(&cfg.hex, "hex", false, "the input is hex encoded")
+	flag.BoolVar(&cfg.varint, "varint", false, "decode integers as with goc.WithVarint")
+	flag.BoolVar(&cfg.schema, "schema", false, "the payload starts with a schema header")
+	flag.BoolVar(&cfg.strict, "strict", false, "mark input that is not canonical")
+	flag.BoolVar(&cfg.refs, "refs", false, "pointers may refer to earlier pointers")
+	flag.Parse()
+
+	if cfg.typeExpr == "" {
+		log.Fatal("missing -type flag")
+	}
+
+	input := io.Reader(os.Stdin)
+
+	if flag.NArg() > 0 && flag.Arg(0) != "-" {
+		f, err := os.Open(flag.Arg(0))
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer f.Close()
+
+		input = f
+	}
+
+	if err := run(os.Stdout, input, cfg); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// run dumps the payload read from r to w.
+func run(w io.Writer, r io.Reader, cfg config) error {
+	t, err := parseType(cfg.typeExpr)
+	if err != nil {
+		return fmt.Errorf("parsing type: %w", err)
+	}
+
+	payload, err := readPayload(r, cfg)
+	if err != nil {
+		return fmt.Errorf("reading payload: %w", err)
+	}
+
+	var options []goc.Option
+
+	if cfg.varint {
+		options = append(options, goc.WithVarint())
+	}
+
+	if cfg.schema {
+		options = append(options, goc.WithSchemaHeader())
+	}
+
+	if cfg.strict {
+		options = append(options, goc.WithStrict())
+	}
+
+	if cfg.refs {
+		options = append(options, goc.WithReferences())
+	}
+
+	return goc.Dump(w, payload, t, options...)
+}
+
+// readPayload reads the payload from r, decoding it according to the -http and -hex flags.
+func readPayload(r io.Reader, cfg config) ([]byte, error) {
+	input, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.hex {
+		input, err = hex.DecodeString(strings.Map(func(r rune) rune {
+			if unicode.IsSpace(r) {
+				return -1
+			}
+
+			return r
+		}, string(input)))
+		if err != nil {
+			return nil, fmt.Errorf("decoding hex: %w", err)
+		}
+	}
+
+	if !cfg.http {
+		return input, nil
+	}
+
+	return readHTTPBody(input)
+}
+
+// readHTTPBody returns the body of a captured HTTP/1.x request or response, as written by net/http/httputil.
+func readHTTPBody(message []byte) ([]byte, error) {
+	br := bufio.NewReader(bytes.NewReader(message))
+
+	var body io.ReadCloser
+
+	if bytes.HasPrefix(message, []byte("HTTP/")) {
+		res, err := http.ReadResponse(br, nil)
+		if err != nil {
+			return nil, fmt.Errorf("reading HTTP response: %w", err)
+		}
+
+		body = res.Body
+	} else {
+		req, err := http.ReadRequest(br)
+		if err != nil {
+			return nil, fmt.Errorf("reading HTTP request: %w", err)
+		}
+
+		body = req.Body
+	}
+
+	defer body.Close()
+
+	payload, err := io.ReadAll(body)
+	if err != nil {
+		return nil, fmt.Errorf("reading HTTP body: %w", err)
+	}
+
+	return payload, nil
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httputil"
+	"net/netip"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/samborkent/gorpc/goc"
+)
+
+type request struct {
+	ID       uint64 `goc:"varint"`
+	Password string
+	Tags     map[string][]int32
+	When     *time.Time
+	internal bool
+}
+
+const requestType = "struct{ID uint64 `goc:\"varint\"`; Password string; Tags map[string][]int32; When *time.Time; internal bool}"
+
+func TestParseType(t *testing.T) {
+	t.Parallel()
+
+	for expr, want := range map[string]reflect.Type{
+		"int":            reflect.TypeFor[int](),
+		"[]byte":         reflect.TypeFor[[]byte](),
+		"[0x4]*string":   reflect.TypeFor[[4]*string](),
+		"map[string]any": reflect.TypeFor[map[string]any](),
+		"(interface{})":  reflect.TypeFor[any](),
+		"[]netip.Addr":   reflect.TypeFor[[]netip.Addr](),
+		"struct{A, B int8 `goc:\"varint\"`}": reflect.TypeFor[struct {
+			A, B int8 `goc:"varint"`
+		}](),
+	} {
+		got, err := parseType(expr)
+		if err != nil {
+			t.Errorf("parseType(%q): %s", expr, err.Error())
+			continue
+		}
+
+		if got != want {
+			t.Errorf("parseType(%q): got %s, want %s", expr, got, want)
+		}
+	}
+
+	for _, expr := range []string{"Request", "os.File", "map[[]int]bool", "struct{io.Reader}", "[n]int", "interface{ M() }", "func()"} {
+		if _, err := parseType(expr); err == nil {
+			t.Errorf("parseType(%q): expected error", expr)
+		}
+	}
+}
+
+func TestRun(t *testing.T) {
+	t.Parallel()
+
+	when := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	payload, err := goc.Encode(request{ID: 42, Password: "secret", Tags: map[string][]int32{"a": {1}}, When: &when})
+	if err != nil {
+		t.Fatalf("Encode: %s", err.Error())
+	}
+
+	dump := func(t *testing.T, input []byte, cfg config) (string, error) {
+		t.Helper()
+
+		cfg.typeExpr = requestType
+
+		var buf strings.Builder
+
+		err := run(&buf, bytes.NewReader(input), cfg)
+
+		return buf.String(), err
+	}
+
+	t.Run("payload", func(t *testing.T) {
+		t.Parallel()
+
+		got, err := dump(t, payload, config{})
+		if err != nil {
+			t.Fatalf("run: %s", err.Error())
+		}
+
+		for _, line := range []string{`.Password string = "secret"`, ".Tags[0].key string = \"a\"", ".When time.Time = 2025-01-02 03:04:05 +0000 UTC"} {
+			if !strings.Contains(got, line) {
+				t.Errorf("dump does not contain %q:\n%s", line, got)
+			}
+		}
+	})
+	t.Run("hex", func(t *testing.T) {
+		t.Parallel()
+
+		want, err := dump(t, payload, config{})
+		if err != nil {
+			t.Fatalf("run: %s", err.Error())
+		}
+
+		got, err := dump(t, []byte(hex.EncodeToString(payload[:8])+"\n  "+hex.EncodeToString(payload[8:])+"\n"), config{hex: true})
+		if err != nil {
+			t.Fatalf("run: %s", err.Error())
+		}
+
+		if got != want {
+			t.Errorf("got dump\n%s\nwant\n%s", got, want)
+		}
+	})
+	t.Run("http", func(t *testing.T) {
+		t.Parallel()
+
+		req, err := http.NewRequest(http.MethodPost, "http://127.0.0.1/hash", bytes.NewReader(payload))
+		if err != nil {
+			t.Fatalf("NewRequest: %s", err.Error())
+		}
+
+		captured, err := httputil.DumpRequestOut(req, true)
+		if err != nil {
+			t.Fatalf("DumpRequestOut: %s", err.Error())
+		}
+
+		if _, err := dump(t, captured, config{http: true}); err != nil {
+			t.Errorf("run request: %s", err.Error())
+		}
+
+		res := &http.Response{
+			StatusCode:    http.StatusOK,
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			ContentLength: int64(len(payload)),
+			Body:          io.NopCloser(bytes.NewReader(payload)),
+		}
+
+		captured, err = httputil.DumpResponse(res, true)
+		if err != nil {
+			t.Fatalf("DumpResponse: %s", err.Error())
+		}
+
+		if _, err := dump(t, captured, config{http: true}); err != nil {
+			t.Errorf("run response: %s", err.Error())
+		}
+	})
+	t.Run("diverged", func(t *testing.T) {
+		t.Parallel()
+
+		got, err := dump(t, payload[:len(payload)-3], config{})
+		if !errors.Is(err, io.ErrUnexpectedEOF) {
+			t.Errorf("got error %v, want %v", err, io.ErrUnexpectedEOF)
+		}
+
+		if !strings.Contains(got, ".When time.Time !! ") {
+			t.Errorf("dump does not mark the time:\n%s", got)
+		}
+	})
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"math/big"
+	"net/netip"
+	"net/url"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// predeclared are the predeclared types that can be used in type expressions.
+var predeclared = map[string]reflect.Type{
+	"bool":       reflect.TypeFor[bool](),
+	"int":        reflect.TypeFor[int](),
+	"int8":       reflect.TypeFor[int8](),
+	"int16":      reflect.TypeFor[int16](),
+	"int32":      reflect.TypeFor[int32](),
+	"int64":      reflect.TypeFor[int64](),
+	"uint":       reflect.TypeFor[uint](),
+	"uint8":      reflect.TypeFor[uint8](),
+	"uint16":     reflect.TypeFor[uint16](),
+	"uint32":     reflect.TypeFor[uint32](),
+	"uint64":     reflect.TypeFor[uint64](),
+	"uintptr":    reflect.TypeFor[uintptr](),
+	"byte":       reflect.TypeFor[byte](),
+	"rune":       reflect.TypeFor[rune](),
+	"float32":    reflect.TypeFor[float32](),
+	"float64":    reflect.TypeFor[float64](),
+	"complex64":  reflect.TypeFor[complex64](),
+	"complex128": reflect.TypeFor[complex128](),
+	"string":     reflect.TypeFor[string](),
+	"any":        reflect.TypeFor[any](),
+	"error":      reflect.TypeFor[error](),
+}
+
+// qualified are the standard library types that can be used in type expressions.
+var qualified = map[string]reflect.Type{
+	"time.Time":     reflect.TypeFor[time.Time](),
+	"time.Duration": reflect.TypeFor[time.Duration](),
+	"big.Int":       reflect.TypeFor[big.Int](),
+	"big.Rat":       reflect.TypeFor[big.Rat](),
+	"netip.Addr":    reflect.TypeFor[netip.Addr](),
+	"netip.Prefix":  reflect.TypeFor[netip.Prefix](),
+	"url.URL":       reflect.TypeFor[url.URL](),
+}
+
+// parseType builds the type described by the Go type expression expr.
+// Struct types are built with their field names and tags, so they are encoded like the types they describe.
+func parseType(expr string) (reflect.Type, error) {
+	node, err := parser.ParseExpr(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	return typeOf(node)
+}
+
+func typeOf(node ast.Expr) (t reflect.Type, err error) {
+	// The reflect constructors panic on invalid types, such as maps with incomparable keys.
+	defer func() {
+		if r := recover(); r != nil {
+			t, err = nil, fmt.Errorf("%s: %v", types.ExprString(node), r)
+		}
+	}()
+
+	switch node := node.(type) {
+	case *ast.ParenExpr:
+		return typeOf(node.X)
+	case *ast.Ident:
+		if t, ok := predeclared[node.Name]; ok {
+			return t, nil
+		}
+
+		return nil, fmt.Errorf("unknown type %s, named types are not supported", node.Name)
+	case *ast.SelectorExpr:
+		if t, ok := qualified[types.ExprString(node)]; ok {
+			return t, nil
+		}
+
+		return nil, fmt.Errorf("unsupported type %s", types.ExprString(node))
+	case *ast.StarExpr:
+		elem, err := typeOf(node.X)
+		if err != nil {
+			return nil, err
+		}
+
+		return reflect.PointerTo(elem), nil
+	case *ast.ArrayType:
+		elem, err := typeOf(node.Elt)
+		if err != nil {
+			return nil, err
+		}
+
+		if node.Len == nil {
+			return reflect.SliceOf(elem), nil
+		}
+
+		lit, ok := node.Len.(*ast.BasicLit)
+		if !ok || lit.Kind != token.INT {
+			return nil, fmt.Errorf("array length %s is not an integer", types.ExprString(node.Len))
+		}
+
+		length, err := strconv.ParseInt(lit.Value, 0, 0)
+		if err != nil {
+			return nil, fmt.Errorf("array length: %w", err)
+		}
+
+		return reflect.ArrayOf(int(length), elem), nil
+	case *ast.MapType:
+		key, err := typeOf(node.Key)
+		if err != nil {
+			return nil, err
+		}
+
+		elem, err := typeOf(node.Value)
+		if err != nil {
+			return nil, err
+		}
+
+		return reflect.MapOf(key, elem), nil
+	case *ast.InterfaceType:
+		if len(node.Methods.List) > 0 {
+			return nil, fmt.Errorf("interface types with methods are not supported")
+		}
+
+		return reflect.TypeFor[any](), nil
+	case *ast.StructType:
+		return structOf(node)
+	default:
+		return nil, fmt.Errorf("unsupported type expression %s", types.ExprString(node))
+	}
+}
+
+func structOf(node *ast.StructType) (reflect.Type, error) {
+	var fields []reflect.StructField
+
+	for _, field := range node.Fields.List {
+		if len(field.Names) == 0 {
+			return nil, fmt.Errorf("embedded field %s is not supported", types.ExprString(field.Type))
+		}
+
+		typ, err := typeOf(field.Type)
+		if err != nil {
+			return nil, err
+		}
+
+		var tag reflect.StructTag
+
+		if field.Tag != nil {
+			value, err := strconv.Unquote(field.Tag.Value)
+			if err != nil {
+				return nil, fmt.Errorf("tag of field %s: %w", field.Names[0].Name, err)
+			}
+
+			tag = reflect.StructTag(value)
+		}
+
+		for _, name := range field.Names {
+			structField := reflect.StructField{Name: name.Name, Type: typ, Tag: tag}
+
+			// Unexported fields are not encoded, but keep their place in the struct.
+			if !name.IsExported() {
+				structField.PkgPath = "main"
+			}
+
+			fields = append(fields, structField)
+		}
+	}
+
+	return reflect.StructOf(fields), nil
+}
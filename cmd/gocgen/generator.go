@@ -0,0 +1,1593 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/format"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const gocPath = "github.com/samborkent/gorpc/goc"
+
+// intEncoding mirrors the integer encodings of the goc package.
+type intEncoding uint8
+
+const (
+	intFixed intEncoding = iota
+	intVarint
+)
+
+// generate type-checks the package in dir and returns the formatted source of the generated methods.
+// The output file is excluded from type-checking, so regenerating does not depend on its previous content.
+func generate(dir, output string, names []string, varint, canonical bool) ([]byte, error) {
+	pkg, err := loadPackage(dir, output)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(names) == 0 {
+		for _, name := range pkg.Scope().Names() {
+			typeName, ok := pkg.Scope().Lookup(name).(*types.TypeName)
+			if !ok || typeName.IsAlias() {
+				continue
+			}
+
+			named, ok := typeName.Type().(*types.Named)
+			if !ok || named.TypeParams().Len() > 0 {
+				continue
+			}
+
+			if _, ok := named.Underlying().(*types.Struct); ok {
+				names = append(names, name)
+			}
+		}
+	}
+
+	if len(names) == 0 {
+		return nil, fmt.Errorf("no struct types found in package %s", pkg.Name())
+	}
+
+	g := &generator{
+		pkg:       pkg,
+		types:     make(map[*types.Named]bool, len(names)),
+		inline:    make(map[*types.Named]bool),
+		imports:   map[string]string{"io": "io", gocPath: "goc"},
+		canonical: canonical,
+	}
+
+	if varint {
+		g.enc = intVarint
+	}
+
+	named := make([]*types.Named, 0, len(names))
+
+	for _, name := range names {
+		typeName, ok := pkg.Scope().Lookup(name).(*types.TypeName)
+		if !ok || typeName.IsAlias() {
+			return nil, fmt.Errorf("type %s not found in package %s", name, pkg.Name())
+		}
+
+		t, ok := typeName.Type().(*types.Named)
+		if !ok {
+			return nil, fmt.Errorf("%s is not a named type", name)
+		}
+
+		if t.TypeParams().Len() > 0 {
+			return nil, fmt.Errorf("generic type %s is not supported", name)
+		}
+
+		if _, ok := t.Underlying().(*types.Struct); !ok {
+			return nil, fmt.Errorf("%s is not a struct type", name)
+		}
+
+		g.types[t] = true
+		named = append(named, t)
+	}
+
+	for _, t := range named {
+		if err := g.generateType(t); err != nil {
+			return nil, err
+		}
+	}
+
+	return g.source()
+}
+
+func loadPackage(dir, output string) (*types.Package, error) {
+	buildPkg, err := build.ImportDir(dir, 0)
+	if err != nil {
+		return nil, fmt.Errorf("loading package: %w", err)
+	}
+
+	fset := token.NewFileSet()
+	files := make([]*ast.File, 0, len(buildPkg.GoFiles))
+
+	for _, name := range buildPkg.GoFiles {
+		if name == output {
+			continue
+		}
+
+		file, err := parser.ParseFile(fset, filepath.Join(dir, name), nil, parser.SkipObjectResolution)
+		if err != nil {
+			return nil, fmt.Errorf("parsing %s: %w", name, err)
+		}
+
+		files = append(files, file)
+	}
+
+	var typeErrs []error
+
+	// Type errors are collected instead of aborting, as the package may refer to
+	// methods that only exist in the (excluded) generated output file.
+	cfg := types.Config{
+		Importer: importer.ForCompiler(fset, "source", nil),
+		Error: func(err error) {
+			typeErrs = append(typeErrs, err)
+		},
+	}
+
+	pkg, _ := cfg.Check(buildPkg.ImportPath, fset, files, nil)
+	if pkg == nil {
+		return nil, fmt.Errorf("type-checking package: %w", errors.Join(typeErrs...))
+	}
+
+	return pkg, nil
+}
+
+type generator struct {
+	buf     bytes.Buffer
+	pkg     *types.Package
+	types   map[*types.Named]bool
+	inline  map[*types.Named]bool
+	imports map[string]string
+	enc     intEncoding
+	// Set to write map entries sorted by their encoded keys.
+	canonical bool
+
+	// Per-function state.
+	tmp     int
+	usesBuf bool
+	usesErr bool
+}
+
+// enter marks a named type as being generated inline, detecting recursive types without generated methods.
+func (g *generator) enter(t *types.Named, path string) (release func(), err error) {
+	if g.inline[t] {
+		return nil, fmt.Errorf("%s: recursive type %s must be included in -type", path, t.Obj().Name())
+	}
+
+	g.inline[t] = true
+
+	return func() {
+		delete(g.inline, t)
+	}, nil
+}
+
+func (g *generator) printf(format string, args ...any) {
+	fmt.Fprintf(&g.buf, format, args...)
+}
+
+func (g *generator) use(path string) {
+	g.imports[path] = path[strings.LastIndex(path, "/")+1:]
+}
+
+// name returns a function-unique identifier.
+func (g *generator) name(prefix string) string {
+	g.tmp++
+	return fmt.Sprintf("%s%d", prefix, g.tmp)
+}
+
+func (g *generator) typeString(t types.Type) string {
+	return types.TypeString(t, func(pkg *types.Package) string {
+		if pkg == g.pkg {
+			return ""
+		}
+
+		g.imports[pkg.Path()] = pkg.Name()
+
+		return pkg.Name()
+	})
+}
+
+// convert returns expr, which is of basic type kind, converted to type t if required.
+func (g *generator) convert(t types.Type, kind types.BasicKind, expr string) string {
+	if types.Identical(t, types.Typ[kind]) {
+		return expr
+	}
+
+	return g.typeString(t) + "(" + expr + ")"
+}
+
+func (g *generator) generateType(t *types.Named) error {
+	name := t.Obj().Name()
+
+	// Encoder.
+	body := g.body(func() error {
+		return g.encodeStruct("x", t.Underlying().(*types.Struct), g.enc, name)
+	})
+	if body.err != nil {
+		return body.err
+	}
+
+	g.printf("// EncodeTo implements [goc.EncodeWriter].\n")
+	g.printf("func (x %s) EncodeTo(w io.Writer) error {\n", name)
+	g.printf("b, err := x.appendGoc(make([]byte, 0, 64))\n")
+	g.printf("if err != nil {\nreturn err\n}\n\n")
+	g.printf("_, err = w.Write(b)\n")
+	g.printf("return err\n}\n\n")
+
+	g.printf("func (x *%s) appendGoc(b []byte) ([]byte, error) {\n", name)
+
+	if body.usesErr {
+		g.printf("var err error\n\n")
+	}
+
+	g.buf.Write(body.src)
+	g.printf("return b, nil\n}\n\n")
+
+	// Decoder.
+	body = g.body(func() error {
+		return g.decodeStruct("x", t.Underlying().(*types.Struct), g.enc, name)
+	})
+	if body.err != nil {
+		return body.err
+	}
+
+	g.printf("// DecodeFrom implements [goc.DecodeReader].\n")
+	g.printf("func (x *%s) DecodeFrom(r io.Reader) error {\n", name)
+
+	if body.usesBuf {
+		g.printf("var buf [16]byte\n\n")
+	}
+
+	g.buf.Write(body.src)
+	g.printf("return nil\n}\n\n")
+
+	return nil
+}
+
+type functionBody struct {
+	src              []byte
+	usesBuf, usesErr bool
+	err              error
+}
+
+// body generates a function body into a separate buffer, tracking which shared variables it uses.
+func (g *generator) body(generate func() error) functionBody {
+	outer := g.buf
+	g.buf = bytes.Buffer{}
+	g.tmp, g.usesBuf, g.usesErr = 0, false, false
+
+	err := generate()
+
+	body := functionBody{
+		src:     slices.Clone(g.buf.Bytes()),
+		usesBuf: g.usesBuf,
+		usesErr: g.usesErr,
+		err:     err,
+	}
+
+	g.buf = outer
+
+	return body
+}
+
+func (g *generator) source() ([]byte, error) {
+	methods := g.buf.Bytes()
+
+	if bytes.Contains(methods, []byte("fmt.Errorf")) {
+		g.use("fmt")
+	}
+
+	g.buf = bytes.Buffer{}
+	g.printf("// Code generated by gocgen; DO NOT EDIT.\n\n")
+	g.printf("package %s\n\n", g.pkg.Name())
+	g.printf("import (\n")
+
+	var std, other []string
+
+	for path := range g.imports {
+		if strings.Contains(path, ".") {
+			other = append(other, path)
+		} else {
+			std = append(std, path)
+		}
+	}
+
+	slices.Sort(std)
+	slices.Sort(other)
+
+	for _, path := range std {
+		g.printf("%q\n", path)
+	}
+
+	g.printf("\n")
+
+	for _, path := range other {
+		g.printf("%q\n", path)
+	}
+
+	g.printf(")\n\n")
+
+	named := make([]string, 0, len(g.types))
+	for t := range g.types {
+		named = append(named, t.Obj().Name())
+	}
+
+	slices.Sort(named)
+
+	g.printf("var (\n")
+
+	for _, name := range named {
+		g.printf("_ goc.EncodeWriter = %s{}\n", name)
+		g.printf("_ goc.DecodeReader = (*%s)(nil)\n", name)
+	}
+
+	g.printf(")\n\n")
+	g.buf.Write(methods)
+
+	src, err := format.Source(g.buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("formatting generated code: %w", err)
+	}
+
+	return src, nil
+}
+
+// fieldTag is the parsed goc struct tag of a field, matching the goc reflection encoder.
+type fieldTag struct {
+	name string
+	// Set if the tag renames the field.
+	named     bool
+	skip      bool
+	enc       intEncoding
+	setEnc    bool
+	omitEmpty bool
+	number    uint64
+}
+
+func parseTag(field *types.Var, tag string) (fieldTag, error) {
+	parsed := fieldTag{name: field.Name()}
+
+	value, ok := reflect.StructTag(tag).Lookup("goc")
+	if !ok {
+		return parsed, nil
+	}
+
+	if value == "-" {
+		parsed.skip = true
+		return parsed, nil
+	}
+
+	for i, option := range strings.Split(value, ",") {
+		switch option {
+		case "varint", "fixed":
+			enc := intFixed
+			if option == "varint" {
+				enc = intVarint
+			}
+
+			if parsed.setEnc && parsed.enc != enc {
+				return parsed, fmt.Errorf("conflicting options %q", value)
+			}
+
+			parsed.enc = enc
+			parsed.setEnc = true
+		case "omitempty":
+			parsed.omitEmpty = true
+		default:
+			if name, ok := strings.CutPrefix(option, "name="); ok && name != "" {
+				if parsed.named && parsed.name != name {
+					return parsed, fmt.Errorf("conflicting options %q", value)
+				}
+
+				parsed.name = name
+				parsed.named = true
+
+				continue
+			}
+
+			if option != "" && option[0] >= '0' && option[0] <= '9' {
+				number, err := strconv.ParseUint(option, 10, 32)
+				if err != nil || number == 0 {
+					return parsed, fmt.Errorf("invalid field number %q", option)
+				}
+
+				if parsed.number != 0 && parsed.number != number {
+					return parsed, fmt.Errorf("conflicting options %q", value)
+				}
+
+				parsed.number = number
+
+				continue
+			}
+
+			// An empty first option is allowed, for tags such as ",omitempty".
+			if i > 0 || option != "" {
+				return parsed, fmt.Errorf("unknown option %q", option)
+			}
+		}
+	}
+
+	return parsed, nil
+}
+
+type structField struct {
+	*types.Var
+	fieldTag
+
+	// Selector of the field within the struct, such as ".Page.Limit" for fields promoted from embedded structs.
+	selector string
+	// Embedded pointers the field is promoted through, outermost first.
+	pointers []embeddedPointer
+
+	// Set for embedded struct pointers of structs without numbered fields,
+	// which are encoded as a presence marker followed by the promoted fields.
+	embedded bool
+	// Number of following fields promoted through the embedded pointer.
+	promoted int
+}
+
+// embeddedPointer is an embedded struct pointer that fields are promoted through.
+type embeddedPointer struct {
+	selector string
+	elem     types.Type
+}
+
+// intEncoding returns the integer encoding of the field within a value encoded with enc.
+func (f structField) intEncoding(enc intEncoding) intEncoding {
+	if f.setEnc {
+		return f.enc
+	}
+
+	return enc
+}
+
+// structFields returns the encoded fields of a struct, leaving out unexported fields and fields tagged with "-",
+// and reports whether the fields are numbered.
+// The fields of embedded structs are promoted following the rules of the goc reflection encoder.
+func (g *generator) structFields(t *types.Struct, path string) (fields []structField, numbered bool, err error) {
+	// Find the depth of the shallowest field with each name, to hide deeper fields.
+	depths := make(map[string]int)
+
+	if err := g.walkFields(t, "", nil, nil, path, func(field structField, depth int) error {
+		if field.embedded {
+			return nil
+		}
+
+		if shallowest, ok := depths[field.name]; !ok || depth < shallowest {
+			depths[field.name] = depth
+		}
+
+		return nil
+	}); err != nil {
+		return nil, false, err
+	}
+
+	fields = make([]structField, 0, t.NumFields())
+	seen := make(map[string]bool, len(depths))
+
+	if err := g.walkFields(t, "", nil, nil, path, func(field structField, depth int) error {
+		if field.embedded {
+			fields = append(fields, field)
+			return nil
+		}
+
+		if depth > depths[field.name] {
+			return nil
+		}
+
+		if seen[field.name] {
+			return fmt.Errorf("%s.%s: field is ambiguous, it is promoted from multiple embedded structs", path, field.name)
+		}
+
+		seen[field.name] = true
+
+		if field.number != 0 {
+			numbered = true
+		}
+
+		fields = append(fields, field)
+
+		return nil
+	}); err != nil {
+		return nil, false, err
+	}
+
+	for i := range fields {
+		if !fields[i].embedded {
+			continue
+		}
+
+		for _, field := range fields[i+1:] {
+			if !strings.HasPrefix(field.selector, fields[i].selector+".") {
+				break
+			}
+
+			fields[i].promoted++
+		}
+	}
+
+	if !numbered {
+		return fields, false, nil
+	}
+
+	// Numbered fields are left out if they are promoted through a nil pointer, so embedded pointers are not encoded.
+	fields = slices.DeleteFunc(fields, func(field structField) bool {
+		return field.embedded
+	})
+
+	numbers := make(map[uint64]string, len(fields))
+
+	for _, field := range fields {
+		if field.number == 0 {
+			return nil, false, fmt.Errorf("%s.%s: missing field number", path, field.name)
+		}
+
+		if other, ok := numbers[field.number]; ok {
+			return nil, false, fmt.Errorf("%s.%s: field number %d already used by %s", path, field.name, field.number, other)
+		}
+
+		numbers[field.number] = field.name
+	}
+
+	return fields, true, nil
+}
+
+// walkFields calls fn for the encoded fields of t in declaration order, descending into embedded structs.
+// Embedded pointers are passed before the fields promoted through them, with their depth.
+// The selector of the fields is prefixed with selector, embedding holds the structs embedding t to detect cycles.
+func (g *generator) walkFields(t *types.Struct, selector string, pointers []embeddedPointer, embedding []types.Type, path string, fn func(field structField, depth int) error) error {
+	depth := len(embedding)
+
+	for i := range t.NumFields() {
+		field := t.Field(i)
+		fieldSelector, fieldPath := selector+"."+field.Name(), path+"."+field.Name()
+
+		tag, err := parseTag(field, t.Tag(i))
+		if err != nil {
+			return fmt.Errorf("%s: %w", fieldPath, err)
+		}
+
+		if tag.skip {
+			continue
+		}
+
+		if field.Embedded() && !tag.named && tag.number == 0 {
+			embedded := types.Unalias(field.Type())
+
+			pointer, isPointer := embedded.(*types.Pointer)
+			if isPointer {
+				embedded = types.Unalias(pointer.Elem())
+			}
+
+			if types.IsInterface(embedded) {
+				return fmt.Errorf("%s: embedded interface %s is not supported, give the field a name or tag it with \"-\"", fieldPath, field.Type().String())
+			}
+
+			if s, ok := g.flattened(embedded); ok {
+				if tag.omitEmpty {
+					return fmt.Errorf("%s: omitempty requires a field name", fieldPath)
+				}
+
+				if slices.ContainsFunc(embedding, func(e types.Type) bool { return types.Identical(e, embedded) }) {
+					return fmt.Errorf("%s: embedded struct %s embeds itself", fieldPath, embedded.String())
+				}
+
+				promotedPointers := pointers
+
+				if isPointer {
+					if err := fn(structField{Var: field, fieldTag: tag, selector: fieldSelector, pointers: pointers, embedded: true}, depth); err != nil {
+						return err
+					}
+
+					promotedPointers = append(slices.Clip(pointers), embeddedPointer{selector: fieldSelector, elem: embedded})
+				}
+
+				// The integer encoding of the embedded field applies to the promoted fields that do not set their own.
+				if err := g.walkFields(s, fieldSelector, promotedPointers, append(embedding, embedded), fieldPath, func(promoted structField, depth int) error {
+					if tag.setEnc && !promoted.setEnc {
+						promoted.enc, promoted.setEnc = tag.enc, true
+					}
+
+					return fn(promoted, depth)
+				}); err != nil {
+					return err
+				}
+
+				continue
+			}
+		}
+
+		if !field.Exported() {
+			continue
+		}
+
+		if err := fn(structField{Var: field, fieldTag: tag, selector: fieldSelector, pointers: pointers}, depth); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// flattened returns the struct of embedded type t if its fields are promoted,
+// which is the case unless goc or the generated code has a dedicated encoding for it.
+// Embedded types of other packages are flattened as well, as codecs registered for them are only known at runtime.
+func (g *generator) flattened(t types.Type) (*types.Struct, bool) {
+	if named, ok := t.(*types.Named); ok && (g.types[named] || customEncoded(named)) {
+		return nil, false
+	}
+
+	s, ok := t.Underlying().(*types.Struct)
+
+	return s, ok
+}
+
+func (g *generator) encodeStruct(expr string, t *types.Struct, enc intEncoding, path string) error {
+	fields, numbered, err := g.structFields(t, path)
+	if err != nil {
+		return err
+	}
+
+	if numbered {
+		return g.encodeNumberedStruct(expr, fields, enc, path)
+	}
+
+	return g.encodeFields(expr, fields, enc, path)
+}
+
+// encodeFields encodes the fields of a struct without numbered fields.
+// Embedded pointers are encoded as a presence marker followed by the promoted fields if present.
+func (g *generator) encodeFields(expr string, fields []structField, enc intEncoding, path string) error {
+	for i := 0; i < len(fields); i++ {
+		field := fields[i]
+		fieldExpr, fieldPath := expr+field.selector, path+"."+field.name
+
+		if field.embedded {
+			g.printf("if %s == nil {\nb = append(b, 0)\n} else {\nb = append(b, 1)\n", fieldExpr)
+
+			if err := g.encodeFields(expr, fields[i+1:i+1+field.promoted], enc, path); err != nil {
+				return err
+			}
+
+			g.printf("}\n")
+
+			i += field.promoted
+
+			continue
+		}
+
+		if field.omitEmpty {
+			empty, err := g.isEmpty(fieldExpr, field.Type(), fieldPath)
+			if err != nil {
+				return err
+			}
+
+			g.printf("if %s {\nb = append(b, 0)\n} else {\nb = append(b, 1)\n", empty)
+		}
+
+		if err := g.encode(fieldExpr, field.Type(), field.intEncoding(enc), fieldPath); err != nil {
+			return err
+		}
+
+		if field.omitEmpty {
+			g.printf("}\n")
+		}
+	}
+
+	return nil
+}
+
+// reachable returns a condition reporting whether the embedded pointers a field is promoted through are not nil,
+// or an empty string if the field is not promoted through a pointer.
+func reachable(expr string, field structField) string {
+	conditions := make([]string, len(field.pointers))
+
+	for i, pointer := range field.pointers {
+		conditions[i] = expr + pointer.selector + " != nil"
+	}
+
+	return strings.Join(conditions, " && ")
+}
+
+// encodeNumberedStruct encodes each field as its uvarint number, the uvarint length of its value and the value,
+// followed by a terminating zero field number. Fields promoted through a nil embedded pointer are left out.
+func (g *generator) encodeNumberedStruct(expr string, fields []structField, enc intEncoding, path string) error {
+	g.use("encoding/binary")
+	g.use("slices")
+
+	for _, field := range fields {
+		fieldExpr, fieldPath := expr+field.selector, path+"."+field.name
+
+		condition := reachable(expr, field)
+		if condition != "" {
+			g.printf("if %s {\n", condition)
+		}
+
+		if field.omitEmpty {
+			empty, err := g.isEmpty(fieldExpr, field.Type(), fieldPath)
+			if err != nil {
+				return err
+			}
+
+			g.printf("if !(%s) {\n", empty)
+		}
+
+		start := g.name("start")
+
+		g.printf("b = binary.AppendUvarint(b, %d)\n", field.number)
+		g.printf("%s := len(b)\n", start)
+
+		if err := g.encode(fieldExpr, field.Type(), field.intEncoding(enc), fieldPath); err != nil {
+			return err
+		}
+
+		g.printf("b = slices.Insert(b, %[1]s, binary.AppendUvarint(make([]byte, 0, binary.MaxVarintLen64), uint64(len(b)-%[1]s))...)\n", start)
+
+		if field.omitEmpty {
+			g.printf("}\n")
+		}
+
+		if condition != "" {
+			g.printf("}\n")
+		}
+	}
+
+	g.printf("b = append(b, 0)\n")
+
+	return nil
+}
+
+// isEmpty returns an expression reporting whether expr is empty for the omitempty option.
+func (g *generator) isEmpty(expr string, t types.Type, path string) (string, error) {
+	switch u := t.Underlying().(type) {
+	case *types.Basic:
+		info := u.Info()
+
+		switch {
+		case info&types.IsBoolean != 0:
+			return "!" + expr, nil
+		case info&types.IsString != 0:
+			return "len(" + expr + ") == 0", nil
+		case info&types.IsInteger != 0:
+			return expr + " == 0", nil
+		case info&types.IsFloat != 0:
+			// Negative zero is not empty.
+			g.use("math")
+			return "math.Float64bits(float64(" + expr + ")) == 0", nil
+		case info&types.IsComplex != 0:
+			g.use("math")
+			return fmt.Sprintf("math.Float64bits(real(%[1]s)) == 0 && math.Float64bits(imag(%[1]s)) == 0", expr), nil
+		}
+	case *types.Slice, *types.Map:
+		return "len(" + expr + ") == 0", nil
+	case *types.Pointer:
+		return expr + " == nil", nil
+	}
+
+	return "", fmt.Errorf("%s: omitempty is not supported for type %s", path, g.typeString(t))
+}
+
+// zero returns the zero value of a type.
+func (g *generator) zero(t types.Type) string {
+	switch u := t.Underlying().(type) {
+	case *types.Basic:
+		switch {
+		case u.Info()&types.IsBoolean != 0:
+			return "false"
+		case u.Info()&types.IsString != 0:
+			return `""`
+		default:
+			return "0"
+		}
+	case *types.Struct, *types.Array:
+		return g.typeString(t) + "{}"
+	default:
+		return "nil"
+	}
+}
+
+func (g *generator) encodeLen(expr string, enc intEncoding, path string, check bool) {
+	if check {
+		g.use("math")
+		g.printf("if len(%s) > math.MaxInt32 {\n", expr)
+		g.printf("return nil, fmt.Errorf(\"encoding %s: maximum length of %%d exceeded\", math.MaxInt32)\n}\n", path)
+	}
+
+	g.use("encoding/binary")
+
+	if enc == intVarint {
+		g.printf("b = binary.AppendUvarint(b, uint64(len(%s)))\n", expr)
+	} else {
+		g.printf("b = binary.LittleEndian.AppendUint32(b, uint32(len(%s)))\n", expr)
+	}
+}
+
+// Standard library types with dedicated goc encodings.
+var stdlibTypes = map[string]bool{
+	"time.Time":        true,
+	"math/big.Int":     true,
+	"math/big.Rat":     true,
+	"net/netip.Addr":   true,
+	"net/netip.Prefix": true,
+	"net/url.URL":      true,
+}
+
+// Method pairs of the goc custom encoding interfaces.
+var customMethods = [][2]string{
+	{"EncodeTo", "DecodeFrom"},
+	{"Encode", "Decode"},
+	{"MarshalBinary", "UnmarshalBinary"},
+}
+
+// delegated reports whether values of a named type are encoded by goc at runtime,
+// because it is a type of another package, which may have a codec registered with goc.RegisterCodec,
+// or it has a custom encoding.
+func (g *generator) delegated(t *types.Named) bool {
+	if pkg := t.Obj().Pkg(); pkg != nil && pkg != g.pkg {
+		return true
+	}
+
+	return customEncoded(t)
+}
+
+// customEncoded reports whether goc has a dedicated encoding for a named type
+// or it implements a pair of custom encoding interfaces.
+func customEncoded(t *types.Named) bool {
+	if pkg := t.Obj().Pkg(); pkg != nil {
+		// goc.Optional has a dedicated encoding as well.
+		if name := pkg.Path() + "." + t.Obj().Name(); stdlibTypes[name] || name == gocPath+".Optional" {
+			return true
+		}
+	}
+
+	if _, ok := t.Underlying().(*types.Pointer); ok {
+		return false
+	}
+
+	methods := types.NewMethodSet(types.NewPointer(t))
+
+	for _, pair := range customMethods {
+		if methods.Lookup(nil, pair[0]) != nil && methods.Lookup(nil, pair[1]) != nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+// fieldOptions returns the goc options of a delegated value.
+func (g *generator) fieldOptions(enc intEncoding) string {
+	var options string
+
+	if enc == intVarint {
+		options += ", goc.WithVarint()"
+	}
+
+	if g.canonical {
+		options += ", goc.WithCanonical()"
+	}
+
+	return options
+}
+
+// encodeField encodes a value through goc.AppendField.
+func (g *generator) encodeField(expr string, enc intEncoding, path string) error {
+	g.usesErr = true
+	g.printf("if b, err = goc.AppendField(b, &%s%s); err != nil {\n", expr, g.fieldOptions(enc))
+	g.printf("return nil, fmt.Errorf(\"encoding %s: %%w\", err)\n}\n", path)
+
+	return nil
+}
+
+// decodeField decodes a value through goc.DecodeField.
+func (g *generator) decodeField(target string, enc intEncoding, path string) error {
+	if enc == intVarint {
+		g.printf("if err := goc.DecodeField(r, &%s, goc.WithVarint()); err != nil {\n", target)
+	} else {
+		g.printf("if err := goc.DecodeField(r, &%s); err != nil {\n", target)
+	}
+
+	g.printf("return fmt.Errorf(\"decoding %s: %%w\", err)\n}\n", path)
+
+	return nil
+}
+
+func (g *generator) encode(expr string, t types.Type, enc intEncoding, path string) error {
+	t = types.Unalias(t)
+
+	if named, ok := t.(*types.Named); ok {
+		if g.types[named] {
+			g.usesErr = true
+			g.printf("if b, err = %s.appendGoc(b); err != nil {\nreturn nil, err\n}\n", expr)
+
+			return nil
+		}
+
+		if g.delegated(named) {
+			return g.encodeField(expr, enc, path)
+		}
+
+		release, err := g.enter(named, path)
+		if err != nil {
+			return err
+		}
+
+		defer release()
+	}
+
+	switch u := t.Underlying().(type) {
+	case *types.Basic:
+		return g.encodeBasic(expr, u, enc, path)
+	case *types.Interface:
+		// Concrete types are looked up in the goc type registry, or the variants of a union, at runtime.
+		return g.encodeField(expr, enc, path)
+	case *types.Pointer:
+		// Pointers are preceded by a presence marker.
+		g.printf("if %s == nil {\nb = append(b, 0)\n} else {\nb = append(b, 1)\n", expr)
+
+		if err := g.encode("(*"+expr+")", u.Elem(), enc, path); err != nil {
+			return err
+		}
+
+		g.printf("}\n")
+
+		return nil
+	case *types.Struct:
+		return g.encodeStruct(expr, u, enc, path)
+	case *types.Array:
+		// Byte arrays have a fixed length, so goc writes them without a length prefix.
+		if !byteArray(u) {
+			g.encodeLen(expr, enc, path, false)
+		} else if types.Identical(u.Elem(), types.Typ[types.Uint8]) {
+			g.printf("b = append(b, %s[:]...)\n", expr)
+			return nil
+		}
+
+		i := g.name("i")
+		g.printf("for %s := range %s {\n", i, expr)
+
+		if err := g.encode(expr+"["+i+"]", u.Elem(), enc, path+"[]"); err != nil {
+			return err
+		}
+
+		g.printf("}\n")
+
+		return nil
+	case *types.Slice:
+		g.encodeLen(expr, enc, path, true)
+
+		if types.Identical(u.Elem(), types.Typ[types.Uint8]) {
+			g.printf("b = append(b, %s...)\n", expr)
+			return nil
+		}
+
+		i := g.name("i")
+		g.printf("for %s := range %s {\n", i, expr)
+
+		if err := g.encode(expr+"["+i+"]", u.Elem(), enc, path+"[]"); err != nil {
+			return err
+		}
+
+		g.printf("}\n")
+
+		return nil
+	case *types.Map:
+		g.encodeLen(expr, enc, path, true)
+
+		k, v := g.name("k"), g.name("v")
+
+		if !g.canonical {
+			g.printf("for %s, %s := range %s {\n", k, v, expr)
+
+			if err := g.encode(k, u.Key(), enc, path+"[key]"); err != nil {
+				return err
+			}
+
+			if err := g.encode(v, u.Elem(), enc, path+"[]"); err != nil {
+				return err
+			}
+
+			g.printf("}\n")
+
+			return nil
+		}
+
+		start, entries, entry := g.name("start"), g.name("entries"), g.name("entry")
+		g.printf("%s, %s := len(b), make([]goc.MapEntry, 0, len(%s))\n", start, entries, expr)
+		g.printf("for %s, %s := range %s {\n", k, v, expr)
+		g.printf("%s := goc.MapEntry{Start: len(b)}\n", entry)
+
+		if err := g.encode(k, u.Key(), enc, path+"[key]"); err != nil {
+			return err
+		}
+
+		g.printf("%s.KeyEnd = len(b)\n", entry)
+
+		if err := g.encode(v, u.Elem(), enc, path+"[]"); err != nil {
+			return err
+		}
+
+		g.printf("%s.End = len(b)\n", entry)
+		g.printf("%s = append(%s, %s)\n}\n", entries, entries, entry)
+		g.printf("b = goc.SortMapEntries(b, %s, %s)\n", start, entries)
+
+		return nil
+	default:
+		return fmt.Errorf("%s: unsupported type %s", path, g.typeString(t))
+	}
+}
+
+func (g *generator) encodeBasic(expr string, t *types.Basic, enc intEncoding, path string) error {
+	switch t.Kind() {
+	case types.Bool:
+		g.printf("if %s {\nb = append(b, 1)\n} else {\nb = append(b, 0)\n}\n", expr)
+		return nil
+	case types.Int8, types.Uint8:
+		g.printf("b = append(b, byte(%s))\n", expr)
+		return nil
+	case types.String:
+		g.encodeLen(expr, enc, path, true)
+		g.printf("b = append(b, %s...)\n", expr)
+
+		return nil
+	}
+
+	g.use("encoding/binary")
+
+	if enc == intVarint {
+		switch t.Kind() {
+		case types.Int, types.Int16, types.Int32, types.Int64:
+			g.printf("b = binary.AppendVarint(b, int64(%s))\n", expr)
+			return nil
+		case types.Uint, types.Uintptr, types.Uint16, types.Uint32, types.Uint64:
+			g.printf("b = binary.AppendUvarint(b, uint64(%s))\n", expr)
+			return nil
+		}
+	}
+
+	switch t.Kind() {
+	case types.Int, types.Uint, types.Uintptr:
+		// Platform-sized integers are prefixed with their size in bytes.
+		g.use("strconv")
+		g.printf("b = append(b, strconv.IntSize/8)\n")
+		g.printf("if strconv.IntSize == 32 {\n")
+		g.printf("b = binary.LittleEndian.AppendUint32(b, uint32(%s))\n", expr)
+		g.printf("} else {\n")
+		g.printf("b = binary.LittleEndian.AppendUint64(b, uint64(%s))\n", expr)
+		g.printf("}\n")
+	case types.Int16, types.Uint16:
+		g.printf("b = binary.LittleEndian.AppendUint16(b, uint16(%s))\n", expr)
+	case types.Int32, types.Uint32:
+		g.printf("b = binary.LittleEndian.AppendUint32(b, uint32(%s))\n", expr)
+	case types.Int64, types.Uint64:
+		g.printf("b = binary.LittleEndian.AppendUint64(b, uint64(%s))\n", expr)
+	case types.Float32:
+		g.use("math")
+		g.printf("b = binary.LittleEndian.AppendUint32(b, math.Float32bits(float32(%s)))\n", expr)
+	case types.Float64:
+		g.use("math")
+		g.printf("b = binary.LittleEndian.AppendUint64(b, math.Float64bits(float64(%s)))\n", expr)
+	case types.Complex64:
+		g.use("math")
+		g.printf("b = binary.LittleEndian.AppendUint32(b, math.Float32bits(real(complex64(%s))))\n", expr)
+		g.printf("b = binary.LittleEndian.AppendUint32(b, math.Float32bits(imag(complex64(%s))))\n", expr)
+	case types.Complex128:
+		g.use("math")
+		g.printf("b = binary.LittleEndian.AppendUint64(b, math.Float64bits(real(complex128(%s))))\n", expr)
+		g.printf("b = binary.LittleEndian.AppendUint64(b, math.Float64bits(imag(complex128(%s))))\n", expr)
+	default:
+		return fmt.Errorf("%s: unsupported type %s", path, t.String())
+	}
+
+	return nil
+}
+
+func (g *generator) decodeStruct(target string, t *types.Struct, enc intEncoding, path string) error {
+	fields, numbered, err := g.structFields(t, path)
+	if err != nil {
+		return err
+	}
+
+	if numbered {
+		return g.decodeNumberedStruct(target, fields, enc, path)
+	}
+
+	return g.decodeFields(target, fields, enc, path)
+}
+
+// decodeFields decodes the fields of a struct without numbered fields, see [generator.encodeFields].
+// Embedded pointers are set to nil if absent, or allocated if present and nil.
+func (g *generator) decodeFields(target string, fields []structField, enc intEncoding, path string) error {
+	for i := 0; i < len(fields); i++ {
+		field := fields[i]
+		fieldTarget, fieldPath := target+field.selector, path+"."+field.name
+
+		if field.embedded {
+			g.readFull(1, "embedded "+fieldPath+" presence")
+			g.printf("switch buf[0] {\n")
+			g.printf("case 0:\n%s = nil\n", fieldTarget)
+			g.printf("case 1:\n")
+			g.printf("if %[1]s == nil {\n%[1]s = new(%[2]s)\n}\n", fieldTarget, g.typeString(types.Unalias(field.Type()).(*types.Pointer).Elem()))
+
+			if err := g.decodeFields(target, fields[i+1:i+1+field.promoted], enc, path); err != nil {
+				return err
+			}
+
+			g.printf("default:\n")
+			g.printf("return fmt.Errorf(\"decoding embedded %s: invalid presence marker %%d\", buf[0])\n}\n", fieldPath)
+
+			i += field.promoted
+
+			continue
+		}
+
+		if field.omitEmpty {
+			g.readFull(1, fieldPath+" presence")
+			g.printf("switch buf[0] {\n")
+			g.printf("case 0:\n%s = %s\n", fieldTarget, g.zero(field.Type()))
+			g.printf("case 1:\n")
+		}
+
+		if err := g.decode(fieldTarget, field.Type(), field.intEncoding(enc), fieldPath); err != nil {
+			return err
+		}
+
+		if field.omitEmpty {
+			g.printf("default:\n")
+			g.printf("return fmt.Errorf(\"decoding %s: invalid presence marker %%d\", buf[0])\n}\n", fieldPath)
+		}
+	}
+
+	return nil
+}
+
+// decodeNumberedStruct decodes fields in any order, skipping unknown fields and zeroing missing ones.
+// Each field value is decoded from a reader limited to its length, bytes left over are skipped.
+// Nil embedded pointers are allocated when a field promoted through them is decoded.
+func (g *generator) decodeNumberedStruct(target string, fields []structField, enc intEncoding, path string) error {
+	g.use("math")
+
+	for _, field := range fields {
+		if condition := reachable(target, field); condition != "" {
+			g.printf("if %s {\n%s%s = %s\n}\n", condition, target, field.selector, g.zero(field.Type()))
+		} else {
+			g.printf("%s%s = %s\n", target, field.selector, g.zero(field.Type()))
+		}
+	}
+
+	number, length, limited := g.name("number"), g.name("length"), g.name("limited")
+
+	g.printf("for {\n")
+	g.printf("%s, err := goc.ReadUvarint(r)\n", number)
+	g.printf("if err != nil {\nreturn fmt.Errorf(\"decoding %s field number: %%w\", err)\n}\n", path)
+	g.printf("if %s == 0 {\nbreak\n}\n", number)
+	g.printf("%s, err := goc.ReadUvarint(r)\n", length)
+	g.printf("if err != nil {\nreturn fmt.Errorf(\"decoding %s field %%d length: %%w\", %s, err)\n}\n", path, number)
+	g.printf("if %s > math.MaxInt32 {\n", length)
+	g.printf("return fmt.Errorf(\"decoding %s field %%d: maximum length of %%d exceeded\", %s, math.MaxInt32)\n}\n", path, number)
+	g.printf("%s := &io.LimitedReader{R: r, N: int64(%s)}\n", limited, length)
+	g.printf("switch %s {\n", number)
+
+	for _, field := range fields {
+		g.printf("case %d:\n", field.number)
+		g.printf("r := io.Reader(%s)\n", limited)
+
+		for _, pointer := range field.pointers {
+			g.printf("if %[1]s%[2]s == nil {\n%[1]s%[2]s = new(%[3]s)\n}\n", target, pointer.selector, g.typeString(pointer.elem))
+		}
+
+		if err := g.decode(target+field.selector, field.Type(), field.intEncoding(enc), path+"."+field.name); err != nil {
+			return err
+		}
+	}
+
+	g.printf("}\n")
+	g.printf("if _, err := io.Copy(io.Discard, %s); err != nil {\n", limited)
+	g.printf("return fmt.Errorf(\"decoding %s field %%d: %%w\", %s, err)\n}\n", path, number)
+	g.printf("if %s.N != 0 {\n", limited)
+	g.printf("return fmt.Errorf(\"decoding %s field %%d: %%w\", %s, io.ErrUnexpectedEOF)\n}\n", path, number)
+	g.printf("}\n")
+
+	return nil
+}
+
+func (g *generator) readFull(n int, path string) {
+	g.usesBuf = true
+	g.printf("if err := goc.ReadFull(r, buf[:%d]); err != nil {\n", n)
+	g.printf("return fmt.Errorf(\"decoding %s: %%w\", err)\n}\n", path)
+}
+
+// decodeLen declares a new variable holding a decoded length and returns its name.
+func (g *generator) decodeLen(enc intEncoding, path string) string {
+	n := g.name("n")
+
+	if enc == intVarint {
+		u := g.name("u")
+
+		g.use("math")
+		g.printf("%s, err := goc.ReadUvarint(r)\n", u)
+		g.printf("if err != nil {\nreturn fmt.Errorf(\"decoding %s length: %%w\", err)\n}\n", path)
+		g.printf("if %s > math.MaxInt32 {\n", u)
+		g.printf("return fmt.Errorf(\"decoding %s: maximum length of %%d exceeded\", math.MaxInt32)\n}\n", path)
+		g.printf("%s := int(%s)\n", n, u)
+
+		return n
+	}
+
+	u := g.name("u")
+
+	g.use("encoding/binary")
+	g.use("math")
+	g.readFull(4, path+" length")
+	g.printf("%s := binary.LittleEndian.Uint32(buf[:4])\n", u)
+	g.printf("if %s > math.MaxInt32 {\n", u)
+	g.printf("return fmt.Errorf(\"decoding %s: maximum length of %%d exceeded\", math.MaxInt32)\n}\n", path)
+	g.printf("%s := int(%s)\n", n, u)
+
+	return n
+}
+
+// check checks a decoded value against the decode options of the reader, such as its limits, before using it.
+func (g *generator) check(check, v, path string) {
+	g.printf("if err := goc.%s(r, %s); err != nil {\n", check, v)
+	g.printf("return fmt.Errorf(\"decoding %s: %%w\", err)\n}\n", path)
+}
+
+func (g *generator) decode(target string, t types.Type, enc intEncoding, path string) error {
+	t = types.Unalias(t)
+
+	if named, ok := t.(*types.Named); ok {
+		if g.types[named] {
+			g.printf("if err := %s.DecodeFrom(r); err != nil {\n", target)
+			g.printf("return fmt.Errorf(\"decoding %s: %%w\", err)\n}\n", path)
+
+			return nil
+		}
+
+		if g.delegated(named) {
+			return g.decodeField(target, enc, path)
+		}
+
+		release, err := g.enter(named, path)
+		if err != nil {
+			return err
+		}
+
+		defer release()
+	}
+
+	switch u := t.Underlying().(type) {
+	case *types.Basic:
+		return g.decodeBasic(target, t, u, enc, path)
+	case *types.Interface:
+		return g.decodeField(target, enc, path)
+	case *types.Pointer:
+		// Pointers are preceded by a presence marker.
+		g.readFull(1, path+" presence")
+		g.printf("switch buf[0] {\n")
+		g.printf("case 0:\n%s = nil\n", target)
+		// Existing values are reused, see goc.DecodeInto.
+		g.printf("case 1:\nif %s == nil {\n%s = new(%s)\n}\n", target, target, g.typeString(u.Elem()))
+
+		if err := g.decode("(*"+target+")", u.Elem(), enc, path); err != nil {
+			return err
+		}
+
+		g.printf("default:\n")
+		g.printf("return fmt.Errorf(\"decoding %s: invalid presence marker %%d\", buf[0])\n}\n", path)
+
+		return nil
+	case *types.Struct:
+		return g.decodeStruct(target, u, enc, path)
+	case *types.Array:
+		if byteArray(u) {
+			return g.decodeByteArray(target, u, enc, path)
+		}
+
+		g.printf("{\n")
+
+		n := g.decodeLen(enc, path)
+		g.printf("if %s > %d {\n", n, u.Len())
+		g.printf("return fmt.Errorf(\"decoding %s: length %%d exceeds array length %d\", %s)\n}\n", path, u.Len(), n)
+
+		if types.Identical(u.Elem(), types.Typ[types.Uint8]) {
+			g.printf("if err := goc.ReadFull(r, %s[:%s]); err != nil {\n", target, n)
+			g.printf("return fmt.Errorf(\"decoding %s: %%w\", err)\n}\n", path)
+		} else {
+			i := g.name("i")
+			g.printf("for %s := range %s {\n", i, n)
+
+			if err := g.decode(target+"["+i+"]", u.Elem(), enc, path+"[]"); err != nil {
+				return err
+			}
+
+			g.printf("}\n")
+		}
+
+		g.printf("clear(%s[%s:])\n}\n", target, n)
+
+		return nil
+	case *types.Slice:
+		g.printf("{\n")
+
+		n := g.decodeLen(enc, path)
+		g.check("CheckLength", n, path)
+
+		// The capacity of existing slices is reused, see goc.DecodeInto.
+		g.use("slices")
+
+		if types.Identical(u.Elem(), types.Typ[types.Uint8]) {
+			b := g.name("b")
+			g.printf("%s, err := goc.ReadAppend(r, %s[:0], %s)\n", b, target, n)
+			g.printf("if err != nil {\n")
+			g.printf("return fmt.Errorf(\"decoding %s: %%w\", err)\n}\n", path)
+			g.printf("%s = %s\n", target, b)
+			g.printf("}\n")
+
+			return nil
+		}
+
+		// The slice grows as elements are decoded, so a length prefix alone cannot force a large allocation.
+		g.printf("%s = slices.Grow(%s[:0], goc.PreallocLen[%s](%s))\n", target, target, g.typeString(u.Elem()), n)
+
+		i := g.name("i")
+		g.printf("for %s := range %s {\n", i, n)
+		g.printf("if %s == cap(%s) {\n%s = slices.Grow(%s, min(%s, %s-%s))\n}\n", i, target, target, target, i, n, i)
+		g.printf("%s = %s[:%s+1]\n", target, target, i)
+
+		if err := g.decode(target+"["+i+"]", u.Elem(), enc, path+"[]"); err != nil {
+			return err
+		}
+
+		g.printf("}\n}\n")
+
+		return nil
+	case *types.Map:
+		g.printf("{\n")
+
+		n := g.decodeLen(enc, path)
+		g.check("CheckLength", n, path)
+
+		// Existing maps are cleared and refilled, see goc.DecodeInto.
+		g.printf("clear(%s)\n", target)
+		g.printf("if %s == nil && %s > 0 {\n", target, n)
+		g.printf("%s = make(%s, goc.PreallocMapLen[%s, %s](%s))\n}\n", target, g.typeString(t), g.typeString(u.Key()), g.typeString(u.Elem()), n)
+		g.printf("for range %s {\n", n)
+
+		k, v := g.name("k"), g.name("v")
+		g.printf("var %s %s\n", k, g.typeString(u.Key()))
+
+		if err := g.decode(k, u.Key(), enc, path+"[key]"); err != nil {
+			return err
+		}
+
+		g.printf("var %s %s\n", v, g.typeString(u.Elem()))
+
+		if err := g.decode(v, u.Elem(), enc, path+"[]"); err != nil {
+			return err
+		}
+
+		g.printf("%s[%s] = %s\n", target, k, v)
+		g.printf("}\n")
+		// The map was cleared, so it is smaller than decoded if keys were duplicated.
+		g.check("CheckDuplicateKey", "len("+target+") != "+n, path)
+		g.printf("}\n")
+
+		return nil
+	default:
+		return fmt.Errorf("%s: unsupported type %s", path, g.typeString(t))
+	}
+}
+
+// byteArray reports whether t is an array of bytes, which goc writes without a length prefix.
+func byteArray(t *types.Array) bool {
+	if named, ok := types.Unalias(t.Elem()).(*types.Named); ok && customEncoded(named) {
+		return false
+	}
+
+	basic, ok := t.Elem().Underlying().(*types.Basic)
+
+	return ok && basic.Kind() == types.Uint8
+}
+
+func (g *generator) decodeByteArray(target string, t *types.Array, enc intEncoding, path string) error {
+	if types.Identical(t.Elem(), types.Typ[types.Uint8]) {
+		g.printf("if err := goc.ReadFull(r, %s[:]); err != nil {\n", target)
+		g.printf("return fmt.Errorf(\"decoding %s: %%w\", err)\n}\n", path)
+
+		return nil
+	}
+
+	i := g.name("i")
+	g.printf("for %s := range %s {\n", i, target)
+
+	if err := g.decode(target+"["+i+"]", t.Elem(), enc, path+"[]"); err != nil {
+		return err
+	}
+
+	g.printf("}\n")
+
+	return nil
+}
+
+// toPlatformInt sets target of type t with int, uint or uintptr kind u to the decoded 64-bit integer v,
+// returning an overflow error if it does not fit the platform size.
+func (g *generator) toPlatformInt(target string, t types.Type, u *types.Basic, v, path string) {
+	to := map[types.BasicKind]string{types.Int: "ToInt", types.Uint: "ToUint", types.Uintptr: "ToUintptr"}[u.Kind()]
+	i := g.name("i")
+
+	g.printf("{\n%s, err := goc.%s(%s)\n", i, to, v)
+	g.printf("if err != nil {\nreturn fmt.Errorf(\"decoding %s: %%w\", err)\n}\n", path)
+	g.printf("%s = %s\n}\n", target, g.convert(t, u.Kind(), i))
+}
+
+func (g *generator) decodeBasic(target string, t types.Type, u *types.Basic, enc intEncoding, path string) error {
+	switch u.Kind() {
+	case types.Bool:
+		g.readFull(1, path)
+		g.check("CheckBool", "buf[0]", path)
+		g.printf("%s = %s\n", target, g.convert(t, types.Bool, "buf[0] != 0"))
+
+		return nil
+	case types.Int8:
+		g.readFull(1, path)
+		g.printf("%s = %s\n", target, g.convert(t, types.Int8, "int8(buf[0])"))
+
+		return nil
+	case types.Uint8:
+		g.readFull(1, path)
+		g.printf("%s = %s\n", target, g.convert(t, types.Uint8, "buf[0]"))
+
+		return nil
+	case types.String:
+		g.printf("{\n")
+
+		n := g.decodeLen(enc, path)
+		g.check("CheckStringLength", n, path)
+		s := g.name("s")
+		g.printf("%s, err := goc.ReadAppend(r, nil, %s)\n", s, n)
+		g.printf("if err != nil {\n")
+		g.printf("return fmt.Errorf(\"decoding %s: %%w\", err)\n}\n", path)
+		g.check("CheckUTF8", s, path)
+		g.printf("%s = %s\n", target, g.convert(t, types.String, "string("+s+")"))
+		g.printf("}\n")
+
+		return nil
+	}
+
+	g.use("encoding/binary")
+
+	if enc == intVarint {
+		switch u.Kind() {
+		case types.Int, types.Int16, types.Int32, types.Int64:
+			v := g.name("v")
+			g.printf("{\n")
+			g.printf("%s, err := goc.ReadVarint(r)\n", v)
+			g.printf("if err != nil {\nreturn fmt.Errorf(\"decoding %s: %%w\", err)\n}\n", path)
+
+			if u.Kind() == types.Int16 || u.Kind() == types.Int32 {
+				bits := 16
+				if u.Kind() == types.Int32 {
+					bits = 32
+				}
+
+				g.use("math")
+				g.use("strconv")
+				g.printf("if %s < math.MinInt%d || %s > math.MaxInt%d {\n", v, bits, v, bits)
+				g.printf("return fmt.Errorf(\"decoding %s: %%w\", &goc.OverflowError{Value: strconv.FormatInt(%s, 10), Type: \"int%d\"})\n}\n", path, v, bits)
+			}
+
+			if u.Kind() == types.Int {
+				g.toPlatformInt(target, t, u, v, path)
+			} else {
+				g.printf("%s = %s\n", target, g.convert(t, types.Int64, v))
+			}
+
+			g.printf("}\n")
+
+			return nil
+		case types.Uint, types.Uintptr, types.Uint16, types.Uint32, types.Uint64:
+			v := g.name("v")
+			g.printf("{\n")
+			g.printf("%s, err := goc.ReadUvarint(r)\n", v)
+			g.printf("if err != nil {\nreturn fmt.Errorf(\"decoding %s: %%w\", err)\n}\n", path)
+
+			if u.Kind() == types.Uint16 || u.Kind() == types.Uint32 {
+				bits := 16
+				if u.Kind() == types.Uint32 {
+					bits = 32
+				}
+
+				g.use("math")
+				g.use("strconv")
+				g.printf("if %s > math.MaxUint%d {\n", v, bits)
+				g.printf("return fmt.Errorf(\"decoding %s: %%w\", &goc.OverflowError{Value: strconv.FormatUint(%s, 10), Type: \"uint%d\"})\n}\n", path, v, bits)
+			}
+
+			if u.Kind() == types.Uint || u.Kind() == types.Uintptr {
+				g.toPlatformInt(target, t, u, v, path)
+			} else {
+				g.printf("%s = %s\n", target, g.convert(t, types.Uint64, v))
+			}
+
+			g.printf("}\n")
+
+			return nil
+		}
+	}
+
+	switch u.Kind() {
+	case types.Int, types.Uint, types.Uintptr:
+		// Platform-sized integers are prefixed with their size in bytes.
+		kind32 := types.Int32
+		conv32, conv64 := "int32(binary.LittleEndian.Uint32(buf[:4]))", "int64(binary.LittleEndian.Uint64(buf[:8]))"
+
+		if u.Kind() != types.Int {
+			kind32 = types.Uint32
+			conv32, conv64 = "binary.LittleEndian.Uint32(buf[:4])", "binary.LittleEndian.Uint64(buf[:8])"
+		}
+
+		g.readFull(1, path+" header")
+		g.printf("switch buf[0] {\n")
+		g.printf("case 4:\n")
+		g.readFull(4, path)
+		g.printf("%s = %s\n", target, g.convert(t, kind32, conv32))
+		g.printf("case 8:\n")
+		g.readFull(8, path)
+		g.toPlatformInt(target, t, u, conv64, path)
+		g.printf("default:\n")
+		g.printf("return fmt.Errorf(\"decoding %s: %%w: unknown %s size %%d encountered\", goc.ErrInvalidIntSize, buf[0])\n", path, u.Name())
+		g.printf("}\n")
+	case types.Int16:
+		g.readFull(2, path)
+		g.printf("%s = %s\n", target, g.convert(t, types.Int16, "int16(binary.LittleEndian.Uint16(buf[:2]))"))
+	case types.Uint16:
+		g.readFull(2, path)
+		g.printf("%s = %s\n", target, g.convert(t, types.Uint16, "binary.LittleEndian.Uint16(buf[:2])"))
+	case types.Int32:
+		g.readFull(4, path)
+		g.printf("%s = %s\n", target, g.convert(t, types.Int32, "int32(binary.LittleEndian.Uint32(buf[:4]))"))
+	case types.Uint32:
+		g.readFull(4, path)
+		g.printf("%s = %s\n", target, g.convert(t, types.Uint32, "binary.LittleEndian.Uint32(buf[:4])"))
+	case types.Int64:
+		g.readFull(8, path)
+		g.printf("%s = %s\n", target, g.convert(t, types.Int64, "int64(binary.LittleEndian.Uint64(buf[:8]))"))
+	case types.Uint64:
+		g.readFull(8, path)
+		g.printf("%s = %s\n", target, g.convert(t, types.Uint64, "binary.LittleEndian.Uint64(buf[:8])"))
+	case types.Float32:
+		g.use("math")
+		g.readFull(4, path)
+		g.printf("%s = %s\n", target, g.convert(t, types.Float32, "math.Float32frombits(binary.LittleEndian.Uint32(buf[:4]))"))
+	case types.Float64:
+		g.use("math")
+		g.readFull(8, path)
+		g.printf("%s = %s\n", target, g.convert(t, types.Float64, "math.Float64frombits(binary.LittleEndian.Uint64(buf[:8]))"))
+	case types.Complex64:
+		g.use("math")
+		g.readFull(8, path)
+		g.printf("%s = %s\n", target, g.convert(t, types.Complex64, "complex(math.Float32frombits(binary.LittleEndian.Uint32(buf[:4])), math.Float32frombits(binary.LittleEndian.Uint32(buf[4:8])))"))
+	case types.Complex128:
+		g.use("math")
+		g.readFull(16, path)
+		g.printf("%s = %s\n", target, g.convert(t, types.Complex128, "complex(math.Float64frombits(binary.LittleEndian.Uint64(buf[:8])), math.Float64frombits(binary.LittleEndian.Uint64(buf[8:16])))"))
+	default:
+		return fmt.Errorf("%s: unsupported type %s", path, u.String())
+	}
+
+	return nil
+}
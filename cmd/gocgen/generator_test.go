@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const fixtureDir = "internal/fixture"
+
+// TestGenerate checks that the generated fixture files are up to date with the generator.
+func TestGenerate(t *testing.T) {
+	t.Parallel()
+
+	for _, test := range []struct {
+		output    string
+		names     []string
+		varint    bool
+		canonical bool
+	}{
+		{output: "goc_gen.go", names: []string{"Object", "Inner", "Varint", "List", "Numbered", "NumberedSubset", "Stdlib", "Embedded", "EmbeddedNumbered"}},
+		{output: "varint_goc_gen.go", names: []string{"VarintObject"}, varint: true},
+		{output: "canonical_goc_gen.go", names: []string{"CanonicalObject"}, canonical: true},
+	} {
+		t.Run(test.output, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := generate(fixtureDir, test.output, test.names, test.varint, test.canonical)
+			if err != nil {
+				t.Fatalf("generate: %s", err.Error())
+			}
+
+			want, err := os.ReadFile(filepath.Join(fixtureDir, test.output))
+			if err != nil {
+				t.Fatalf("ReadFile: %s", err.Error())
+			}
+
+			if !bytes.Equal(got, want) {
+				t.Errorf("%s is out of date, run go generate ./%s", test.output, fixtureDir)
+			}
+		})
+	}
+}
+
+func TestGenerateErrors(t *testing.T) {
+	t.Parallel()
+
+	for name, src := range map[string]string{
+		"channel":            "type T struct { C chan int }",
+		"func":               "type T struct { F func() }",
+		"omitempty":          "type T struct { S struct{} `goc:\",omitempty\"` }",
+		"numbered":           "type T struct { A int32 `goc:\"1\"`; B int32 }",
+		"duplicate":          "type T struct { A int32 `goc:\"1\"`; B int32 `goc:\"1\"` }",
+		"not struct":         "type T int",
+		"recursive":          "type T struct { N *N }\ntype N struct { Next *N }",
+		"embedded interface": "type T struct { error }",
+		"embeds itself":      "type T struct { *E }\ntype E struct { *E }",
+		"ambiguous":          "type T struct { A; B }\ntype A struct { X int32 }\ntype B struct { X int32 }",
+		"unknown option":     "type T struct { X int32 `goc:\"name=x,varnit\"` }",
+		"unknown first":      "type T struct { X int32 `goc:\"varnit\"` }",
+		"conflicting option": "type T struct { X int32 `goc:\"name=x,fixed,varint\"` }",
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := t.TempDir()
+
+			if err := os.WriteFile(filepath.Join(dir, "t.go"), []byte("package p\n\n"+src+"\n"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			_, err := generate(dir, "goc_gen.go", []string{"T"}, false, false)
+			if err == nil {
+				t.Fatal("expected error")
+			}
+
+			if !strings.Contains(err.Error(), "T") {
+				t.Errorf("error %q does not mention the type", err.Error())
+			}
+		})
+	}
+}
@@ -0,0 +1,288 @@
+// Code generated by gocgen; DO NOT EDIT.
+
+package fixture
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/samborkent/gorpc/goc"
+)
+
+var (
+	_ goc.EncodeWriter = CanonicalObject{}
+	_ goc.DecodeReader = (*CanonicalObject)(nil)
+)
+
+// EncodeTo implements [goc.EncodeWriter].
+func (x CanonicalObject) EncodeTo(w io.Writer) error {
+	b, err := x.appendGoc(make([]byte, 0, 64))
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(b)
+	return err
+}
+
+func (x *CanonicalObject) appendGoc(b []byte) ([]byte, error) {
+	var err error
+
+	if len(x.Map) > math.MaxInt32 {
+		return nil, fmt.Errorf("encoding CanonicalObject.Map: maximum length of %d exceeded", math.MaxInt32)
+	}
+	b = binary.LittleEndian.AppendUint32(b, uint32(len(x.Map)))
+	start3, entries4 := len(b), make([]goc.MapEntry, 0, len(x.Map))
+	for k1, v2 := range x.Map {
+		entry5 := goc.MapEntry{Start: len(b)}
+		if len(k1) > math.MaxInt32 {
+			return nil, fmt.Errorf("encoding CanonicalObject.Map[key]: maximum length of %d exceeded", math.MaxInt32)
+		}
+		b = binary.LittleEndian.AppendUint32(b, uint32(len(k1)))
+		b = append(b, k1...)
+		entry5.KeyEnd = len(b)
+		b = binary.LittleEndian.AppendUint32(b, uint32(v2))
+		entry5.End = len(b)
+		entries4 = append(entries4, entry5)
+	}
+	b = goc.SortMapEntries(b, start3, entries4)
+	if len(x.Nested) > math.MaxInt32 {
+		return nil, fmt.Errorf("encoding CanonicalObject.Nested: maximum length of %d exceeded", math.MaxInt32)
+	}
+	b = binary.LittleEndian.AppendUint32(b, uint32(len(x.Nested)))
+	start8, entries9 := len(b), make([]goc.MapEntry, 0, len(x.Nested))
+	for k6, v7 := range x.Nested {
+		entry10 := goc.MapEntry{Start: len(b)}
+		b = binary.LittleEndian.AppendUint16(b, uint16(k6))
+		entry10.KeyEnd = len(b)
+		if len(v7) > math.MaxInt32 {
+			return nil, fmt.Errorf("encoding CanonicalObject.Nested[]: maximum length of %d exceeded", math.MaxInt32)
+		}
+		b = binary.LittleEndian.AppendUint32(b, uint32(len(v7)))
+		start13, entries14 := len(b), make([]goc.MapEntry, 0, len(v7))
+		for k11, v12 := range v7 {
+			entry15 := goc.MapEntry{Start: len(b)}
+			b = binary.LittleEndian.AppendUint64(b, math.Float64bits(float64(k11)))
+			entry15.KeyEnd = len(b)
+			if len(v12) > math.MaxInt32 {
+				return nil, fmt.Errorf("encoding CanonicalObject.Nested[][]: maximum length of %d exceeded", math.MaxInt32)
+			}
+			b = binary.LittleEndian.AppendUint32(b, uint32(len(v12)))
+			b = append(b, v12...)
+			entry15.End = len(b)
+			entries14 = append(entries14, entry15)
+		}
+		b = goc.SortMapEntries(b, start13, entries14)
+		entry10.End = len(b)
+		entries9 = append(entries9, entry10)
+	}
+	b = goc.SortMapEntries(b, start8, entries9)
+	if len(x.Any) > math.MaxInt32 {
+		return nil, fmt.Errorf("encoding CanonicalObject.Any: maximum length of %d exceeded", math.MaxInt32)
+	}
+	b = binary.LittleEndian.AppendUint32(b, uint32(len(x.Any)))
+	start18, entries19 := len(b), make([]goc.MapEntry, 0, len(x.Any))
+	for k16, v17 := range x.Any {
+		entry20 := goc.MapEntry{Start: len(b)}
+		if len(k16) > math.MaxInt32 {
+			return nil, fmt.Errorf("encoding CanonicalObject.Any[key]: maximum length of %d exceeded", math.MaxInt32)
+		}
+		b = binary.LittleEndian.AppendUint32(b, uint32(len(k16)))
+		b = append(b, k16...)
+		entry20.KeyEnd = len(b)
+		if b, err = goc.AppendField(b, &v17, goc.WithCanonical()); err != nil {
+			return nil, fmt.Errorf("encoding CanonicalObject.Any[]: %w", err)
+		}
+		entry20.End = len(b)
+		entries19 = append(entries19, entry20)
+	}
+	b = goc.SortMapEntries(b, start18, entries19)
+	return b, nil
+}
+
+// DecodeFrom implements [goc.DecodeReader].
+func (x *CanonicalObject) DecodeFrom(r io.Reader) error {
+	var buf [16]byte
+
+	{
+		if err := goc.ReadFull(r, buf[:4]); err != nil {
+			return fmt.Errorf("decoding CanonicalObject.Map length: %w", err)
+		}
+		u2 := binary.LittleEndian.Uint32(buf[:4])
+		if u2 > math.MaxInt32 {
+			return fmt.Errorf("decoding CanonicalObject.Map: maximum length of %d exceeded", math.MaxInt32)
+		}
+		n1 := int(u2)
+		if err := goc.CheckLength(r, n1); err != nil {
+			return fmt.Errorf("decoding CanonicalObject.Map: %w", err)
+		}
+		clear(x.Map)
+		if x.Map == nil && n1 > 0 {
+			x.Map = make(map[string]int32, goc.PreallocMapLen[string, int32](n1))
+		}
+		for range n1 {
+			var k3 string
+			{
+				if err := goc.ReadFull(r, buf[:4]); err != nil {
+					return fmt.Errorf("decoding CanonicalObject.Map[key] length: %w", err)
+				}
+				u6 := binary.LittleEndian.Uint32(buf[:4])
+				if u6 > math.MaxInt32 {
+					return fmt.Errorf("decoding CanonicalObject.Map[key]: maximum length of %d exceeded", math.MaxInt32)
+				}
+				n5 := int(u6)
+				if err := goc.CheckStringLength(r, n5); err != nil {
+					return fmt.Errorf("decoding CanonicalObject.Map[key]: %w", err)
+				}
+				s7, err := goc.ReadAppend(r, nil, n5)
+				if err != nil {
+					return fmt.Errorf("decoding CanonicalObject.Map[key]: %w", err)
+				}
+				if err := goc.CheckUTF8(r, s7); err != nil {
+					return fmt.Errorf("decoding CanonicalObject.Map[key]: %w", err)
+				}
+				k3 = string(s7)
+			}
+			var v4 int32
+			if err := goc.ReadFull(r, buf[:4]); err != nil {
+				return fmt.Errorf("decoding CanonicalObject.Map[]: %w", err)
+			}
+			v4 = int32(binary.LittleEndian.Uint32(buf[:4]))
+			x.Map[k3] = v4
+		}
+		if err := goc.CheckDuplicateKey(r, len(x.Map) != n1); err != nil {
+			return fmt.Errorf("decoding CanonicalObject.Map: %w", err)
+		}
+	}
+	{
+		if err := goc.ReadFull(r, buf[:4]); err != nil {
+			return fmt.Errorf("decoding CanonicalObject.Nested length: %w", err)
+		}
+		u9 := binary.LittleEndian.Uint32(buf[:4])
+		if u9 > math.MaxInt32 {
+			return fmt.Errorf("decoding CanonicalObject.Nested: maximum length of %d exceeded", math.MaxInt32)
+		}
+		n8 := int(u9)
+		if err := goc.CheckLength(r, n8); err != nil {
+			return fmt.Errorf("decoding CanonicalObject.Nested: %w", err)
+		}
+		clear(x.Nested)
+		if x.Nested == nil && n8 > 0 {
+			x.Nested = make(map[int16]map[float64]string, goc.PreallocMapLen[int16, map[float64]string](n8))
+		}
+		for range n8 {
+			var k10 int16
+			if err := goc.ReadFull(r, buf[:2]); err != nil {
+				return fmt.Errorf("decoding CanonicalObject.Nested[key]: %w", err)
+			}
+			k10 = int16(binary.LittleEndian.Uint16(buf[:2]))
+			var v11 map[float64]string
+			{
+				if err := goc.ReadFull(r, buf[:4]); err != nil {
+					return fmt.Errorf("decoding CanonicalObject.Nested[] length: %w", err)
+				}
+				u13 := binary.LittleEndian.Uint32(buf[:4])
+				if u13 > math.MaxInt32 {
+					return fmt.Errorf("decoding CanonicalObject.Nested[]: maximum length of %d exceeded", math.MaxInt32)
+				}
+				n12 := int(u13)
+				if err := goc.CheckLength(r, n12); err != nil {
+					return fmt.Errorf("decoding CanonicalObject.Nested[]: %w", err)
+				}
+				clear(v11)
+				if v11 == nil && n12 > 0 {
+					v11 = make(map[float64]string, goc.PreallocMapLen[float64, string](n12))
+				}
+				for range n12 {
+					var k14 float64
+					if err := goc.ReadFull(r, buf[:8]); err != nil {
+						return fmt.Errorf("decoding CanonicalObject.Nested[][key]: %w", err)
+					}
+					k14 = math.Float64frombits(binary.LittleEndian.Uint64(buf[:8]))
+					var v15 string
+					{
+						if err := goc.ReadFull(r, buf[:4]); err != nil {
+							return fmt.Errorf("decoding CanonicalObject.Nested[][] length: %w", err)
+						}
+						u17 := binary.LittleEndian.Uint32(buf[:4])
+						if u17 > math.MaxInt32 {
+							return fmt.Errorf("decoding CanonicalObject.Nested[][]: maximum length of %d exceeded", math.MaxInt32)
+						}
+						n16 := int(u17)
+						if err := goc.CheckStringLength(r, n16); err != nil {
+							return fmt.Errorf("decoding CanonicalObject.Nested[][]: %w", err)
+						}
+						s18, err := goc.ReadAppend(r, nil, n16)
+						if err != nil {
+							return fmt.Errorf("decoding CanonicalObject.Nested[][]: %w", err)
+						}
+						if err := goc.CheckUTF8(r, s18); err != nil {
+							return fmt.Errorf("decoding CanonicalObject.Nested[][]: %w", err)
+						}
+						v15 = string(s18)
+					}
+					v11[k14] = v15
+				}
+				if err := goc.CheckDuplicateKey(r, len(v11) != n12); err != nil {
+					return fmt.Errorf("decoding CanonicalObject.Nested[]: %w", err)
+				}
+			}
+			x.Nested[k10] = v11
+		}
+		if err := goc.CheckDuplicateKey(r, len(x.Nested) != n8); err != nil {
+			return fmt.Errorf("decoding CanonicalObject.Nested: %w", err)
+		}
+	}
+	{
+		if err := goc.ReadFull(r, buf[:4]); err != nil {
+			return fmt.Errorf("decoding CanonicalObject.Any length: %w", err)
+		}
+		u20 := binary.LittleEndian.Uint32(buf[:4])
+		if u20 > math.MaxInt32 {
+			return fmt.Errorf("decoding CanonicalObject.Any: maximum length of %d exceeded", math.MaxInt32)
+		}
+		n19 := int(u20)
+		if err := goc.CheckLength(r, n19); err != nil {
+			return fmt.Errorf("decoding CanonicalObject.Any: %w", err)
+		}
+		clear(x.Any)
+		if x.Any == nil && n19 > 0 {
+			x.Any = make(map[string]any, goc.PreallocMapLen[string, any](n19))
+		}
+		for range n19 {
+			var k21 string
+			{
+				if err := goc.ReadFull(r, buf[:4]); err != nil {
+					return fmt.Errorf("decoding CanonicalObject.Any[key] length: %w", err)
+				}
+				u24 := binary.LittleEndian.Uint32(buf[:4])
+				if u24 > math.MaxInt32 {
+					return fmt.Errorf("decoding CanonicalObject.Any[key]: maximum length of %d exceeded", math.MaxInt32)
+				}
+				n23 := int(u24)
+				if err := goc.CheckStringLength(r, n23); err != nil {
+					return fmt.Errorf("decoding CanonicalObject.Any[key]: %w", err)
+				}
+				s25, err := goc.ReadAppend(r, nil, n23)
+				if err != nil {
+					return fmt.Errorf("decoding CanonicalObject.Any[key]: %w", err)
+				}
+				if err := goc.CheckUTF8(r, s25); err != nil {
+					return fmt.Errorf("decoding CanonicalObject.Any[key]: %w", err)
+				}
+				k21 = string(s25)
+			}
+			var v22 any
+			if err := goc.DecodeField(r, &v22); err != nil {
+				return fmt.Errorf("decoding CanonicalObject.Any[]: %w", err)
+			}
+			x.Any[k21] = v22
+		}
+		if err := goc.CheckDuplicateKey(r, len(x.Any) != n19); err != nil {
+			return fmt.Errorf("decoding CanonicalObject.Any: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,24 @@
+// Package external stands in for a third-party package, whose types cannot implement goc encoding interfaces.
+package external
+
+// Point has a codec registered by the fixture tests.
+type Point struct {
+	X, Y int16
+	// Unexported fields are left out of the reflection encoding, but not out of the registered codec.
+	label string
+}
+
+// NewPoint returns a labeled point.
+func NewPoint(x, y int16, label string) Point {
+	return Point{X: x, Y: y, label: label}
+}
+
+// Label returns the label of p.
+func (p Point) Label() string {
+	return p.label
+}
+
+// Pair has no codec, it is encoded by its structure.
+type Pair struct {
+	A, B int32
+}
@@ -0,0 +1,214 @@
+// Package fixture contains types used to verify that gocgen output matches the goc reflection encoder.
+package fixture
+
+import (
+	"fmt"
+	"math/big"
+	"net/netip"
+	"net/url"
+	"time"
+
+	"github.com/samborkent/gorpc/cmd/gocgen/internal/fixture/external"
+	"github.com/samborkent/gorpc/goc"
+)
+
+//go:generate go run github.com/samborkent/gorpc/cmd/gocgen -type=Object,Inner,Varint,List,Numbered,NumberedSubset,Stdlib,Embedded,EmbeddedNumbered
+//go:generate go run github.com/samborkent/gorpc/cmd/gocgen -type=VarintObject -varint -output=varint_goc_gen.go
+//go:generate go run github.com/samborkent/gorpc/cmd/gocgen -type=CanonicalObject -canonical -output=canonical_goc_gen.go
+
+type (
+	ID    uint64
+	Name  string
+	Flag  bool
+	Blob  []byte
+	Score float32
+	Octet uint8
+	UUID  [16]byte
+)
+
+type Object struct {
+	Bool       bool
+	Int        int
+	Int8       int8
+	Int16      int16
+	Int32      int32
+	Int64      int64
+	Uint       uint
+	Uint8      uint8
+	Uint16     uint16
+	Uint32     uint32
+	Uint64     uint64
+	Uintptr    uintptr
+	Float32    float32
+	Float64    float64
+	Complex64  complex64
+	Complex128 complex128
+	String     string
+
+	ID    ID
+	Name  Name
+	Flag  Flag
+	Blob  Blob
+	Score Score
+
+	Bytes  []byte
+	Array  [4]byte
+	UUID   UUID
+	Octets [3]Octet
+	Matrix [2][3]int16
+	Slice  []int32
+	Names  []Name
+	Map    map[string]string
+	Scores map[ID][]Score
+
+	Inner   Inner
+	Inners  []Inner
+	Pointer *Inner
+	Nested  struct {
+		A int64
+		B string
+	}
+
+	Varint Varint
+
+	Skipped       string `goc:"-"`
+	internal      string
+	OptionalInt   int32            `goc:",omitempty"`
+	OptionalFloat float64          `goc:"name=optionalFloat,omitempty"`
+	OptionalSlice []string         `goc:",omitempty"`
+	OptionalMap   map[string]Score `goc:",omitempty"`
+	OptionalInner *Inner           `goc:",omitempty"`
+}
+
+type Inner struct {
+	Key   string
+	Value float64
+}
+
+type Varint struct {
+	Fixed  uint64 `goc:"fixed"`
+	Varint uint64 `goc:"varint"`
+	Signed int32  `goc:"varint"`
+	Slice  []int  `goc:"varint"`
+}
+
+type VarintObject struct {
+	Int    int
+	Int16  int16
+	Uint32 uint32
+	Fixed  int64 `goc:"fixed"`
+	String string
+	Map    map[uint16]int32
+}
+
+type CanonicalObject struct {
+	Map    map[string]int32
+	Nested map[int16]map[float64]string
+	Any    map[string]any
+}
+
+type List struct {
+	Value int32
+	Next  *List
+}
+
+type Numbered struct {
+	ID    ID        `goc:"1"`
+	Name  string    `goc:"name=name,2"`
+	Inner Inner     `goc:"3"`
+	Tags  []Name    `goc:"4,omitempty"`
+	Next  *Numbered `goc:"5"`
+	Count uint32    `goc:"6,varint"`
+}
+
+// NumberedSubset is Numbered with fields removed and reordered.
+type NumberedSubset struct {
+	Count uint32 `goc:"6,varint"`
+	Name  string `goc:"2"`
+}
+
+// Embedded promotes the fields of embedded structs and struct pointers over multiple levels.
+// Its Limit field hides Page.Limit, which hides Auth.Limit.
+type Embedded struct {
+	Page
+	*Audit `goc:"varint"`
+	Name   string
+	Limit  int16
+}
+
+type Page struct {
+	Offset uint32
+	Limit  uint32
+}
+
+type Audit struct {
+	Created int64
+	*Auth
+}
+
+type Auth struct {
+	Token string
+	Limit uint32
+}
+
+// EmbeddedNumbered promotes numbered fields, leaving out fields promoted through a nil pointer.
+type EmbeddedNumbered struct {
+	NumberedBase
+	*NumberedAudit
+	Name string `goc:"3"`
+}
+
+type NumberedBase struct {
+	ID ID `goc:"1"`
+}
+
+type NumberedAudit struct {
+	Created int64  `goc:"2"`
+	Token   string `goc:"4,omitempty"`
+}
+
+type Stdlib struct {
+	Time     time.Time
+	Duration time.Duration
+	Int      *big.Int
+	Rat      big.Rat
+	Addr     netip.Addr
+	Prefix   netip.Prefix
+	URL      url.URL
+	Version  Version
+	Any      any
+	Values   map[string]any `goc:"varint"`
+	Optional goc.Optional[int32]
+	Patch    []goc.Optional[Inner]
+	Results  []Result
+	Point    external.Point
+	Pairs    []external.Pair
+}
+
+// Result is a union of Inner and *Version.
+type Result interface {
+	isResult()
+}
+
+func (Inner) isResult()    {}
+func (*Version) isResult() {}
+
+// Version implements goc.Encoder and goc.Decoder.
+type Version struct {
+	Major, Minor uint8
+}
+
+func (v Version) Encode() ([]byte, error) {
+	return fmt.Appendf(nil, "%d.%d", v.Major, v.Minor), nil
+}
+
+func (v *Version) Decode(b []byte) error {
+	_, err := fmt.Sscanf(string(b), "%d.%d", &v.Major, &v.Minor)
+	return err
+}
+
+func init() {
+	goc.Register[Inner]("fixture.Inner")
+	goc.Register[string]("string")
+	goc.RegisterUnion[Result](Inner{}, &Version{})
+}
@@ -0,0 +1,600 @@
+package fixture
+
+import (
+	"bytes"
+	cryptorand "crypto/rand"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"math/big"
+	"math/rand/v2"
+	"net/netip"
+	"net/url"
+	"reflect"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/samborkent/gorpc/cmd/gocgen/internal/fixture/external"
+	"github.com/samborkent/gorpc/goc"
+)
+
+// Types without generated methods, encoded through reflection.
+type (
+	plainObject       Object
+	plainVarintObject VarintObject
+	plainCanonical    CanonicalObject
+	plainNumbered     Numbered
+	plainStdlib       Stdlib
+	plainEmbedded     Embedded
+	plainEmbeddedNum  EmbeddedNumbered
+	plainList         struct {
+		Value int32
+		Next  *plainList
+	}
+)
+
+func init() {
+	goc.RegisterCodec(func(w io.Writer, p external.Point) error {
+		b, err := goc.Encode(struct {
+			X, Y  int16
+			Label string
+		}{p.X, p.Y, p.Label()})
+		if err != nil {
+			return err
+		}
+
+		_, err = w.Write(b)
+
+		return err
+	}, func(r io.Reader) (external.Point, error) {
+		var p struct {
+			X, Y  int16
+			Label string
+		}
+
+		if err := goc.DecodeInto(r, &p); err != nil {
+			return external.Point{}, err
+		}
+
+		return external.NewPoint(p.X, p.Y, p.Label), nil
+	})
+}
+
+func TestGenerated(t *testing.T) {
+	t.Parallel()
+
+	t.Run("object", func(t *testing.T) {
+		t.Parallel()
+
+		want := newObject()
+
+		compare(t, want, plainObject(want))
+	})
+	t.Run("varint", func(t *testing.T) {
+		t.Parallel()
+
+		want := VarintObject{
+			Int:    rand.Int() - rand.Int(),
+			Int16:  int16(rand.Int32()),
+			Uint32: rand.Uint32(),
+			Fixed:  rand.Int64(),
+			String: cryptorand.Text(),
+			Map:    map[uint16]int32{uint16(rand.Uint32()): -rand.Int32()},
+		}
+
+		compare(t, want, plainVarintObject(want), goc.WithVarint())
+	})
+	t.Run("canonical", func(t *testing.T) {
+		t.Parallel()
+
+		want := CanonicalObject{
+			Map:    make(map[string]int32),
+			Nested: make(map[int16]map[float64]string),
+			Any:    make(map[string]any),
+		}
+
+		for range 16 {
+			want.Map[cryptorand.Text()] = rand.Int32()
+			want.Nested[int16(rand.Int32())] = map[float64]string{rand.Float64(): cryptorand.Text(), rand.Float64(): cryptorand.Text()}
+			want.Any[cryptorand.Text()] = Inner{Key: cryptorand.Text()}
+		}
+
+		compare(t, want, plainCanonical(want), goc.WithCanonical())
+	})
+	t.Run("zero", func(t *testing.T) {
+		t.Parallel()
+
+		want := Object{Pointer: new(Inner)}
+
+		compare(t, want, plainObject(want))
+	})
+	t.Run("nil pointer", func(t *testing.T) {
+		t.Parallel()
+
+		compare(t, Object{}, plainObject{})
+	})
+	t.Run("negative zero", func(t *testing.T) {
+		t.Parallel()
+
+		want := Object{OptionalFloat: math.Copysign(0, -1)}
+
+		compare(t, want, plainObject(want))
+	})
+	t.Run("skipped", func(t *testing.T) {
+		t.Parallel()
+
+		d, err := goc.Encode(Object{Skipped: cryptorand.Text(), internal: cryptorand.Text()})
+		if err != nil {
+			t.Fatalf("Encode: %s", err.Error())
+		}
+
+		got, err := goc.Decode[Object](d)
+		if err != nil {
+			t.Fatalf("Decode: %s", err.Error())
+		}
+
+		if got.Skipped != "" || got.internal != "" {
+			t.Errorf("skipped fields were encoded: %+v", got)
+		}
+	})
+	t.Run("list", func(t *testing.T) {
+		t.Parallel()
+
+		compare(t,
+			List{Value: 1, Next: &List{Value: 2, Next: &List{Value: 3}}},
+			plainList{Value: 1, Next: &plainList{Value: 2, Next: &plainList{Value: 3}}},
+		)
+	})
+	t.Run("numbered", func(t *testing.T) {
+		t.Parallel()
+
+		want := Numbered{
+			ID:    ID(rand.Uint64()),
+			Name:  cryptorand.Text(),
+			Inner: Inner{Key: cryptorand.Text(), Value: rand.Float64()},
+			Tags:  []Name{Name(cryptorand.Text())},
+			Next:  &Numbered{Count: rand.Uint32()},
+			Count: rand.Uint32(),
+		}
+
+		compare(t, want, plainNumbered(want))
+		compare(t, Numbered{}, plainNumbered{})
+
+		d, err := goc.Encode(want)
+		if err != nil {
+			t.Fatalf("Encode: %s", err.Error())
+		}
+
+		got, err := goc.Decode[NumberedSubset](d)
+		if err != nil {
+			t.Fatalf("Decode: %s", err.Error())
+		}
+
+		if got != (NumberedSubset{Count: want.Count, Name: want.Name}) {
+			t.Errorf("got %+v, want %+v", got, want)
+		}
+	})
+	t.Run("embedded", func(t *testing.T) {
+		t.Parallel()
+
+		want := Embedded{
+			Page:  Page{Offset: rand.Uint32()},
+			Audit: &Audit{Created: rand.Int64(), Auth: &Auth{Token: cryptorand.Text()}},
+			Name:  cryptorand.Text(),
+			Limit: int16(rand.Int32()),
+		}
+
+		compare(t, want, plainEmbedded(want))
+
+		// Hidden fields are not encoded.
+		want.Page.Limit, want.Audit.Auth.Limit = rand.Uint32(), rand.Uint32()
+
+		d, err := goc.Encode(want)
+		if err != nil {
+			t.Fatalf("Encode: %s", err.Error())
+		}
+
+		got, err := goc.Decode[Embedded](d)
+		if err != nil {
+			t.Fatalf("Decode: %s", err.Error())
+		}
+
+		if got.Page.Limit != 0 || got.Audit.Auth.Limit != 0 {
+			t.Errorf("hidden fields were encoded: %+v", got)
+		}
+
+		missing := Embedded{Audit: &Audit{Created: rand.Int64()}}
+		compare(t, missing, plainEmbedded(missing))
+		compare(t, Embedded{}, plainEmbedded{})
+	})
+	t.Run("embedded numbered", func(t *testing.T) {
+		t.Parallel()
+
+		want := EmbeddedNumbered{
+			NumberedBase:  NumberedBase{ID: ID(rand.Uint64())},
+			NumberedAudit: &NumberedAudit{Created: rand.Int64(), Token: cryptorand.Text()},
+			Name:          cryptorand.Text(),
+		}
+
+		compare(t, want, plainEmbeddedNum(want))
+		compare(t, EmbeddedNumbered{}, plainEmbeddedNum{})
+	})
+	t.Run("stdlib", func(t *testing.T) {
+		t.Parallel()
+
+		want := Stdlib{
+			Time:     time.Now().Round(0).UTC(),
+			Duration: time.Duration(rand.Int64()),
+			Int:      big.NewInt(-rand.Int64()),
+			Addr:     netip.MustParseAddr("2001:db8::1"),
+			Prefix:   netip.MustParsePrefix("192.0.2.0/24"),
+			URL:      url.URL{Scheme: "https", Host: "example.com", Path: "/" + cryptorand.Text()},
+			Version:  Version{Major: 1, Minor: 2},
+			Any:      Inner{Key: cryptorand.Text()},
+			Values:   map[string]any{"string": cryptorand.Text()},
+			Optional: goc.OptionalOf(rand.Int32()),
+			Patch:    []goc.Optional[Inner]{{}, goc.OptionalOf(Inner{Key: cryptorand.Text()})},
+			Results:  []Result{Inner{Value: rand.Float64()}, &Version{Major: 3}, nil},
+			Point:    external.NewPoint(int16(rand.Int32()), int16(rand.Int32()), cryptorand.Text()),
+			Pairs:    []external.Pair{{A: rand.Int32(), B: rand.Int32()}},
+		}
+		want.Rat.SetFrac64(rand.Int64(), rand.Int64N(1<<20)+1)
+
+		compare(t, want, plainStdlib(want))
+
+		// Decoded big.Rat values always have a denominator.
+		var zero Stdlib
+		zero.Rat.SetInt64(0)
+
+		compare(t, zero, plainStdlib(zero))
+	})
+	t.Run("truncated", func(t *testing.T) {
+		t.Parallel()
+
+		d, err := goc.Encode(newObject())
+		if err != nil {
+			t.Fatalf("Encode: %s", err.Error())
+		}
+
+		for _, n := range []int{0, 1, len(d) / 2, len(d) - 1} {
+			if _, err := goc.Decode[Object](d[:n]); err == nil {
+				t.Errorf("expected error decoding %d of %d bytes", n, len(d))
+			}
+		}
+	})
+	t.Run("decode into", func(t *testing.T) {
+		t.Parallel()
+
+		want := newObject()
+
+		d, err := goc.Encode(want)
+		if err != nil {
+			t.Fatalf("Encode: %s", err.Error())
+		}
+
+		got, plain := newObject(), plainObject(newObject())
+		slice, pointer := &got.Slice[:1][0], got.Pointer
+
+		if err := goc.DecodeBytesInto(d, &got); err != nil {
+			t.Fatalf("DecodeBytesInto generated: %s", err.Error())
+		}
+
+		if err := goc.DecodeBytesInto(d, &plain); err != nil {
+			t.Fatalf("DecodeBytesInto reflection: %s", err.Error())
+		}
+
+		if !reflect.DeepEqual(got, want) || !reflect.DeepEqual(plain, plainObject(want)) {
+			t.Errorf("got %+v and %+v, want %+v", got, plain, want)
+		}
+
+		if &got.Slice[0] != slice || got.Pointer != pointer {
+			t.Error("expected slice and pointer to be reused")
+		}
+
+		// Empty collections and absent pointers overwrite existing values.
+		d, err = goc.Encode(Object{})
+		if err != nil {
+			t.Fatalf("Encode: %s", err.Error())
+		}
+
+		if err := goc.DecodeBytesInto(d, &got); err != nil {
+			t.Fatalf("DecodeBytesInto generated: %s", err.Error())
+		}
+
+		if len(got.Slice) != 0 || len(got.Map) != 0 || len(got.Inners) != 0 || got.Pointer != nil || got.Array != [4]byte{} {
+			t.Errorf("got %+v, want empty object", got)
+		}
+	})
+	t.Run("limits", func(t *testing.T) {
+		t.Parallel()
+
+		object := newObject()
+
+		d, err := goc.Encode(object)
+		if err != nil {
+			t.Fatalf("Encode: %s", err.Error())
+		}
+
+		if _, err := goc.Decode[Object](d, goc.WithMaxStringLength(len(object.String)-1)); !errors.Is(err, goc.ErrMaxStringLength) {
+			t.Errorf("got error %v, want %v", err, goc.ErrMaxStringLength)
+		}
+
+		if _, err := goc.Decode[Object](d, goc.WithMaxLength(len(object.Slice)-1)); !errors.Is(err, goc.ErrMaxLength) {
+			t.Errorf("got error %v, want %v", err, goc.ErrMaxLength)
+		}
+
+		if _, err := goc.Decode[Object](d, goc.WithMaxBytes(len(d)-1)); !errors.Is(err, goc.ErrMaxBytes) {
+			t.Errorf("got error %v, want %v", err, goc.ErrMaxBytes)
+		}
+
+		if _, err := goc.Decode[Object](d, goc.WithMaxBytes(len(d))); err != nil {
+			t.Errorf("Decode: %s", err.Error())
+		}
+
+		// Numbered fields are decoded through a limited reader.
+		d, err = goc.Encode(Numbered{Name: "numbered"})
+		if err != nil {
+			t.Fatalf("Encode: %s", err.Error())
+		}
+
+		if _, err := goc.Decode[Numbered](d, goc.WithMaxStringLength(4)); !errors.Is(err, goc.ErrMaxStringLength) {
+			t.Errorf("got error %v, want %v", err, goc.ErrMaxStringLength)
+		}
+	})
+	t.Run("strict", func(t *testing.T) {
+		t.Parallel()
+
+		checkStrict := func(t *testing.T, d []byte, decode func([]byte, ...goc.Option) error, target error) {
+			t.Helper()
+
+			if err := decode(d); err != nil {
+				t.Errorf("Decode: %s", err.Error())
+			}
+
+			if err := decode(d, goc.WithStrict()); !errors.Is(err, target) {
+				t.Errorf("got error %v, want %v", err, target)
+			}
+		}
+
+		object := newObject()
+
+		d, err := goc.Encode(object)
+		if err != nil {
+			t.Fatalf("Encode: %s", err.Error())
+		}
+
+		if _, err := goc.Decode[Object](d, goc.WithStrict()); err != nil {
+			t.Errorf("Decode: %s", err.Error())
+		}
+
+		decodeObject := func(d []byte, options ...goc.Option) error {
+			_, err := goc.Decode[Object](d, options...)
+			return err
+		}
+
+		checkStrict(t, append(d, 0), decodeObject, goc.ErrTrailingBytes)
+
+		// The first field is a bool.
+		d[0] = 2
+		checkStrict(t, d, decodeObject, goc.ErrInvalidBool)
+
+		object.String = "\xff"
+
+		d, err = goc.Encode(object)
+		if err != nil {
+			t.Fatalf("Encode: %s", err.Error())
+		}
+
+		checkStrict(t, d, decodeObject, goc.ErrInvalidUTF8)
+
+		// Numbered fields are decoded through a limited reader.
+		d, err = goc.Encode(Numbered{Name: "\xff"})
+		if err != nil {
+			t.Fatalf("Encode: %s", err.Error())
+		}
+
+		checkStrict(t, d, func(d []byte, options ...goc.Option) error {
+			_, err := goc.Decode[Numbered](d, options...)
+			return err
+		}, goc.ErrInvalidUTF8)
+
+		// Map entries are encoded like a slice of key-value pairs, followed by the two empty maps.
+		d, err = goc.Encode([]struct {
+			Key   string
+			Value int32
+		}{{"a", 1}, {"a", 2}})
+		if err != nil {
+			t.Fatalf("Encode: %s", err.Error())
+		}
+
+		checkStrict(t, append(d, make([]byte, 8)...), func(d []byte, options ...goc.Option) error {
+			_, err := goc.Decode[CanonicalObject](d, options...)
+			return err
+		}, goc.ErrDuplicateMapKey)
+	})
+}
+
+// compare checks that the generated methods of G and the reflection encoder of P produce identical bytes,
+// and that both round trip.
+func compare[G, P any](t *testing.T, generated G, plain P, options ...goc.Option) {
+	t.Helper()
+
+	got, err := goc.Encode(generated)
+	if err != nil {
+		t.Fatalf("Encode generated: %s", err.Error())
+	}
+
+	want, err := goc.Encode(plain, options...)
+	if err != nil {
+		t.Fatalf("Encode reflection: %s", err.Error())
+	}
+
+	if !bytes.Equal(got, want) {
+		t.Fatalf("generated encoding differs from reflection encoding:\ngot  %x\nwant %x", got, want)
+	}
+
+	decoded, err := goc.Decode[G](got)
+	if err != nil {
+		t.Fatalf("Decode generated: %s", err.Error())
+	}
+
+	if !reflect.DeepEqual(decoded, generated) {
+		t.Errorf("got %+v, want %+v", decoded, generated)
+	}
+}
+
+func newObject() Object {
+	return Object{
+		Bool:       true,
+		Int:        rand.Int() - rand.Int(),
+		Int8:       int8(rand.Int32()),
+		Int16:      int16(rand.Int32()),
+		Int32:      rand.Int32() - rand.Int32(),
+		Int64:      rand.Int64() - rand.Int64(),
+		Uint:       rand.Uint(),
+		Uint8:      uint8(rand.Uint32()),
+		Uint16:     uint16(rand.Uint32()),
+		Uint32:     rand.Uint32(),
+		Uint64:     rand.Uint64(),
+		Uintptr:    uintptr(rand.Uint64()),
+		Float32:    rand.Float32(),
+		Float64:    rand.Float64(),
+		Complex64:  complex(rand.Float32(), rand.Float32()),
+		Complex128: complex(rand.Float64(), rand.Float64()),
+		String:     cryptorand.Text(),
+		ID:         ID(rand.Uint64()),
+		Name:       Name(cryptorand.Text()),
+		Flag:       true,
+		Blob:       Blob(cryptorand.Text()),
+		Score:      Score(rand.Float32()),
+		Bytes:      []byte(cryptorand.Text()),
+		Array:      [4]byte{1, 2, 3, 4},
+		UUID:       UUID{15: 1},
+		Octets:     [3]Octet{1, 2, 3},
+		Matrix:     [2][3]int16{{1, -2, 3}, {-4, 5, -6}},
+		Slice:      []int32{rand.Int32(), -rand.Int32(), rand.Int32()},
+		Names:      []Name{Name(cryptorand.Text()), ""},
+		// Single entry maps, as map iteration order is random.
+		Map:    map[string]string{cryptorand.Text(): cryptorand.Text()},
+		Scores: map[ID][]Score{ID(rand.Uint64()): {Score(rand.Float32()), Score(rand.Float32())}},
+		Inner: Inner{
+			Key:   cryptorand.Text(),
+			Value: rand.Float64(),
+		},
+		Inners: []Inner{
+			{Key: cryptorand.Text(), Value: rand.Float64()},
+			{Key: cryptorand.Text(), Value: rand.Float64()},
+		},
+		Pointer: &Inner{
+			Key:   cryptorand.Text(),
+			Value: rand.Float64(),
+		},
+		Nested: struct {
+			A int64
+			B string
+		}{
+			A: rand.Int64(),
+			B: cryptorand.Text(),
+		},
+		Varint: Varint{
+			Fixed:  rand.Uint64(),
+			Varint: rand.Uint64N(1 << 10),
+			Signed: -rand.Int32N(1 << 10),
+			Slice:  []int{-1, 0, 1, rand.Int()},
+		},
+		OptionalInt:   rand.Int32(),
+		OptionalFloat: rand.Float64(),
+		OptionalSlice: []string{cryptorand.Text()},
+		OptionalMap:   map[string]Score{cryptorand.Text(): Score(rand.Float32())},
+		OptionalInner: &Inner{Key: cryptorand.Text()},
+	}
+}
+
+func BenchmarkEncode(b *testing.B) {
+	buf := new(bytes.Buffer)
+
+	b.Run("generated", func(b *testing.B) {
+		object := newObject()
+
+		for b.Loop() {
+			buf.Reset()
+
+			if err := goc.EncodeTo(buf, object); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("reflection", func(b *testing.B) {
+		object := plainObject(newObject())
+
+		for b.Loop() {
+			buf.Reset()
+
+			if err := goc.EncodeTo(buf, object); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+// TestGeneratedAllocation is not parallel, so it measures the memory allocated by its own decoding only.
+func TestGeneratedAllocation(t *testing.T) {
+	marker := []byte{0xde, 0xad, 0xbe, 0xef}
+
+	d, err := goc.Encode(Object{Bytes: marker})
+	if err != nil {
+		t.Fatalf("Encode: %s", err.Error())
+	}
+
+	// Replace the length of Bytes with 0x7fffffff and cut the payload off after the marker.
+	i := bytes.Index(d, marker)
+	bytesPayload := binary.LittleEndian.AppendUint32(slices.Clone(d[:i-4]), math.MaxInt32)
+	bytesPayload = append(bytesPayload, marker...)
+
+	// Inner starts with its Key string.
+	stringPayload := binary.LittleEndian.AppendUint32(nil, math.MaxInt32)
+	stringPayload = append(stringPayload, marker...)
+
+	for name, decode := range map[string]func() error{
+		"bytes":  func() error { _, err := goc.Decode[Object](bytesPayload); return err },
+		"string": func() error { _, err := goc.Decode[Inner](stringPayload); return err },
+	} {
+		var before, after runtime.MemStats
+
+		runtime.ReadMemStats(&before)
+
+		if err := decode(); !errors.Is(err, io.ErrUnexpectedEOF) {
+			t.Errorf("%s: got error %v, want %v", name, err, io.ErrUnexpectedEOF)
+		}
+
+		runtime.ReadMemStats(&after)
+
+		if allocated := after.TotalAlloc - before.TotalAlloc; allocated > 1<<20 {
+			t.Errorf("%s: decoding allocated %d bytes", name, allocated)
+		}
+	}
+
+	// A length of 0xffffffff does not fit an int on 32-bit platforms.
+	overflow := append(binary.LittleEndian.AppendUint32(nil, math.MaxUint32), marker...)
+
+	if _, err := goc.Decode[Inner](overflow); err == nil || !strings.Contains(err.Error(), "maximum length") {
+		t.Errorf("got error %v, want maximum length exceeded", err)
+	}
+}
+
+func BenchmarkDecode(b *testing.B) {
+	d, err := goc.Encode(newObject())
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	for b.Loop() {
+		if _, err := goc.Decode[Object](d); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
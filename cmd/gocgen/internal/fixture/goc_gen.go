@@ -0,0 +1,2003 @@
+// Code generated by gocgen; DO NOT EDIT.
+
+package fixture
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"slices"
+	"strconv"
+
+	"github.com/samborkent/gorpc/cmd/gocgen/internal/fixture/external"
+	"github.com/samborkent/gorpc/goc"
+)
+
+var (
+	_ goc.EncodeWriter = Embedded{}
+	_ goc.DecodeReader = (*Embedded)(nil)
+	_ goc.EncodeWriter = EmbeddedNumbered{}
+	_ goc.DecodeReader = (*EmbeddedNumbered)(nil)
+	_ goc.EncodeWriter = Inner{}
+	_ goc.DecodeReader = (*Inner)(nil)
+	_ goc.EncodeWriter = List{}
+	_ goc.DecodeReader = (*List)(nil)
+	_ goc.EncodeWriter = Numbered{}
+	_ goc.DecodeReader = (*Numbered)(nil)
+	_ goc.EncodeWriter = NumberedSubset{}
+	_ goc.DecodeReader = (*NumberedSubset)(nil)
+	_ goc.EncodeWriter = Object{}
+	_ goc.DecodeReader = (*Object)(nil)
+	_ goc.EncodeWriter = Stdlib{}
+	_ goc.DecodeReader = (*Stdlib)(nil)
+	_ goc.EncodeWriter = Varint{}
+	_ goc.DecodeReader = (*Varint)(nil)
+)
+
+// EncodeTo implements [goc.EncodeWriter].
+func (x Object) EncodeTo(w io.Writer) error {
+	b, err := x.appendGoc(make([]byte, 0, 64))
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(b)
+	return err
+}
+
+func (x *Object) appendGoc(b []byte) ([]byte, error) {
+	var err error
+
+	if x.Bool {
+		b = append(b, 1)
+	} else {
+		b = append(b, 0)
+	}
+	b = append(b, strconv.IntSize/8)
+	if strconv.IntSize == 32 {
+		b = binary.LittleEndian.AppendUint32(b, uint32(x.Int))
+	} else {
+		b = binary.LittleEndian.AppendUint64(b, uint64(x.Int))
+	}
+	b = append(b, byte(x.Int8))
+	b = binary.LittleEndian.AppendUint16(b, uint16(x.Int16))
+	b = binary.LittleEndian.AppendUint32(b, uint32(x.Int32))
+	b = binary.LittleEndian.AppendUint64(b, uint64(x.Int64))
+	b = append(b, strconv.IntSize/8)
+	if strconv.IntSize == 32 {
+		b = binary.LittleEndian.AppendUint32(b, uint32(x.Uint))
+	} else {
+		b = binary.LittleEndian.AppendUint64(b, uint64(x.Uint))
+	}
+	b = append(b, byte(x.Uint8))
+	b = binary.LittleEndian.AppendUint16(b, uint16(x.Uint16))
+	b = binary.LittleEndian.AppendUint32(b, uint32(x.Uint32))
+	b = binary.LittleEndian.AppendUint64(b, uint64(x.Uint64))
+	b = append(b, strconv.IntSize/8)
+	if strconv.IntSize == 32 {
+		b = binary.LittleEndian.AppendUint32(b, uint32(x.Uintptr))
+	} else {
+		b = binary.LittleEndian.AppendUint64(b, uint64(x.Uintptr))
+	}
+	b = binary.LittleEndian.AppendUint32(b, math.Float32bits(float32(x.Float32)))
+	b = binary.LittleEndian.AppendUint64(b, math.Float64bits(float64(x.Float64)))
+	b = binary.LittleEndian.AppendUint32(b, math.Float32bits(real(complex64(x.Complex64))))
+	b = binary.LittleEndian.AppendUint32(b, math.Float32bits(imag(complex64(x.Complex64))))
+	b = binary.LittleEndian.AppendUint64(b, math.Float64bits(real(complex128(x.Complex128))))
+	b = binary.LittleEndian.AppendUint64(b, math.Float64bits(imag(complex128(x.Complex128))))
+	if len(x.String) > math.MaxInt32 {
+		return nil, fmt.Errorf("encoding Object.String: maximum length of %d exceeded", math.MaxInt32)
+	}
+	b = binary.LittleEndian.AppendUint32(b, uint32(len(x.String)))
+	b = append(b, x.String...)
+	b = binary.LittleEndian.AppendUint64(b, uint64(x.ID))
+	if len(x.Name) > math.MaxInt32 {
+		return nil, fmt.Errorf("encoding Object.Name: maximum length of %d exceeded", math.MaxInt32)
+	}
+	b = binary.LittleEndian.AppendUint32(b, uint32(len(x.Name)))
+	b = append(b, x.Name...)
+	if x.Flag {
+		b = append(b, 1)
+	} else {
+		b = append(b, 0)
+	}
+	if len(x.Blob) > math.MaxInt32 {
+		return nil, fmt.Errorf("encoding Object.Blob: maximum length of %d exceeded", math.MaxInt32)
+	}
+	b = binary.LittleEndian.AppendUint32(b, uint32(len(x.Blob)))
+	b = append(b, x.Blob...)
+	b = binary.LittleEndian.AppendUint32(b, math.Float32bits(float32(x.Score)))
+	if len(x.Bytes) > math.MaxInt32 {
+		return nil, fmt.Errorf("encoding Object.Bytes: maximum length of %d exceeded", math.MaxInt32)
+	}
+	b = binary.LittleEndian.AppendUint32(b, uint32(len(x.Bytes)))
+	b = append(b, x.Bytes...)
+	b = append(b, x.Array[:]...)
+	b = append(b, x.UUID[:]...)
+	for i1 := range x.Octets {
+		b = append(b, byte(x.Octets[i1]))
+	}
+	b = binary.LittleEndian.AppendUint32(b, uint32(len(x.Matrix)))
+	for i2 := range x.Matrix {
+		b = binary.LittleEndian.AppendUint32(b, uint32(len(x.Matrix[i2])))
+		for i3 := range x.Matrix[i2] {
+			b = binary.LittleEndian.AppendUint16(b, uint16(x.Matrix[i2][i3]))
+		}
+	}
+	if len(x.Slice) > math.MaxInt32 {
+		return nil, fmt.Errorf("encoding Object.Slice: maximum length of %d exceeded", math.MaxInt32)
+	}
+	b = binary.LittleEndian.AppendUint32(b, uint32(len(x.Slice)))
+	for i4 := range x.Slice {
+		b = binary.LittleEndian.AppendUint32(b, uint32(x.Slice[i4]))
+	}
+	if len(x.Names) > math.MaxInt32 {
+		return nil, fmt.Errorf("encoding Object.Names: maximum length of %d exceeded", math.MaxInt32)
+	}
+	b = binary.LittleEndian.AppendUint32(b, uint32(len(x.Names)))
+	for i5 := range x.Names {
+		if len(x.Names[i5]) > math.MaxInt32 {
+			return nil, fmt.Errorf("encoding Object.Names[]: maximum length of %d exceeded", math.MaxInt32)
+		}
+		b = binary.LittleEndian.AppendUint32(b, uint32(len(x.Names[i5])))
+		b = append(b, x.Names[i5]...)
+	}
+	if len(x.Map) > math.MaxInt32 {
+		return nil, fmt.Errorf("encoding Object.Map: maximum length of %d exceeded", math.MaxInt32)
+	}
+	b = binary.LittleEndian.AppendUint32(b, uint32(len(x.Map)))
+	for k6, v7 := range x.Map {
+		if len(k6) > math.MaxInt32 {
+			return nil, fmt.Errorf("encoding Object.Map[key]: maximum length of %d exceeded", math.MaxInt32)
+		}
+		b = binary.LittleEndian.AppendUint32(b, uint32(len(k6)))
+		b = append(b, k6...)
+		if len(v7) > math.MaxInt32 {
+			return nil, fmt.Errorf("encoding Object.Map[]: maximum length of %d exceeded", math.MaxInt32)
+		}
+		b = binary.LittleEndian.AppendUint32(b, uint32(len(v7)))
+		b = append(b, v7...)
+	}
+	if len(x.Scores) > math.MaxInt32 {
+		return nil, fmt.Errorf("encoding Object.Scores: maximum length of %d exceeded", math.MaxInt32)
+	}
+	b = binary.LittleEndian.AppendUint32(b, uint32(len(x.Scores)))
+	for k8, v9 := range x.Scores {
+		b = binary.LittleEndian.AppendUint64(b, uint64(k8))
+		if len(v9) > math.MaxInt32 {
+			return nil, fmt.Errorf("encoding Object.Scores[]: maximum length of %d exceeded", math.MaxInt32)
+		}
+		b = binary.LittleEndian.AppendUint32(b, uint32(len(v9)))
+		for i10 := range v9 {
+			b = binary.LittleEndian.AppendUint32(b, math.Float32bits(float32(v9[i10])))
+		}
+	}
+	if b, err = x.Inner.appendGoc(b); err != nil {
+		return nil, err
+	}
+	if len(x.Inners) > math.MaxInt32 {
+		return nil, fmt.Errorf("encoding Object.Inners: maximum length of %d exceeded", math.MaxInt32)
+	}
+	b = binary.LittleEndian.AppendUint32(b, uint32(len(x.Inners)))
+	for i11 := range x.Inners {
+		if b, err = x.Inners[i11].appendGoc(b); err != nil {
+			return nil, err
+		}
+	}
+	if x.Pointer == nil {
+		b = append(b, 0)
+	} else {
+		b = append(b, 1)
+		if b, err = (*x.Pointer).appendGoc(b); err != nil {
+			return nil, err
+		}
+	}
+	b = binary.LittleEndian.AppendUint64(b, uint64(x.Nested.A))
+	if len(x.Nested.B) > math.MaxInt32 {
+		return nil, fmt.Errorf("encoding Object.Nested.B: maximum length of %d exceeded", math.MaxInt32)
+	}
+	b = binary.LittleEndian.AppendUint32(b, uint32(len(x.Nested.B)))
+	b = append(b, x.Nested.B...)
+	if b, err = x.Varint.appendGoc(b); err != nil {
+		return nil, err
+	}
+	if x.OptionalInt == 0 {
+		b = append(b, 0)
+	} else {
+		b = append(b, 1)
+		b = binary.LittleEndian.AppendUint32(b, uint32(x.OptionalInt))
+	}
+	if math.Float64bits(float64(x.OptionalFloat)) == 0 {
+		b = append(b, 0)
+	} else {
+		b = append(b, 1)
+		b = binary.LittleEndian.AppendUint64(b, math.Float64bits(float64(x.OptionalFloat)))
+	}
+	if len(x.OptionalSlice) == 0 {
+		b = append(b, 0)
+	} else {
+		b = append(b, 1)
+		if len(x.OptionalSlice) > math.MaxInt32 {
+			return nil, fmt.Errorf("encoding Object.OptionalSlice: maximum length of %d exceeded", math.MaxInt32)
+		}
+		b = binary.LittleEndian.AppendUint32(b, uint32(len(x.OptionalSlice)))
+		for i12 := range x.OptionalSlice {
+			if len(x.OptionalSlice[i12]) > math.MaxInt32 {
+				return nil, fmt.Errorf("encoding Object.OptionalSlice[]: maximum length of %d exceeded", math.MaxInt32)
+			}
+			b = binary.LittleEndian.AppendUint32(b, uint32(len(x.OptionalSlice[i12])))
+			b = append(b, x.OptionalSlice[i12]...)
+		}
+	}
+	if len(x.OptionalMap) == 0 {
+		b = append(b, 0)
+	} else {
+		b = append(b, 1)
+		if len(x.OptionalMap) > math.MaxInt32 {
+			return nil, fmt.Errorf("encoding Object.OptionalMap: maximum length of %d exceeded", math.MaxInt32)
+		}
+		b = binary.LittleEndian.AppendUint32(b, uint32(len(x.OptionalMap)))
+		for k13, v14 := range x.OptionalMap {
+			if len(k13) > math.MaxInt32 {
+				return nil, fmt.Errorf("encoding Object.OptionalMap[key]: maximum length of %d exceeded", math.MaxInt32)
+			}
+			b = binary.LittleEndian.AppendUint32(b, uint32(len(k13)))
+			b = append(b, k13...)
+			b = binary.LittleEndian.AppendUint32(b, math.Float32bits(float32(v14)))
+		}
+	}
+	if x.OptionalInner == nil {
+		b = append(b, 0)
+	} else {
+		b = append(b, 1)
+		if x.OptionalInner == nil {
+			b = append(b, 0)
+		} else {
+			b = append(b, 1)
+			if b, err = (*x.OptionalInner).appendGoc(b); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return b, nil
+}
+
+// DecodeFrom implements [goc.DecodeReader].
+func (x *Object) DecodeFrom(r io.Reader) error {
+	var buf [16]byte
+
+	if err := goc.ReadFull(r, buf[:1]); err != nil {
+		return fmt.Errorf("decoding Object.Bool: %w", err)
+	}
+	if err := goc.CheckBool(r, buf[0]); err != nil {
+		return fmt.Errorf("decoding Object.Bool: %w", err)
+	}
+	x.Bool = buf[0] != 0
+	if err := goc.ReadFull(r, buf[:1]); err != nil {
+		return fmt.Errorf("decoding Object.Int header: %w", err)
+	}
+	switch buf[0] {
+	case 4:
+		if err := goc.ReadFull(r, buf[:4]); err != nil {
+			return fmt.Errorf("decoding Object.Int: %w", err)
+		}
+		x.Int = int(int32(binary.LittleEndian.Uint32(buf[:4])))
+	case 8:
+		if err := goc.ReadFull(r, buf[:8]); err != nil {
+			return fmt.Errorf("decoding Object.Int: %w", err)
+		}
+		{
+			i1, err := goc.ToInt(int64(binary.LittleEndian.Uint64(buf[:8])))
+			if err != nil {
+				return fmt.Errorf("decoding Object.Int: %w", err)
+			}
+			x.Int = i1
+		}
+	default:
+		return fmt.Errorf("decoding Object.Int: %w: unknown int size %d encountered", goc.ErrInvalidIntSize, buf[0])
+	}
+	if err := goc.ReadFull(r, buf[:1]); err != nil {
+		return fmt.Errorf("decoding Object.Int8: %w", err)
+	}
+	x.Int8 = int8(buf[0])
+	if err := goc.ReadFull(r, buf[:2]); err != nil {
+		return fmt.Errorf("decoding Object.Int16: %w", err)
+	}
+	x.Int16 = int16(binary.LittleEndian.Uint16(buf[:2]))
+	if err := goc.ReadFull(r, buf[:4]); err != nil {
+		return fmt.Errorf("decoding Object.Int32: %w", err)
+	}
+	x.Int32 = int32(binary.LittleEndian.Uint32(buf[:4]))
+	if err := goc.ReadFull(r, buf[:8]); err != nil {
+		return fmt.Errorf("decoding Object.Int64: %w", err)
+	}
+	x.Int64 = int64(binary.LittleEndian.Uint64(buf[:8]))
+	if err := goc.ReadFull(r, buf[:1]); err != nil {
+		return fmt.Errorf("decoding Object.Uint header: %w", err)
+	}
+	switch buf[0] {
+	case 4:
+		if err := goc.ReadFull(r, buf[:4]); err != nil {
+			return fmt.Errorf("decoding Object.Uint: %w", err)
+		}
+		x.Uint = uint(binary.LittleEndian.Uint32(buf[:4]))
+	case 8:
+		if err := goc.ReadFull(r, buf[:8]); err != nil {
+			return fmt.Errorf("decoding Object.Uint: %w", err)
+		}
+		{
+			i2, err := goc.ToUint(binary.LittleEndian.Uint64(buf[:8]))
+			if err != nil {
+				return fmt.Errorf("decoding Object.Uint: %w", err)
+			}
+			x.Uint = i2
+		}
+	default:
+		return fmt.Errorf("decoding Object.Uint: %w: unknown uint size %d encountered", goc.ErrInvalidIntSize, buf[0])
+	}
+	if err := goc.ReadFull(r, buf[:1]); err != nil {
+		return fmt.Errorf("decoding Object.Uint8: %w", err)
+	}
+	x.Uint8 = buf[0]
+	if err := goc.ReadFull(r, buf[:2]); err != nil {
+		return fmt.Errorf("decoding Object.Uint16: %w", err)
+	}
+	x.Uint16 = binary.LittleEndian.Uint16(buf[:2])
+	if err := goc.ReadFull(r, buf[:4]); err != nil {
+		return fmt.Errorf("decoding Object.Uint32: %w", err)
+	}
+	x.Uint32 = binary.LittleEndian.Uint32(buf[:4])
+	if err := goc.ReadFull(r, buf[:8]); err != nil {
+		return fmt.Errorf("decoding Object.Uint64: %w", err)
+	}
+	x.Uint64 = binary.LittleEndian.Uint64(buf[:8])
+	if err := goc.ReadFull(r, buf[:1]); err != nil {
+		return fmt.Errorf("decoding Object.Uintptr header: %w", err)
+	}
+	switch buf[0] {
+	case 4:
+		if err := goc.ReadFull(r, buf[:4]); err != nil {
+			return fmt.Errorf("decoding Object.Uintptr: %w", err)
+		}
+		x.Uintptr = uintptr(binary.LittleEndian.Uint32(buf[:4]))
+	case 8:
+		if err := goc.ReadFull(r, buf[:8]); err != nil {
+			return fmt.Errorf("decoding Object.Uintptr: %w", err)
+		}
+		{
+			i3, err := goc.ToUintptr(binary.LittleEndian.Uint64(buf[:8]))
+			if err != nil {
+				return fmt.Errorf("decoding Object.Uintptr: %w", err)
+			}
+			x.Uintptr = i3
+		}
+	default:
+		return fmt.Errorf("decoding Object.Uintptr: %w: unknown uintptr size %d encountered", goc.ErrInvalidIntSize, buf[0])
+	}
+	if err := goc.ReadFull(r, buf[:4]); err != nil {
+		return fmt.Errorf("decoding Object.Float32: %w", err)
+	}
+	x.Float32 = math.Float32frombits(binary.LittleEndian.Uint32(buf[:4]))
+	if err := goc.ReadFull(r, buf[:8]); err != nil {
+		return fmt.Errorf("decoding Object.Float64: %w", err)
+	}
+	x.Float64 = math.Float64frombits(binary.LittleEndian.Uint64(buf[:8]))
+	if err := goc.ReadFull(r, buf[:8]); err != nil {
+		return fmt.Errorf("decoding Object.Complex64: %w", err)
+	}
+	x.Complex64 = complex(math.Float32frombits(binary.LittleEndian.Uint32(buf[:4])), math.Float32frombits(binary.LittleEndian.Uint32(buf[4:8])))
+	if err := goc.ReadFull(r, buf[:16]); err != nil {
+		return fmt.Errorf("decoding Object.Complex128: %w", err)
+	}
+	x.Complex128 = complex(math.Float64frombits(binary.LittleEndian.Uint64(buf[:8])), math.Float64frombits(binary.LittleEndian.Uint64(buf[8:16])))
+	{
+		if err := goc.ReadFull(r, buf[:4]); err != nil {
+			return fmt.Errorf("decoding Object.String length: %w", err)
+		}
+		u5 := binary.LittleEndian.Uint32(buf[:4])
+		if u5 > math.MaxInt32 {
+			return fmt.Errorf("decoding Object.String: maximum length of %d exceeded", math.MaxInt32)
+		}
+		n4 := int(u5)
+		if err := goc.CheckStringLength(r, n4); err != nil {
+			return fmt.Errorf("decoding Object.String: %w", err)
+		}
+		s6, err := goc.ReadAppend(r, nil, n4)
+		if err != nil {
+			return fmt.Errorf("decoding Object.String: %w", err)
+		}
+		if err := goc.CheckUTF8(r, s6); err != nil {
+			return fmt.Errorf("decoding Object.String: %w", err)
+		}
+		x.String = string(s6)
+	}
+	if err := goc.ReadFull(r, buf[:8]); err != nil {
+		return fmt.Errorf("decoding Object.ID: %w", err)
+	}
+	x.ID = ID(binary.LittleEndian.Uint64(buf[:8]))
+	{
+		if err := goc.ReadFull(r, buf[:4]); err != nil {
+			return fmt.Errorf("decoding Object.Name length: %w", err)
+		}
+		u8 := binary.LittleEndian.Uint32(buf[:4])
+		if u8 > math.MaxInt32 {
+			return fmt.Errorf("decoding Object.Name: maximum length of %d exceeded", math.MaxInt32)
+		}
+		n7 := int(u8)
+		if err := goc.CheckStringLength(r, n7); err != nil {
+			return fmt.Errorf("decoding Object.Name: %w", err)
+		}
+		s9, err := goc.ReadAppend(r, nil, n7)
+		if err != nil {
+			return fmt.Errorf("decoding Object.Name: %w", err)
+		}
+		if err := goc.CheckUTF8(r, s9); err != nil {
+			return fmt.Errorf("decoding Object.Name: %w", err)
+		}
+		x.Name = Name(string(s9))
+	}
+	if err := goc.ReadFull(r, buf[:1]); err != nil {
+		return fmt.Errorf("decoding Object.Flag: %w", err)
+	}
+	if err := goc.CheckBool(r, buf[0]); err != nil {
+		return fmt.Errorf("decoding Object.Flag: %w", err)
+	}
+	x.Flag = Flag(buf[0] != 0)
+	{
+		if err := goc.ReadFull(r, buf[:4]); err != nil {
+			return fmt.Errorf("decoding Object.Blob length: %w", err)
+		}
+		u11 := binary.LittleEndian.Uint32(buf[:4])
+		if u11 > math.MaxInt32 {
+			return fmt.Errorf("decoding Object.Blob: maximum length of %d exceeded", math.MaxInt32)
+		}
+		n10 := int(u11)
+		if err := goc.CheckLength(r, n10); err != nil {
+			return fmt.Errorf("decoding Object.Blob: %w", err)
+		}
+		b12, err := goc.ReadAppend(r, x.Blob[:0], n10)
+		if err != nil {
+			return fmt.Errorf("decoding Object.Blob: %w", err)
+		}
+		x.Blob = b12
+	}
+	if err := goc.ReadFull(r, buf[:4]); err != nil {
+		return fmt.Errorf("decoding Object.Score: %w", err)
+	}
+	x.Score = Score(math.Float32frombits(binary.LittleEndian.Uint32(buf[:4])))
+	{
+		if err := goc.ReadFull(r, buf[:4]); err != nil {
+			return fmt.Errorf("decoding Object.Bytes length: %w", err)
+		}
+		u14 := binary.LittleEndian.Uint32(buf[:4])
+		if u14 > math.MaxInt32 {
+			return fmt.Errorf("decoding Object.Bytes: maximum length of %d exceeded", math.MaxInt32)
+		}
+		n13 := int(u14)
+		if err := goc.CheckLength(r, n13); err != nil {
+			return fmt.Errorf("decoding Object.Bytes: %w", err)
+		}
+		b15, err := goc.ReadAppend(r, x.Bytes[:0], n13)
+		if err != nil {
+			return fmt.Errorf("decoding Object.Bytes: %w", err)
+		}
+		x.Bytes = b15
+	}
+	if err := goc.ReadFull(r, x.Array[:]); err != nil {
+		return fmt.Errorf("decoding Object.Array: %w", err)
+	}
+	if err := goc.ReadFull(r, x.UUID[:]); err != nil {
+		return fmt.Errorf("decoding Object.UUID: %w", err)
+	}
+	for i16 := range x.Octets {
+		if err := goc.ReadFull(r, buf[:1]); err != nil {
+			return fmt.Errorf("decoding Object.Octets[]: %w", err)
+		}
+		x.Octets[i16] = Octet(buf[0])
+	}
+	{
+		if err := goc.ReadFull(r, buf[:4]); err != nil {
+			return fmt.Errorf("decoding Object.Matrix length: %w", err)
+		}
+		u18 := binary.LittleEndian.Uint32(buf[:4])
+		if u18 > math.MaxInt32 {
+			return fmt.Errorf("decoding Object.Matrix: maximum length of %d exceeded", math.MaxInt32)
+		}
+		n17 := int(u18)
+		if n17 > 2 {
+			return fmt.Errorf("decoding Object.Matrix: length %d exceeds array length 2", n17)
+		}
+		for i19 := range n17 {
+			{
+				if err := goc.ReadFull(r, buf[:4]); err != nil {
+					return fmt.Errorf("decoding Object.Matrix[] length: %w", err)
+				}
+				u21 := binary.LittleEndian.Uint32(buf[:4])
+				if u21 > math.MaxInt32 {
+					return fmt.Errorf("decoding Object.Matrix[]: maximum length of %d exceeded", math.MaxInt32)
+				}
+				n20 := int(u21)
+				if n20 > 3 {
+					return fmt.Errorf("decoding Object.Matrix[]: length %d exceeds array length 3", n20)
+				}
+				for i22 := range n20 {
+					if err := goc.ReadFull(r, buf[:2]); err != nil {
+						return fmt.Errorf("decoding Object.Matrix[][]: %w", err)
+					}
+					x.Matrix[i19][i22] = int16(binary.LittleEndian.Uint16(buf[:2]))
+				}
+				clear(x.Matrix[i19][n20:])
+			}
+		}
+		clear(x.Matrix[n17:])
+	}
+	{
+		if err := goc.ReadFull(r, buf[:4]); err != nil {
+			return fmt.Errorf("decoding Object.Slice length: %w", err)
+		}
+		u24 := binary.LittleEndian.Uint32(buf[:4])
+		if u24 > math.MaxInt32 {
+			return fmt.Errorf("decoding Object.Slice: maximum length of %d exceeded", math.MaxInt32)
+		}
+		n23 := int(u24)
+		if err := goc.CheckLength(r, n23); err != nil {
+			return fmt.Errorf("decoding Object.Slice: %w", err)
+		}
+		x.Slice = slices.Grow(x.Slice[:0], goc.PreallocLen[int32](n23))
+		for i25 := range n23 {
+			if i25 == cap(x.Slice) {
+				x.Slice = slices.Grow(x.Slice, min(i25, n23-i25))
+			}
+			x.Slice = x.Slice[:i25+1]
+			if err := goc.ReadFull(r, buf[:4]); err != nil {
+				return fmt.Errorf("decoding Object.Slice[]: %w", err)
+			}
+			x.Slice[i25] = int32(binary.LittleEndian.Uint32(buf[:4]))
+		}
+	}
+	{
+		if err := goc.ReadFull(r, buf[:4]); err != nil {
+			return fmt.Errorf("decoding Object.Names length: %w", err)
+		}
+		u27 := binary.LittleEndian.Uint32(buf[:4])
+		if u27 > math.MaxInt32 {
+			return fmt.Errorf("decoding Object.Names: maximum length of %d exceeded", math.MaxInt32)
+		}
+		n26 := int(u27)
+		if err := goc.CheckLength(r, n26); err != nil {
+			return fmt.Errorf("decoding Object.Names: %w", err)
+		}
+		x.Names = slices.Grow(x.Names[:0], goc.PreallocLen[Name](n26))
+		for i28 := range n26 {
+			if i28 == cap(x.Names) {
+				x.Names = slices.Grow(x.Names, min(i28, n26-i28))
+			}
+			x.Names = x.Names[:i28+1]
+			{
+				if err := goc.ReadFull(r, buf[:4]); err != nil {
+					return fmt.Errorf("decoding Object.Names[] length: %w", err)
+				}
+				u30 := binary.LittleEndian.Uint32(buf[:4])
+				if u30 > math.MaxInt32 {
+					return fmt.Errorf("decoding Object.Names[]: maximum length of %d exceeded", math.MaxInt32)
+				}
+				n29 := int(u30)
+				if err := goc.CheckStringLength(r, n29); err != nil {
+					return fmt.Errorf("decoding Object.Names[]: %w", err)
+				}
+				s31, err := goc.ReadAppend(r, nil, n29)
+				if err != nil {
+					return fmt.Errorf("decoding Object.Names[]: %w", err)
+				}
+				if err := goc.CheckUTF8(r, s31); err != nil {
+					return fmt.Errorf("decoding Object.Names[]: %w", err)
+				}
+				x.Names[i28] = Name(string(s31))
+			}
+		}
+	}
+	{
+		if err := goc.ReadFull(r, buf[:4]); err != nil {
+			return fmt.Errorf("decoding Object.Map length: %w", err)
+		}
+		u33 := binary.LittleEndian.Uint32(buf[:4])
+		if u33 > math.MaxInt32 {
+			return fmt.Errorf("decoding Object.Map: maximum length of %d exceeded", math.MaxInt32)
+		}
+		n32 := int(u33)
+		if err := goc.CheckLength(r, n32); err != nil {
+			return fmt.Errorf("decoding Object.Map: %w", err)
+		}
+		clear(x.Map)
+		if x.Map == nil && n32 > 0 {
+			x.Map = make(map[string]string, goc.PreallocMapLen[string, string](n32))
+		}
+		for range n32 {
+			var k34 string
+			{
+				if err := goc.ReadFull(r, buf[:4]); err != nil {
+					return fmt.Errorf("decoding Object.Map[key] length: %w", err)
+				}
+				u37 := binary.LittleEndian.Uint32(buf[:4])
+				if u37 > math.MaxInt32 {
+					return fmt.Errorf("decoding Object.Map[key]: maximum length of %d exceeded", math.MaxInt32)
+				}
+				n36 := int(u37)
+				if err := goc.CheckStringLength(r, n36); err != nil {
+					return fmt.Errorf("decoding Object.Map[key]: %w", err)
+				}
+				s38, err := goc.ReadAppend(r, nil, n36)
+				if err != nil {
+					return fmt.Errorf("decoding Object.Map[key]: %w", err)
+				}
+				if err := goc.CheckUTF8(r, s38); err != nil {
+					return fmt.Errorf("decoding Object.Map[key]: %w", err)
+				}
+				k34 = string(s38)
+			}
+			var v35 string
+			{
+				if err := goc.ReadFull(r, buf[:4]); err != nil {
+					return fmt.Errorf("decoding Object.Map[] length: %w", err)
+				}
+				u40 := binary.LittleEndian.Uint32(buf[:4])
+				if u40 > math.MaxInt32 {
+					return fmt.Errorf("decoding Object.Map[]: maximum length of %d exceeded", math.MaxInt32)
+				}
+				n39 := int(u40)
+				if err := goc.CheckStringLength(r, n39); err != nil {
+					return fmt.Errorf("decoding Object.Map[]: %w", err)
+				}
+				s41, err := goc.ReadAppend(r, nil, n39)
+				if err != nil {
+					return fmt.Errorf("decoding Object.Map[]: %w", err)
+				}
+				if err := goc.CheckUTF8(r, s41); err != nil {
+					return fmt.Errorf("decoding Object.Map[]: %w", err)
+				}
+				v35 = string(s41)
+			}
+			x.Map[k34] = v35
+		}
+		if err := goc.CheckDuplicateKey(r, len(x.Map) != n32); err != nil {
+			return fmt.Errorf("decoding Object.Map: %w", err)
+		}
+	}
+	{
+		if err := goc.ReadFull(r, buf[:4]); err != nil {
+			return fmt.Errorf("decoding Object.Scores length: %w", err)
+		}
+		u43 := binary.LittleEndian.Uint32(buf[:4])
+		if u43 > math.MaxInt32 {
+			return fmt.Errorf("decoding Object.Scores: maximum length of %d exceeded", math.MaxInt32)
+		}
+		n42 := int(u43)
+		if err := goc.CheckLength(r, n42); err != nil {
+			return fmt.Errorf("decoding Object.Scores: %w", err)
+		}
+		clear(x.Scores)
+		if x.Scores == nil && n42 > 0 {
+			x.Scores = make(map[ID][]Score, goc.PreallocMapLen[ID, []Score](n42))
+		}
+		for range n42 {
+			var k44 ID
+			if err := goc.ReadFull(r, buf[:8]); err != nil {
+				return fmt.Errorf("decoding Object.Scores[key]: %w", err)
+			}
+			k44 = ID(binary.LittleEndian.Uint64(buf[:8]))
+			var v45 []Score
+			{
+				if err := goc.ReadFull(r, buf[:4]); err != nil {
+					return fmt.Errorf("decoding Object.Scores[] length: %w", err)
+				}
+				u47 := binary.LittleEndian.Uint32(buf[:4])
+				if u47 > math.MaxInt32 {
+					return fmt.Errorf("decoding Object.Scores[]: maximum length of %d exceeded", math.MaxInt32)
+				}
+				n46 := int(u47)
+				if err := goc.CheckLength(r, n46); err != nil {
+					return fmt.Errorf("decoding Object.Scores[]: %w", err)
+				}
+				v45 = slices.Grow(v45[:0], goc.PreallocLen[Score](n46))
+				for i48 := range n46 {
+					if i48 == cap(v45) {
+						v45 = slices.Grow(v45, min(i48, n46-i48))
+					}
+					v45 = v45[:i48+1]
+					if err := goc.ReadFull(r, buf[:4]); err != nil {
+						return fmt.Errorf("decoding Object.Scores[][]: %w", err)
+					}
+					v45[i48] = Score(math.Float32frombits(binary.LittleEndian.Uint32(buf[:4])))
+				}
+			}
+			x.Scores[k44] = v45
+		}
+		if err := goc.CheckDuplicateKey(r, len(x.Scores) != n42); err != nil {
+			return fmt.Errorf("decoding Object.Scores: %w", err)
+		}
+	}
+	if err := x.Inner.DecodeFrom(r); err != nil {
+		return fmt.Errorf("decoding Object.Inner: %w", err)
+	}
+	{
+		if err := goc.ReadFull(r, buf[:4]); err != nil {
+			return fmt.Errorf("decoding Object.Inners length: %w", err)
+		}
+		u50 := binary.LittleEndian.Uint32(buf[:4])
+		if u50 > math.MaxInt32 {
+			return fmt.Errorf("decoding Object.Inners: maximum length of %d exceeded", math.MaxInt32)
+		}
+		n49 := int(u50)
+		if err := goc.CheckLength(r, n49); err != nil {
+			return fmt.Errorf("decoding Object.Inners: %w", err)
+		}
+		x.Inners = slices.Grow(x.Inners[:0], goc.PreallocLen[Inner](n49))
+		for i51 := range n49 {
+			if i51 == cap(x.Inners) {
+				x.Inners = slices.Grow(x.Inners, min(i51, n49-i51))
+			}
+			x.Inners = x.Inners[:i51+1]
+			if err := x.Inners[i51].DecodeFrom(r); err != nil {
+				return fmt.Errorf("decoding Object.Inners[]: %w", err)
+			}
+		}
+	}
+	if err := goc.ReadFull(r, buf[:1]); err != nil {
+		return fmt.Errorf("decoding Object.Pointer presence: %w", err)
+	}
+	switch buf[0] {
+	case 0:
+		x.Pointer = nil
+	case 1:
+		if x.Pointer == nil {
+			x.Pointer = new(Inner)
+		}
+		if err := (*x.Pointer).DecodeFrom(r); err != nil {
+			return fmt.Errorf("decoding Object.Pointer: %w", err)
+		}
+	default:
+		return fmt.Errorf("decoding Object.Pointer: invalid presence marker %d", buf[0])
+	}
+	if err := goc.ReadFull(r, buf[:8]); err != nil {
+		return fmt.Errorf("decoding Object.Nested.A: %w", err)
+	}
+	x.Nested.A = int64(binary.LittleEndian.Uint64(buf[:8]))
+	{
+		if err := goc.ReadFull(r, buf[:4]); err != nil {
+			return fmt.Errorf("decoding Object.Nested.B length: %w", err)
+		}
+		u53 := binary.LittleEndian.Uint32(buf[:4])
+		if u53 > math.MaxInt32 {
+			return fmt.Errorf("decoding Object.Nested.B: maximum length of %d exceeded", math.MaxInt32)
+		}
+		n52 := int(u53)
+		if err := goc.CheckStringLength(r, n52); err != nil {
+			return fmt.Errorf("decoding Object.Nested.B: %w", err)
+		}
+		s54, err := goc.ReadAppend(r, nil, n52)
+		if err != nil {
+			return fmt.Errorf("decoding Object.Nested.B: %w", err)
+		}
+		if err := goc.CheckUTF8(r, s54); err != nil {
+			return fmt.Errorf("decoding Object.Nested.B: %w", err)
+		}
+		x.Nested.B = string(s54)
+	}
+	if err := x.Varint.DecodeFrom(r); err != nil {
+		return fmt.Errorf("decoding Object.Varint: %w", err)
+	}
+	if err := goc.ReadFull(r, buf[:1]); err != nil {
+		return fmt.Errorf("decoding Object.OptionalInt presence: %w", err)
+	}
+	switch buf[0] {
+	case 0:
+		x.OptionalInt = 0
+	case 1:
+		if err := goc.ReadFull(r, buf[:4]); err != nil {
+			return fmt.Errorf("decoding Object.OptionalInt: %w", err)
+		}
+		x.OptionalInt = int32(binary.LittleEndian.Uint32(buf[:4]))
+	default:
+		return fmt.Errorf("decoding Object.OptionalInt: invalid presence marker %d", buf[0])
+	}
+	if err := goc.ReadFull(r, buf[:1]); err != nil {
+		return fmt.Errorf("decoding Object.optionalFloat presence: %w", err)
+	}
+	switch buf[0] {
+	case 0:
+		x.OptionalFloat = 0
+	case 1:
+		if err := goc.ReadFull(r, buf[:8]); err != nil {
+			return fmt.Errorf("decoding Object.optionalFloat: %w", err)
+		}
+		x.OptionalFloat = math.Float64frombits(binary.LittleEndian.Uint64(buf[:8]))
+	default:
+		return fmt.Errorf("decoding Object.optionalFloat: invalid presence marker %d", buf[0])
+	}
+	if err := goc.ReadFull(r, buf[:1]); err != nil {
+		return fmt.Errorf("decoding Object.OptionalSlice presence: %w", err)
+	}
+	switch buf[0] {
+	case 0:
+		x.OptionalSlice = nil
+	case 1:
+		{
+			if err := goc.ReadFull(r, buf[:4]); err != nil {
+				return fmt.Errorf("decoding Object.OptionalSlice length: %w", err)
+			}
+			u56 := binary.LittleEndian.Uint32(buf[:4])
+			if u56 > math.MaxInt32 {
+				return fmt.Errorf("decoding Object.OptionalSlice: maximum length of %d exceeded", math.MaxInt32)
+			}
+			n55 := int(u56)
+			if err := goc.CheckLength(r, n55); err != nil {
+				return fmt.Errorf("decoding Object.OptionalSlice: %w", err)
+			}
+			x.OptionalSlice = slices.Grow(x.OptionalSlice[:0], goc.PreallocLen[string](n55))
+			for i57 := range n55 {
+				if i57 == cap(x.OptionalSlice) {
+					x.OptionalSlice = slices.Grow(x.OptionalSlice, min(i57, n55-i57))
+				}
+				x.OptionalSlice = x.OptionalSlice[:i57+1]
+				{
+					if err := goc.ReadFull(r, buf[:4]); err != nil {
+						return fmt.Errorf("decoding Object.OptionalSlice[] length: %w", err)
+					}
+					u59 := binary.LittleEndian.Uint32(buf[:4])
+					if u59 > math.MaxInt32 {
+						return fmt.Errorf("decoding Object.OptionalSlice[]: maximum length of %d exceeded", math.MaxInt32)
+					}
+					n58 := int(u59)
+					if err := goc.CheckStringLength(r, n58); err != nil {
+						return fmt.Errorf("decoding Object.OptionalSlice[]: %w", err)
+					}
+					s60, err := goc.ReadAppend(r, nil, n58)
+					if err != nil {
+						return fmt.Errorf("decoding Object.OptionalSlice[]: %w", err)
+					}
+					if err := goc.CheckUTF8(r, s60); err != nil {
+						return fmt.Errorf("decoding Object.OptionalSlice[]: %w", err)
+					}
+					x.OptionalSlice[i57] = string(s60)
+				}
+			}
+		}
+	default:
+		return fmt.Errorf("decoding Object.OptionalSlice: invalid presence marker %d", buf[0])
+	}
+	if err := goc.ReadFull(r, buf[:1]); err != nil {
+		return fmt.Errorf("decoding Object.OptionalMap presence: %w", err)
+	}
+	switch buf[0] {
+	case 0:
+		x.OptionalMap = nil
+	case 1:
+		{
+			if err := goc.ReadFull(r, buf[:4]); err != nil {
+				return fmt.Errorf("decoding Object.OptionalMap length: %w", err)
+			}
+			u62 := binary.LittleEndian.Uint32(buf[:4])
+			if u62 > math.MaxInt32 {
+				return fmt.Errorf("decoding Object.OptionalMap: maximum length of %d exceeded", math.MaxInt32)
+			}
+			n61 := int(u62)
+			if err := goc.CheckLength(r, n61); err != nil {
+				return fmt.Errorf("decoding Object.OptionalMap: %w", err)
+			}
+			clear(x.OptionalMap)
+			if x.OptionalMap == nil && n61 > 0 {
+				x.OptionalMap = make(map[string]Score, goc.PreallocMapLen[string, Score](n61))
+			}
+			for range n61 {
+				var k63 string
+				{
+					if err := goc.ReadFull(r, buf[:4]); err != nil {
+						return fmt.Errorf("decoding Object.OptionalMap[key] length: %w", err)
+					}
+					u66 := binary.LittleEndian.Uint32(buf[:4])
+					if u66 > math.MaxInt32 {
+						return fmt.Errorf("decoding Object.OptionalMap[key]: maximum length of %d exceeded", math.MaxInt32)
+					}
+					n65 := int(u66)
+					if err := goc.CheckStringLength(r, n65); err != nil {
+						return fmt.Errorf("decoding Object.OptionalMap[key]: %w", err)
+					}
+					s67, err := goc.ReadAppend(r, nil, n65)
+					if err != nil {
+						return fmt.Errorf("decoding Object.OptionalMap[key]: %w", err)
+					}
+					if err := goc.CheckUTF8(r, s67); err != nil {
+						return fmt.Errorf("decoding Object.OptionalMap[key]: %w", err)
+					}
+					k63 = string(s67)
+				}
+				var v64 Score
+				if err := goc.ReadFull(r, buf[:4]); err != nil {
+					return fmt.Errorf("decoding Object.OptionalMap[]: %w", err)
+				}
+				v64 = Score(math.Float32frombits(binary.LittleEndian.Uint32(buf[:4])))
+				x.OptionalMap[k63] = v64
+			}
+			if err := goc.CheckDuplicateKey(r, len(x.OptionalMap) != n61); err != nil {
+				return fmt.Errorf("decoding Object.OptionalMap: %w", err)
+			}
+		}
+	default:
+		return fmt.Errorf("decoding Object.OptionalMap: invalid presence marker %d", buf[0])
+	}
+	if err := goc.ReadFull(r, buf[:1]); err != nil {
+		return fmt.Errorf("decoding Object.OptionalInner presence: %w", err)
+	}
+	switch buf[0] {
+	case 0:
+		x.OptionalInner = nil
+	case 1:
+		if err := goc.ReadFull(r, buf[:1]); err != nil {
+			return fmt.Errorf("decoding Object.OptionalInner presence: %w", err)
+		}
+		switch buf[0] {
+		case 0:
+			x.OptionalInner = nil
+		case 1:
+			if x.OptionalInner == nil {
+				x.OptionalInner = new(Inner)
+			}
+			if err := (*x.OptionalInner).DecodeFrom(r); err != nil {
+				return fmt.Errorf("decoding Object.OptionalInner: %w", err)
+			}
+		default:
+			return fmt.Errorf("decoding Object.OptionalInner: invalid presence marker %d", buf[0])
+		}
+	default:
+		return fmt.Errorf("decoding Object.OptionalInner: invalid presence marker %d", buf[0])
+	}
+	return nil
+}
+
+// EncodeTo implements [goc.EncodeWriter].
+func (x Inner) EncodeTo(w io.Writer) error {
+	b, err := x.appendGoc(make([]byte, 0, 64))
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(b)
+	return err
+}
+
+func (x *Inner) appendGoc(b []byte) ([]byte, error) {
+	if len(x.Key) > math.MaxInt32 {
+		return nil, fmt.Errorf("encoding Inner.Key: maximum length of %d exceeded", math.MaxInt32)
+	}
+	b = binary.LittleEndian.AppendUint32(b, uint32(len(x.Key)))
+	b = append(b, x.Key...)
+	b = binary.LittleEndian.AppendUint64(b, math.Float64bits(float64(x.Value)))
+	return b, nil
+}
+
+// DecodeFrom implements [goc.DecodeReader].
+func (x *Inner) DecodeFrom(r io.Reader) error {
+	var buf [16]byte
+
+	{
+		if err := goc.ReadFull(r, buf[:4]); err != nil {
+			return fmt.Errorf("decoding Inner.Key length: %w", err)
+		}
+		u2 := binary.LittleEndian.Uint32(buf[:4])
+		if u2 > math.MaxInt32 {
+			return fmt.Errorf("decoding Inner.Key: maximum length of %d exceeded", math.MaxInt32)
+		}
+		n1 := int(u2)
+		if err := goc.CheckStringLength(r, n1); err != nil {
+			return fmt.Errorf("decoding Inner.Key: %w", err)
+		}
+		s3, err := goc.ReadAppend(r, nil, n1)
+		if err != nil {
+			return fmt.Errorf("decoding Inner.Key: %w", err)
+		}
+		if err := goc.CheckUTF8(r, s3); err != nil {
+			return fmt.Errorf("decoding Inner.Key: %w", err)
+		}
+		x.Key = string(s3)
+	}
+	if err := goc.ReadFull(r, buf[:8]); err != nil {
+		return fmt.Errorf("decoding Inner.Value: %w", err)
+	}
+	x.Value = math.Float64frombits(binary.LittleEndian.Uint64(buf[:8]))
+	return nil
+}
+
+// EncodeTo implements [goc.EncodeWriter].
+func (x Varint) EncodeTo(w io.Writer) error {
+	b, err := x.appendGoc(make([]byte, 0, 64))
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(b)
+	return err
+}
+
+func (x *Varint) appendGoc(b []byte) ([]byte, error) {
+	b = binary.LittleEndian.AppendUint64(b, uint64(x.Fixed))
+	b = binary.AppendUvarint(b, uint64(x.Varint))
+	b = binary.AppendVarint(b, int64(x.Signed))
+	if len(x.Slice) > math.MaxInt32 {
+		return nil, fmt.Errorf("encoding Varint.Slice: maximum length of %d exceeded", math.MaxInt32)
+	}
+	b = binary.AppendUvarint(b, uint64(len(x.Slice)))
+	for i1 := range x.Slice {
+		b = binary.AppendVarint(b, int64(x.Slice[i1]))
+	}
+	return b, nil
+}
+
+// DecodeFrom implements [goc.DecodeReader].
+func (x *Varint) DecodeFrom(r io.Reader) error {
+	var buf [16]byte
+
+	if err := goc.ReadFull(r, buf[:8]); err != nil {
+		return fmt.Errorf("decoding Varint.Fixed: %w", err)
+	}
+	x.Fixed = binary.LittleEndian.Uint64(buf[:8])
+	{
+		v1, err := goc.ReadUvarint(r)
+		if err != nil {
+			return fmt.Errorf("decoding Varint.Varint: %w", err)
+		}
+		x.Varint = v1
+	}
+	{
+		v2, err := goc.ReadVarint(r)
+		if err != nil {
+			return fmt.Errorf("decoding Varint.Signed: %w", err)
+		}
+		if v2 < math.MinInt32 || v2 > math.MaxInt32 {
+			return fmt.Errorf("decoding Varint.Signed: %w", &goc.OverflowError{Value: strconv.FormatInt(v2, 10), Type: "int32"})
+		}
+		x.Signed = int32(v2)
+	}
+	{
+		u4, err := goc.ReadUvarint(r)
+		if err != nil {
+			return fmt.Errorf("decoding Varint.Slice length: %w", err)
+		}
+		if u4 > math.MaxInt32 {
+			return fmt.Errorf("decoding Varint.Slice: maximum length of %d exceeded", math.MaxInt32)
+		}
+		n3 := int(u4)
+		if err := goc.CheckLength(r, n3); err != nil {
+			return fmt.Errorf("decoding Varint.Slice: %w", err)
+		}
+		x.Slice = slices.Grow(x.Slice[:0], goc.PreallocLen[int](n3))
+		for i5 := range n3 {
+			if i5 == cap(x.Slice) {
+				x.Slice = slices.Grow(x.Slice, min(i5, n3-i5))
+			}
+			x.Slice = x.Slice[:i5+1]
+			{
+				v6, err := goc.ReadVarint(r)
+				if err != nil {
+					return fmt.Errorf("decoding Varint.Slice[]: %w", err)
+				}
+				{
+					i7, err := goc.ToInt(v6)
+					if err != nil {
+						return fmt.Errorf("decoding Varint.Slice[]: %w", err)
+					}
+					x.Slice[i5] = i7
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// EncodeTo implements [goc.EncodeWriter].
+func (x List) EncodeTo(w io.Writer) error {
+	b, err := x.appendGoc(make([]byte, 0, 64))
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(b)
+	return err
+}
+
+func (x *List) appendGoc(b []byte) ([]byte, error) {
+	var err error
+
+	b = binary.LittleEndian.AppendUint32(b, uint32(x.Value))
+	if x.Next == nil {
+		b = append(b, 0)
+	} else {
+		b = append(b, 1)
+		if b, err = (*x.Next).appendGoc(b); err != nil {
+			return nil, err
+		}
+	}
+	return b, nil
+}
+
+// DecodeFrom implements [goc.DecodeReader].
+func (x *List) DecodeFrom(r io.Reader) error {
+	var buf [16]byte
+
+	if err := goc.ReadFull(r, buf[:4]); err != nil {
+		return fmt.Errorf("decoding List.Value: %w", err)
+	}
+	x.Value = int32(binary.LittleEndian.Uint32(buf[:4]))
+	if err := goc.ReadFull(r, buf[:1]); err != nil {
+		return fmt.Errorf("decoding List.Next presence: %w", err)
+	}
+	switch buf[0] {
+	case 0:
+		x.Next = nil
+	case 1:
+		if x.Next == nil {
+			x.Next = new(List)
+		}
+		if err := (*x.Next).DecodeFrom(r); err != nil {
+			return fmt.Errorf("decoding List.Next: %w", err)
+		}
+	default:
+		return fmt.Errorf("decoding List.Next: invalid presence marker %d", buf[0])
+	}
+	return nil
+}
+
+// EncodeTo implements [goc.EncodeWriter].
+func (x Numbered) EncodeTo(w io.Writer) error {
+	b, err := x.appendGoc(make([]byte, 0, 64))
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(b)
+	return err
+}
+
+func (x *Numbered) appendGoc(b []byte) ([]byte, error) {
+	var err error
+
+	b = binary.AppendUvarint(b, 1)
+	start1 := len(b)
+	b = binary.LittleEndian.AppendUint64(b, uint64(x.ID))
+	b = slices.Insert(b, start1, binary.AppendUvarint(make([]byte, 0, binary.MaxVarintLen64), uint64(len(b)-start1))...)
+	b = binary.AppendUvarint(b, 2)
+	start2 := len(b)
+	if len(x.Name) > math.MaxInt32 {
+		return nil, fmt.Errorf("encoding Numbered.name: maximum length of %d exceeded", math.MaxInt32)
+	}
+	b = binary.LittleEndian.AppendUint32(b, uint32(len(x.Name)))
+	b = append(b, x.Name...)
+	b = slices.Insert(b, start2, binary.AppendUvarint(make([]byte, 0, binary.MaxVarintLen64), uint64(len(b)-start2))...)
+	b = binary.AppendUvarint(b, 3)
+	start3 := len(b)
+	if b, err = x.Inner.appendGoc(b); err != nil {
+		return nil, err
+	}
+	b = slices.Insert(b, start3, binary.AppendUvarint(make([]byte, 0, binary.MaxVarintLen64), uint64(len(b)-start3))...)
+	if !(len(x.Tags) == 0) {
+		b = binary.AppendUvarint(b, 4)
+		start4 := len(b)
+		if len(x.Tags) > math.MaxInt32 {
+			return nil, fmt.Errorf("encoding Numbered.Tags: maximum length of %d exceeded", math.MaxInt32)
+		}
+		b = binary.LittleEndian.AppendUint32(b, uint32(len(x.Tags)))
+		for i5 := range x.Tags {
+			if len(x.Tags[i5]) > math.MaxInt32 {
+				return nil, fmt.Errorf("encoding Numbered.Tags[]: maximum length of %d exceeded", math.MaxInt32)
+			}
+			b = binary.LittleEndian.AppendUint32(b, uint32(len(x.Tags[i5])))
+			b = append(b, x.Tags[i5]...)
+		}
+		b = slices.Insert(b, start4, binary.AppendUvarint(make([]byte, 0, binary.MaxVarintLen64), uint64(len(b)-start4))...)
+	}
+	b = binary.AppendUvarint(b, 5)
+	start6 := len(b)
+	if x.Next == nil {
+		b = append(b, 0)
+	} else {
+		b = append(b, 1)
+		if b, err = (*x.Next).appendGoc(b); err != nil {
+			return nil, err
+		}
+	}
+	b = slices.Insert(b, start6, binary.AppendUvarint(make([]byte, 0, binary.MaxVarintLen64), uint64(len(b)-start6))...)
+	b = binary.AppendUvarint(b, 6)
+	start7 := len(b)
+	b = binary.AppendUvarint(b, uint64(x.Count))
+	b = slices.Insert(b, start7, binary.AppendUvarint(make([]byte, 0, binary.MaxVarintLen64), uint64(len(b)-start7))...)
+	b = append(b, 0)
+	return b, nil
+}
+
+// DecodeFrom implements [goc.DecodeReader].
+func (x *Numbered) DecodeFrom(r io.Reader) error {
+	var buf [16]byte
+
+	x.ID = 0
+	x.Name = ""
+	x.Inner = Inner{}
+	x.Tags = nil
+	x.Next = nil
+	x.Count = 0
+	for {
+		number1, err := goc.ReadUvarint(r)
+		if err != nil {
+			return fmt.Errorf("decoding Numbered field number: %w", err)
+		}
+		if number1 == 0 {
+			break
+		}
+		length2, err := goc.ReadUvarint(r)
+		if err != nil {
+			return fmt.Errorf("decoding Numbered field %d length: %w", number1, err)
+		}
+		if length2 > math.MaxInt32 {
+			return fmt.Errorf("decoding Numbered field %d: maximum length of %d exceeded", number1, math.MaxInt32)
+		}
+		limited3 := &io.LimitedReader{R: r, N: int64(length2)}
+		switch number1 {
+		case 1:
+			r := io.Reader(limited3)
+			if err := goc.ReadFull(r, buf[:8]); err != nil {
+				return fmt.Errorf("decoding Numbered.ID: %w", err)
+			}
+			x.ID = ID(binary.LittleEndian.Uint64(buf[:8]))
+		case 2:
+			r := io.Reader(limited3)
+			{
+				if err := goc.ReadFull(r, buf[:4]); err != nil {
+					return fmt.Errorf("decoding Numbered.name length: %w", err)
+				}
+				u5 := binary.LittleEndian.Uint32(buf[:4])
+				if u5 > math.MaxInt32 {
+					return fmt.Errorf("decoding Numbered.name: maximum length of %d exceeded", math.MaxInt32)
+				}
+				n4 := int(u5)
+				if err := goc.CheckStringLength(r, n4); err != nil {
+					return fmt.Errorf("decoding Numbered.name: %w", err)
+				}
+				s6, err := goc.ReadAppend(r, nil, n4)
+				if err != nil {
+					return fmt.Errorf("decoding Numbered.name: %w", err)
+				}
+				if err := goc.CheckUTF8(r, s6); err != nil {
+					return fmt.Errorf("decoding Numbered.name: %w", err)
+				}
+				x.Name = string(s6)
+			}
+		case 3:
+			r := io.Reader(limited3)
+			if err := x.Inner.DecodeFrom(r); err != nil {
+				return fmt.Errorf("decoding Numbered.Inner: %w", err)
+			}
+		case 4:
+			r := io.Reader(limited3)
+			{
+				if err := goc.ReadFull(r, buf[:4]); err != nil {
+					return fmt.Errorf("decoding Numbered.Tags length: %w", err)
+				}
+				u8 := binary.LittleEndian.Uint32(buf[:4])
+				if u8 > math.MaxInt32 {
+					return fmt.Errorf("decoding Numbered.Tags: maximum length of %d exceeded", math.MaxInt32)
+				}
+				n7 := int(u8)
+				if err := goc.CheckLength(r, n7); err != nil {
+					return fmt.Errorf("decoding Numbered.Tags: %w", err)
+				}
+				x.Tags = slices.Grow(x.Tags[:0], goc.PreallocLen[Name](n7))
+				for i9 := range n7 {
+					if i9 == cap(x.Tags) {
+						x.Tags = slices.Grow(x.Tags, min(i9, n7-i9))
+					}
+					x.Tags = x.Tags[:i9+1]
+					{
+						if err := goc.ReadFull(r, buf[:4]); err != nil {
+							return fmt.Errorf("decoding Numbered.Tags[] length: %w", err)
+						}
+						u11 := binary.LittleEndian.Uint32(buf[:4])
+						if u11 > math.MaxInt32 {
+							return fmt.Errorf("decoding Numbered.Tags[]: maximum length of %d exceeded", math.MaxInt32)
+						}
+						n10 := int(u11)
+						if err := goc.CheckStringLength(r, n10); err != nil {
+							return fmt.Errorf("decoding Numbered.Tags[]: %w", err)
+						}
+						s12, err := goc.ReadAppend(r, nil, n10)
+						if err != nil {
+							return fmt.Errorf("decoding Numbered.Tags[]: %w", err)
+						}
+						if err := goc.CheckUTF8(r, s12); err != nil {
+							return fmt.Errorf("decoding Numbered.Tags[]: %w", err)
+						}
+						x.Tags[i9] = Name(string(s12))
+					}
+				}
+			}
+		case 5:
+			r := io.Reader(limited3)
+			if err := goc.ReadFull(r, buf[:1]); err != nil {
+				return fmt.Errorf("decoding Numbered.Next presence: %w", err)
+			}
+			switch buf[0] {
+			case 0:
+				x.Next = nil
+			case 1:
+				if x.Next == nil {
+					x.Next = new(Numbered)
+				}
+				if err := (*x.Next).DecodeFrom(r); err != nil {
+					return fmt.Errorf("decoding Numbered.Next: %w", err)
+				}
+			default:
+				return fmt.Errorf("decoding Numbered.Next: invalid presence marker %d", buf[0])
+			}
+		case 6:
+			r := io.Reader(limited3)
+			{
+				v13, err := goc.ReadUvarint(r)
+				if err != nil {
+					return fmt.Errorf("decoding Numbered.Count: %w", err)
+				}
+				if v13 > math.MaxUint32 {
+					return fmt.Errorf("decoding Numbered.Count: %w", &goc.OverflowError{Value: strconv.FormatUint(v13, 10), Type: "uint32"})
+				}
+				x.Count = uint32(v13)
+			}
+		}
+		if _, err := io.Copy(io.Discard, limited3); err != nil {
+			return fmt.Errorf("decoding Numbered field %d: %w", number1, err)
+		}
+		if limited3.N != 0 {
+			return fmt.Errorf("decoding Numbered field %d: %w", number1, io.ErrUnexpectedEOF)
+		}
+	}
+	return nil
+}
+
+// EncodeTo implements [goc.EncodeWriter].
+func (x NumberedSubset) EncodeTo(w io.Writer) error {
+	b, err := x.appendGoc(make([]byte, 0, 64))
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(b)
+	return err
+}
+
+func (x *NumberedSubset) appendGoc(b []byte) ([]byte, error) {
+	b = binary.AppendUvarint(b, 6)
+	start1 := len(b)
+	b = binary.AppendUvarint(b, uint64(x.Count))
+	b = slices.Insert(b, start1, binary.AppendUvarint(make([]byte, 0, binary.MaxVarintLen64), uint64(len(b)-start1))...)
+	b = binary.AppendUvarint(b, 2)
+	start2 := len(b)
+	if len(x.Name) > math.MaxInt32 {
+		return nil, fmt.Errorf("encoding NumberedSubset.Name: maximum length of %d exceeded", math.MaxInt32)
+	}
+	b = binary.LittleEndian.AppendUint32(b, uint32(len(x.Name)))
+	b = append(b, x.Name...)
+	b = slices.Insert(b, start2, binary.AppendUvarint(make([]byte, 0, binary.MaxVarintLen64), uint64(len(b)-start2))...)
+	b = append(b, 0)
+	return b, nil
+}
+
+// DecodeFrom implements [goc.DecodeReader].
+func (x *NumberedSubset) DecodeFrom(r io.Reader) error {
+	var buf [16]byte
+
+	x.Count = 0
+	x.Name = ""
+	for {
+		number1, err := goc.ReadUvarint(r)
+		if err != nil {
+			return fmt.Errorf("decoding NumberedSubset field number: %w", err)
+		}
+		if number1 == 0 {
+			break
+		}
+		length2, err := goc.ReadUvarint(r)
+		if err != nil {
+			return fmt.Errorf("decoding NumberedSubset field %d length: %w", number1, err)
+		}
+		if length2 > math.MaxInt32 {
+			return fmt.Errorf("decoding NumberedSubset field %d: maximum length of %d exceeded", number1, math.MaxInt32)
+		}
+		limited3 := &io.LimitedReader{R: r, N: int64(length2)}
+		switch number1 {
+		case 6:
+			r := io.Reader(limited3)
+			{
+				v4, err := goc.ReadUvarint(r)
+				if err != nil {
+					return fmt.Errorf("decoding NumberedSubset.Count: %w", err)
+				}
+				if v4 > math.MaxUint32 {
+					return fmt.Errorf("decoding NumberedSubset.Count: %w", &goc.OverflowError{Value: strconv.FormatUint(v4, 10), Type: "uint32"})
+				}
+				x.Count = uint32(v4)
+			}
+		case 2:
+			r := io.Reader(limited3)
+			{
+				if err := goc.ReadFull(r, buf[:4]); err != nil {
+					return fmt.Errorf("decoding NumberedSubset.Name length: %w", err)
+				}
+				u6 := binary.LittleEndian.Uint32(buf[:4])
+				if u6 > math.MaxInt32 {
+					return fmt.Errorf("decoding NumberedSubset.Name: maximum length of %d exceeded", math.MaxInt32)
+				}
+				n5 := int(u6)
+				if err := goc.CheckStringLength(r, n5); err != nil {
+					return fmt.Errorf("decoding NumberedSubset.Name: %w", err)
+				}
+				s7, err := goc.ReadAppend(r, nil, n5)
+				if err != nil {
+					return fmt.Errorf("decoding NumberedSubset.Name: %w", err)
+				}
+				if err := goc.CheckUTF8(r, s7); err != nil {
+					return fmt.Errorf("decoding NumberedSubset.Name: %w", err)
+				}
+				x.Name = string(s7)
+			}
+		}
+		if _, err := io.Copy(io.Discard, limited3); err != nil {
+			return fmt.Errorf("decoding NumberedSubset field %d: %w", number1, err)
+		}
+		if limited3.N != 0 {
+			return fmt.Errorf("decoding NumberedSubset field %d: %w", number1, io.ErrUnexpectedEOF)
+		}
+	}
+	return nil
+}
+
+// EncodeTo implements [goc.EncodeWriter].
+func (x Stdlib) EncodeTo(w io.Writer) error {
+	b, err := x.appendGoc(make([]byte, 0, 64))
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(b)
+	return err
+}
+
+func (x *Stdlib) appendGoc(b []byte) ([]byte, error) {
+	var err error
+
+	if b, err = goc.AppendField(b, &x.Time); err != nil {
+		return nil, fmt.Errorf("encoding Stdlib.Time: %w", err)
+	}
+	if b, err = goc.AppendField(b, &x.Duration); err != nil {
+		return nil, fmt.Errorf("encoding Stdlib.Duration: %w", err)
+	}
+	if x.Int == nil {
+		b = append(b, 0)
+	} else {
+		b = append(b, 1)
+		if b, err = goc.AppendField(b, &(*x.Int)); err != nil {
+			return nil, fmt.Errorf("encoding Stdlib.Int: %w", err)
+		}
+	}
+	if b, err = goc.AppendField(b, &x.Rat); err != nil {
+		return nil, fmt.Errorf("encoding Stdlib.Rat: %w", err)
+	}
+	if b, err = goc.AppendField(b, &x.Addr); err != nil {
+		return nil, fmt.Errorf("encoding Stdlib.Addr: %w", err)
+	}
+	if b, err = goc.AppendField(b, &x.Prefix); err != nil {
+		return nil, fmt.Errorf("encoding Stdlib.Prefix: %w", err)
+	}
+	if b, err = goc.AppendField(b, &x.URL); err != nil {
+		return nil, fmt.Errorf("encoding Stdlib.URL: %w", err)
+	}
+	if b, err = goc.AppendField(b, &x.Version); err != nil {
+		return nil, fmt.Errorf("encoding Stdlib.Version: %w", err)
+	}
+	if b, err = goc.AppendField(b, &x.Any); err != nil {
+		return nil, fmt.Errorf("encoding Stdlib.Any: %w", err)
+	}
+	if len(x.Values) > math.MaxInt32 {
+		return nil, fmt.Errorf("encoding Stdlib.Values: maximum length of %d exceeded", math.MaxInt32)
+	}
+	b = binary.AppendUvarint(b, uint64(len(x.Values)))
+	for k1, v2 := range x.Values {
+		if len(k1) > math.MaxInt32 {
+			return nil, fmt.Errorf("encoding Stdlib.Values[key]: maximum length of %d exceeded", math.MaxInt32)
+		}
+		b = binary.AppendUvarint(b, uint64(len(k1)))
+		b = append(b, k1...)
+		if b, err = goc.AppendField(b, &v2, goc.WithVarint()); err != nil {
+			return nil, fmt.Errorf("encoding Stdlib.Values[]: %w", err)
+		}
+	}
+	if b, err = goc.AppendField(b, &x.Optional); err != nil {
+		return nil, fmt.Errorf("encoding Stdlib.Optional: %w", err)
+	}
+	if len(x.Patch) > math.MaxInt32 {
+		return nil, fmt.Errorf("encoding Stdlib.Patch: maximum length of %d exceeded", math.MaxInt32)
+	}
+	b = binary.LittleEndian.AppendUint32(b, uint32(len(x.Patch)))
+	for i3 := range x.Patch {
+		if b, err = goc.AppendField(b, &x.Patch[i3]); err != nil {
+			return nil, fmt.Errorf("encoding Stdlib.Patch[]: %w", err)
+		}
+	}
+	if len(x.Results) > math.MaxInt32 {
+		return nil, fmt.Errorf("encoding Stdlib.Results: maximum length of %d exceeded", math.MaxInt32)
+	}
+	b = binary.LittleEndian.AppendUint32(b, uint32(len(x.Results)))
+	for i4 := range x.Results {
+		if b, err = goc.AppendField(b, &x.Results[i4]); err != nil {
+			return nil, fmt.Errorf("encoding Stdlib.Results[]: %w", err)
+		}
+	}
+	if b, err = goc.AppendField(b, &x.Point); err != nil {
+		return nil, fmt.Errorf("encoding Stdlib.Point: %w", err)
+	}
+	if len(x.Pairs) > math.MaxInt32 {
+		return nil, fmt.Errorf("encoding Stdlib.Pairs: maximum length of %d exceeded", math.MaxInt32)
+	}
+	b = binary.LittleEndian.AppendUint32(b, uint32(len(x.Pairs)))
+	for i5 := range x.Pairs {
+		if b, err = goc.AppendField(b, &x.Pairs[i5]); err != nil {
+			return nil, fmt.Errorf("encoding Stdlib.Pairs[]: %w", err)
+		}
+	}
+	return b, nil
+}
+
+// DecodeFrom implements [goc.DecodeReader].
+func (x *Stdlib) DecodeFrom(r io.Reader) error {
+	var buf [16]byte
+
+	if err := goc.DecodeField(r, &x.Time); err != nil {
+		return fmt.Errorf("decoding Stdlib.Time: %w", err)
+	}
+	if err := goc.DecodeField(r, &x.Duration); err != nil {
+		return fmt.Errorf("decoding Stdlib.Duration: %w", err)
+	}
+	if err := goc.ReadFull(r, buf[:1]); err != nil {
+		return fmt.Errorf("decoding Stdlib.Int presence: %w", err)
+	}
+	switch buf[0] {
+	case 0:
+		x.Int = nil
+	case 1:
+		if x.Int == nil {
+			x.Int = new(big.Int)
+		}
+		if err := goc.DecodeField(r, &(*x.Int)); err != nil {
+			return fmt.Errorf("decoding Stdlib.Int: %w", err)
+		}
+	default:
+		return fmt.Errorf("decoding Stdlib.Int: invalid presence marker %d", buf[0])
+	}
+	if err := goc.DecodeField(r, &x.Rat); err != nil {
+		return fmt.Errorf("decoding Stdlib.Rat: %w", err)
+	}
+	if err := goc.DecodeField(r, &x.Addr); err != nil {
+		return fmt.Errorf("decoding Stdlib.Addr: %w", err)
+	}
+	if err := goc.DecodeField(r, &x.Prefix); err != nil {
+		return fmt.Errorf("decoding Stdlib.Prefix: %w", err)
+	}
+	if err := goc.DecodeField(r, &x.URL); err != nil {
+		return fmt.Errorf("decoding Stdlib.URL: %w", err)
+	}
+	if err := goc.DecodeField(r, &x.Version); err != nil {
+		return fmt.Errorf("decoding Stdlib.Version: %w", err)
+	}
+	if err := goc.DecodeField(r, &x.Any); err != nil {
+		return fmt.Errorf("decoding Stdlib.Any: %w", err)
+	}
+	{
+		u2, err := goc.ReadUvarint(r)
+		if err != nil {
+			return fmt.Errorf("decoding Stdlib.Values length: %w", err)
+		}
+		if u2 > math.MaxInt32 {
+			return fmt.Errorf("decoding Stdlib.Values: maximum length of %d exceeded", math.MaxInt32)
+		}
+		n1 := int(u2)
+		if err := goc.CheckLength(r, n1); err != nil {
+			return fmt.Errorf("decoding Stdlib.Values: %w", err)
+		}
+		clear(x.Values)
+		if x.Values == nil && n1 > 0 {
+			x.Values = make(map[string]any, goc.PreallocMapLen[string, any](n1))
+		}
+		for range n1 {
+			var k3 string
+			{
+				u6, err := goc.ReadUvarint(r)
+				if err != nil {
+					return fmt.Errorf("decoding Stdlib.Values[key] length: %w", err)
+				}
+				if u6 > math.MaxInt32 {
+					return fmt.Errorf("decoding Stdlib.Values[key]: maximum length of %d exceeded", math.MaxInt32)
+				}
+				n5 := int(u6)
+				if err := goc.CheckStringLength(r, n5); err != nil {
+					return fmt.Errorf("decoding Stdlib.Values[key]: %w", err)
+				}
+				s7, err := goc.ReadAppend(r, nil, n5)
+				if err != nil {
+					return fmt.Errorf("decoding Stdlib.Values[key]: %w", err)
+				}
+				if err := goc.CheckUTF8(r, s7); err != nil {
+					return fmt.Errorf("decoding Stdlib.Values[key]: %w", err)
+				}
+				k3 = string(s7)
+			}
+			var v4 any
+			if err := goc.DecodeField(r, &v4, goc.WithVarint()); err != nil {
+				return fmt.Errorf("decoding Stdlib.Values[]: %w", err)
+			}
+			x.Values[k3] = v4
+		}
+		if err := goc.CheckDuplicateKey(r, len(x.Values) != n1); err != nil {
+			return fmt.Errorf("decoding Stdlib.Values: %w", err)
+		}
+	}
+	if err := goc.DecodeField(r, &x.Optional); err != nil {
+		return fmt.Errorf("decoding Stdlib.Optional: %w", err)
+	}
+	{
+		if err := goc.ReadFull(r, buf[:4]); err != nil {
+			return fmt.Errorf("decoding Stdlib.Patch length: %w", err)
+		}
+		u9 := binary.LittleEndian.Uint32(buf[:4])
+		if u9 > math.MaxInt32 {
+			return fmt.Errorf("decoding Stdlib.Patch: maximum length of %d exceeded", math.MaxInt32)
+		}
+		n8 := int(u9)
+		if err := goc.CheckLength(r, n8); err != nil {
+			return fmt.Errorf("decoding Stdlib.Patch: %w", err)
+		}
+		x.Patch = slices.Grow(x.Patch[:0], goc.PreallocLen[goc.Optional[Inner]](n8))
+		for i10 := range n8 {
+			if i10 == cap(x.Patch) {
+				x.Patch = slices.Grow(x.Patch, min(i10, n8-i10))
+			}
+			x.Patch = x.Patch[:i10+1]
+			if err := goc.DecodeField(r, &x.Patch[i10]); err != nil {
+				return fmt.Errorf("decoding Stdlib.Patch[]: %w", err)
+			}
+		}
+	}
+	{
+		if err := goc.ReadFull(r, buf[:4]); err != nil {
+			return fmt.Errorf("decoding Stdlib.Results length: %w", err)
+		}
+		u12 := binary.LittleEndian.Uint32(buf[:4])
+		if u12 > math.MaxInt32 {
+			return fmt.Errorf("decoding Stdlib.Results: maximum length of %d exceeded", math.MaxInt32)
+		}
+		n11 := int(u12)
+		if err := goc.CheckLength(r, n11); err != nil {
+			return fmt.Errorf("decoding Stdlib.Results: %w", err)
+		}
+		x.Results = slices.Grow(x.Results[:0], goc.PreallocLen[Result](n11))
+		for i13 := range n11 {
+			if i13 == cap(x.Results) {
+				x.Results = slices.Grow(x.Results, min(i13, n11-i13))
+			}
+			x.Results = x.Results[:i13+1]
+			if err := goc.DecodeField(r, &x.Results[i13]); err != nil {
+				return fmt.Errorf("decoding Stdlib.Results[]: %w", err)
+			}
+		}
+	}
+	if err := goc.DecodeField(r, &x.Point); err != nil {
+		return fmt.Errorf("decoding Stdlib.Point: %w", err)
+	}
+	{
+		if err := goc.ReadFull(r, buf[:4]); err != nil {
+			return fmt.Errorf("decoding Stdlib.Pairs length: %w", err)
+		}
+		u15 := binary.LittleEndian.Uint32(buf[:4])
+		if u15 > math.MaxInt32 {
+			return fmt.Errorf("decoding Stdlib.Pairs: maximum length of %d exceeded", math.MaxInt32)
+		}
+		n14 := int(u15)
+		if err := goc.CheckLength(r, n14); err != nil {
+			return fmt.Errorf("decoding Stdlib.Pairs: %w", err)
+		}
+		x.Pairs = slices.Grow(x.Pairs[:0], goc.PreallocLen[external.Pair](n14))
+		for i16 := range n14 {
+			if i16 == cap(x.Pairs) {
+				x.Pairs = slices.Grow(x.Pairs, min(i16, n14-i16))
+			}
+			x.Pairs = x.Pairs[:i16+1]
+			if err := goc.DecodeField(r, &x.Pairs[i16]); err != nil {
+				return fmt.Errorf("decoding Stdlib.Pairs[]: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+// EncodeTo implements [goc.EncodeWriter].
+func (x Embedded) EncodeTo(w io.Writer) error {
+	b, err := x.appendGoc(make([]byte, 0, 64))
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(b)
+	return err
+}
+
+func (x *Embedded) appendGoc(b []byte) ([]byte, error) {
+	b = binary.LittleEndian.AppendUint32(b, uint32(x.Page.Offset))
+	if x.Audit == nil {
+		b = append(b, 0)
+	} else {
+		b = append(b, 1)
+		b = binary.AppendVarint(b, int64(x.Audit.Created))
+		if x.Audit.Auth == nil {
+			b = append(b, 0)
+		} else {
+			b = append(b, 1)
+			if len(x.Audit.Auth.Token) > math.MaxInt32 {
+				return nil, fmt.Errorf("encoding Embedded.Token: maximum length of %d exceeded", math.MaxInt32)
+			}
+			b = binary.AppendUvarint(b, uint64(len(x.Audit.Auth.Token)))
+			b = append(b, x.Audit.Auth.Token...)
+		}
+	}
+	if len(x.Name) > math.MaxInt32 {
+		return nil, fmt.Errorf("encoding Embedded.Name: maximum length of %d exceeded", math.MaxInt32)
+	}
+	b = binary.LittleEndian.AppendUint32(b, uint32(len(x.Name)))
+	b = append(b, x.Name...)
+	b = binary.LittleEndian.AppendUint16(b, uint16(x.Limit))
+	return b, nil
+}
+
+// DecodeFrom implements [goc.DecodeReader].
+func (x *Embedded) DecodeFrom(r io.Reader) error {
+	var buf [16]byte
+
+	if err := goc.ReadFull(r, buf[:4]); err != nil {
+		return fmt.Errorf("decoding Embedded.Offset: %w", err)
+	}
+	x.Page.Offset = binary.LittleEndian.Uint32(buf[:4])
+	if err := goc.ReadFull(r, buf[:1]); err != nil {
+		return fmt.Errorf("decoding embedded Embedded.Audit presence: %w", err)
+	}
+	switch buf[0] {
+	case 0:
+		x.Audit = nil
+	case 1:
+		if x.Audit == nil {
+			x.Audit = new(Audit)
+		}
+		{
+			v1, err := goc.ReadVarint(r)
+			if err != nil {
+				return fmt.Errorf("decoding Embedded.Created: %w", err)
+			}
+			x.Audit.Created = v1
+		}
+		if err := goc.ReadFull(r, buf[:1]); err != nil {
+			return fmt.Errorf("decoding embedded Embedded.Auth presence: %w", err)
+		}
+		switch buf[0] {
+		case 0:
+			x.Audit.Auth = nil
+		case 1:
+			if x.Audit.Auth == nil {
+				x.Audit.Auth = new(Auth)
+			}
+			{
+				u3, err := goc.ReadUvarint(r)
+				if err != nil {
+					return fmt.Errorf("decoding Embedded.Token length: %w", err)
+				}
+				if u3 > math.MaxInt32 {
+					return fmt.Errorf("decoding Embedded.Token: maximum length of %d exceeded", math.MaxInt32)
+				}
+				n2 := int(u3)
+				if err := goc.CheckStringLength(r, n2); err != nil {
+					return fmt.Errorf("decoding Embedded.Token: %w", err)
+				}
+				s4, err := goc.ReadAppend(r, nil, n2)
+				if err != nil {
+					return fmt.Errorf("decoding Embedded.Token: %w", err)
+				}
+				if err := goc.CheckUTF8(r, s4); err != nil {
+					return fmt.Errorf("decoding Embedded.Token: %w", err)
+				}
+				x.Audit.Auth.Token = string(s4)
+			}
+		default:
+			return fmt.Errorf("decoding embedded Embedded.Auth: invalid presence marker %d", buf[0])
+		}
+	default:
+		return fmt.Errorf("decoding embedded Embedded.Audit: invalid presence marker %d", buf[0])
+	}
+	{
+		if err := goc.ReadFull(r, buf[:4]); err != nil {
+			return fmt.Errorf("decoding Embedded.Name length: %w", err)
+		}
+		u6 := binary.LittleEndian.Uint32(buf[:4])
+		if u6 > math.MaxInt32 {
+			return fmt.Errorf("decoding Embedded.Name: maximum length of %d exceeded", math.MaxInt32)
+		}
+		n5 := int(u6)
+		if err := goc.CheckStringLength(r, n5); err != nil {
+			return fmt.Errorf("decoding Embedded.Name: %w", err)
+		}
+		s7, err := goc.ReadAppend(r, nil, n5)
+		if err != nil {
+			return fmt.Errorf("decoding Embedded.Name: %w", err)
+		}
+		if err := goc.CheckUTF8(r, s7); err != nil {
+			return fmt.Errorf("decoding Embedded.Name: %w", err)
+		}
+		x.Name = string(s7)
+	}
+	if err := goc.ReadFull(r, buf[:2]); err != nil {
+		return fmt.Errorf("decoding Embedded.Limit: %w", err)
+	}
+	x.Limit = int16(binary.LittleEndian.Uint16(buf[:2]))
+	return nil
+}
+
+// EncodeTo implements [goc.EncodeWriter].
+func (x EmbeddedNumbered) EncodeTo(w io.Writer) error {
+	b, err := x.appendGoc(make([]byte, 0, 64))
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(b)
+	return err
+}
+
+func (x *EmbeddedNumbered) appendGoc(b []byte) ([]byte, error) {
+	b = binary.AppendUvarint(b, 1)
+	start1 := len(b)
+	b = binary.LittleEndian.AppendUint64(b, uint64(x.NumberedBase.ID))
+	b = slices.Insert(b, start1, binary.AppendUvarint(make([]byte, 0, binary.MaxVarintLen64), uint64(len(b)-start1))...)
+	if x.NumberedAudit != nil {
+		b = binary.AppendUvarint(b, 2)
+		start2 := len(b)
+		b = binary.LittleEndian.AppendUint64(b, uint64(x.NumberedAudit.Created))
+		b = slices.Insert(b, start2, binary.AppendUvarint(make([]byte, 0, binary.MaxVarintLen64), uint64(len(b)-start2))...)
+	}
+	if x.NumberedAudit != nil {
+		if !(len(x.NumberedAudit.Token) == 0) {
+			b = binary.AppendUvarint(b, 4)
+			start3 := len(b)
+			if len(x.NumberedAudit.Token) > math.MaxInt32 {
+				return nil, fmt.Errorf("encoding EmbeddedNumbered.Token: maximum length of %d exceeded", math.MaxInt32)
+			}
+			b = binary.LittleEndian.AppendUint32(b, uint32(len(x.NumberedAudit.Token)))
+			b = append(b, x.NumberedAudit.Token...)
+			b = slices.Insert(b, start3, binary.AppendUvarint(make([]byte, 0, binary.MaxVarintLen64), uint64(len(b)-start3))...)
+		}
+	}
+	b = binary.AppendUvarint(b, 3)
+	start4 := len(b)
+	if len(x.Name) > math.MaxInt32 {
+		return nil, fmt.Errorf("encoding EmbeddedNumbered.Name: maximum length of %d exceeded", math.MaxInt32)
+	}
+	b = binary.LittleEndian.AppendUint32(b, uint32(len(x.Name)))
+	b = append(b, x.Name...)
+	b = slices.Insert(b, start4, binary.AppendUvarint(make([]byte, 0, binary.MaxVarintLen64), uint64(len(b)-start4))...)
+	b = append(b, 0)
+	return b, nil
+}
+
+// DecodeFrom implements [goc.DecodeReader].
+func (x *EmbeddedNumbered) DecodeFrom(r io.Reader) error {
+	var buf [16]byte
+
+	x.NumberedBase.ID = 0
+	if x.NumberedAudit != nil {
+		x.NumberedAudit.Created = 0
+	}
+	if x.NumberedAudit != nil {
+		x.NumberedAudit.Token = ""
+	}
+	x.Name = ""
+	for {
+		number1, err := goc.ReadUvarint(r)
+		if err != nil {
+			return fmt.Errorf("decoding EmbeddedNumbered field number: %w", err)
+		}
+		if number1 == 0 {
+			break
+		}
+		length2, err := goc.ReadUvarint(r)
+		if err != nil {
+			return fmt.Errorf("decoding EmbeddedNumbered field %d length: %w", number1, err)
+		}
+		if length2 > math.MaxInt32 {
+			return fmt.Errorf("decoding EmbeddedNumbered field %d: maximum length of %d exceeded", number1, math.MaxInt32)
+		}
+		limited3 := &io.LimitedReader{R: r, N: int64(length2)}
+		switch number1 {
+		case 1:
+			r := io.Reader(limited3)
+			if err := goc.ReadFull(r, buf[:8]); err != nil {
+				return fmt.Errorf("decoding EmbeddedNumbered.ID: %w", err)
+			}
+			x.NumberedBase.ID = ID(binary.LittleEndian.Uint64(buf[:8]))
+		case 2:
+			r := io.Reader(limited3)
+			if x.NumberedAudit == nil {
+				x.NumberedAudit = new(NumberedAudit)
+			}
+			if err := goc.ReadFull(r, buf[:8]); err != nil {
+				return fmt.Errorf("decoding EmbeddedNumbered.Created: %w", err)
+			}
+			x.NumberedAudit.Created = int64(binary.LittleEndian.Uint64(buf[:8]))
+		case 4:
+			r := io.Reader(limited3)
+			if x.NumberedAudit == nil {
+				x.NumberedAudit = new(NumberedAudit)
+			}
+			{
+				if err := goc.ReadFull(r, buf[:4]); err != nil {
+					return fmt.Errorf("decoding EmbeddedNumbered.Token length: %w", err)
+				}
+				u5 := binary.LittleEndian.Uint32(buf[:4])
+				if u5 > math.MaxInt32 {
+					return fmt.Errorf("decoding EmbeddedNumbered.Token: maximum length of %d exceeded", math.MaxInt32)
+				}
+				n4 := int(u5)
+				if err := goc.CheckStringLength(r, n4); err != nil {
+					return fmt.Errorf("decoding EmbeddedNumbered.Token: %w", err)
+				}
+				s6, err := goc.ReadAppend(r, nil, n4)
+				if err != nil {
+					return fmt.Errorf("decoding EmbeddedNumbered.Token: %w", err)
+				}
+				if err := goc.CheckUTF8(r, s6); err != nil {
+					return fmt.Errorf("decoding EmbeddedNumbered.Token: %w", err)
+				}
+				x.NumberedAudit.Token = string(s6)
+			}
+		case 3:
+			r := io.Reader(limited3)
+			{
+				if err := goc.ReadFull(r, buf[:4]); err != nil {
+					return fmt.Errorf("decoding EmbeddedNumbered.Name length: %w", err)
+				}
+				u8 := binary.LittleEndian.Uint32(buf[:4])
+				if u8 > math.MaxInt32 {
+					return fmt.Errorf("decoding EmbeddedNumbered.Name: maximum length of %d exceeded", math.MaxInt32)
+				}
+				n7 := int(u8)
+				if err := goc.CheckStringLength(r, n7); err != nil {
+					return fmt.Errorf("decoding EmbeddedNumbered.Name: %w", err)
+				}
+				s9, err := goc.ReadAppend(r, nil, n7)
+				if err != nil {
+					return fmt.Errorf("decoding EmbeddedNumbered.Name: %w", err)
+				}
+				if err := goc.CheckUTF8(r, s9); err != nil {
+					return fmt.Errorf("decoding EmbeddedNumbered.Name: %w", err)
+				}
+				x.Name = string(s9)
+			}
+		}
+		if _, err := io.Copy(io.Discard, limited3); err != nil {
+			return fmt.Errorf("decoding EmbeddedNumbered field %d: %w", number1, err)
+		}
+		if limited3.N != 0 {
+			return fmt.Errorf("decoding EmbeddedNumbered field %d: %w", number1, io.ErrUnexpectedEOF)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,160 @@
+// Code generated by gocgen; DO NOT EDIT.
+
+package fixture
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+
+	"github.com/samborkent/gorpc/goc"
+)
+
+var (
+	_ goc.EncodeWriter = VarintObject{}
+	_ goc.DecodeReader = (*VarintObject)(nil)
+)
+
+// EncodeTo implements [goc.EncodeWriter].
+func (x VarintObject) EncodeTo(w io.Writer) error {
+	b, err := x.appendGoc(make([]byte, 0, 64))
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(b)
+	return err
+}
+
+func (x *VarintObject) appendGoc(b []byte) ([]byte, error) {
+	b = binary.AppendVarint(b, int64(x.Int))
+	b = binary.AppendVarint(b, int64(x.Int16))
+	b = binary.AppendUvarint(b, uint64(x.Uint32))
+	b = binary.LittleEndian.AppendUint64(b, uint64(x.Fixed))
+	if len(x.String) > math.MaxInt32 {
+		return nil, fmt.Errorf("encoding VarintObject.String: maximum length of %d exceeded", math.MaxInt32)
+	}
+	b = binary.AppendUvarint(b, uint64(len(x.String)))
+	b = append(b, x.String...)
+	if len(x.Map) > math.MaxInt32 {
+		return nil, fmt.Errorf("encoding VarintObject.Map: maximum length of %d exceeded", math.MaxInt32)
+	}
+	b = binary.AppendUvarint(b, uint64(len(x.Map)))
+	for k1, v2 := range x.Map {
+		b = binary.AppendUvarint(b, uint64(k1))
+		b = binary.AppendVarint(b, int64(v2))
+	}
+	return b, nil
+}
+
+// DecodeFrom implements [goc.DecodeReader].
+func (x *VarintObject) DecodeFrom(r io.Reader) error {
+	var buf [16]byte
+
+	{
+		v1, err := goc.ReadVarint(r)
+		if err != nil {
+			return fmt.Errorf("decoding VarintObject.Int: %w", err)
+		}
+		{
+			i2, err := goc.ToInt(v1)
+			if err != nil {
+				return fmt.Errorf("decoding VarintObject.Int: %w", err)
+			}
+			x.Int = i2
+		}
+	}
+	{
+		v3, err := goc.ReadVarint(r)
+		if err != nil {
+			return fmt.Errorf("decoding VarintObject.Int16: %w", err)
+		}
+		if v3 < math.MinInt16 || v3 > math.MaxInt16 {
+			return fmt.Errorf("decoding VarintObject.Int16: %w", &goc.OverflowError{Value: strconv.FormatInt(v3, 10), Type: "int16"})
+		}
+		x.Int16 = int16(v3)
+	}
+	{
+		v4, err := goc.ReadUvarint(r)
+		if err != nil {
+			return fmt.Errorf("decoding VarintObject.Uint32: %w", err)
+		}
+		if v4 > math.MaxUint32 {
+			return fmt.Errorf("decoding VarintObject.Uint32: %w", &goc.OverflowError{Value: strconv.FormatUint(v4, 10), Type: "uint32"})
+		}
+		x.Uint32 = uint32(v4)
+	}
+	if err := goc.ReadFull(r, buf[:8]); err != nil {
+		return fmt.Errorf("decoding VarintObject.Fixed: %w", err)
+	}
+	x.Fixed = int64(binary.LittleEndian.Uint64(buf[:8]))
+	{
+		u6, err := goc.ReadUvarint(r)
+		if err != nil {
+			return fmt.Errorf("decoding VarintObject.String length: %w", err)
+		}
+		if u6 > math.MaxInt32 {
+			return fmt.Errorf("decoding VarintObject.String: maximum length of %d exceeded", math.MaxInt32)
+		}
+		n5 := int(u6)
+		if err := goc.CheckStringLength(r, n5); err != nil {
+			return fmt.Errorf("decoding VarintObject.String: %w", err)
+		}
+		s7, err := goc.ReadAppend(r, nil, n5)
+		if err != nil {
+			return fmt.Errorf("decoding VarintObject.String: %w", err)
+		}
+		if err := goc.CheckUTF8(r, s7); err != nil {
+			return fmt.Errorf("decoding VarintObject.String: %w", err)
+		}
+		x.String = string(s7)
+	}
+	{
+		u9, err := goc.ReadUvarint(r)
+		if err != nil {
+			return fmt.Errorf("decoding VarintObject.Map length: %w", err)
+		}
+		if u9 > math.MaxInt32 {
+			return fmt.Errorf("decoding VarintObject.Map: maximum length of %d exceeded", math.MaxInt32)
+		}
+		n8 := int(u9)
+		if err := goc.CheckLength(r, n8); err != nil {
+			return fmt.Errorf("decoding VarintObject.Map: %w", err)
+		}
+		clear(x.Map)
+		if x.Map == nil && n8 > 0 {
+			x.Map = make(map[uint16]int32, goc.PreallocMapLen[uint16, int32](n8))
+		}
+		for range n8 {
+			var k10 uint16
+			{
+				v12, err := goc.ReadUvarint(r)
+				if err != nil {
+					return fmt.Errorf("decoding VarintObject.Map[key]: %w", err)
+				}
+				if v12 > math.MaxUint16 {
+					return fmt.Errorf("decoding VarintObject.Map[key]: %w", &goc.OverflowError{Value: strconv.FormatUint(v12, 10), Type: "uint16"})
+				}
+				k10 = uint16(v12)
+			}
+			var v11 int32
+			{
+				v13, err := goc.ReadVarint(r)
+				if err != nil {
+					return fmt.Errorf("decoding VarintObject.Map[]: %w", err)
+				}
+				if v13 < math.MinInt32 || v13 > math.MaxInt32 {
+					return fmt.Errorf("decoding VarintObject.Map[]: %w", &goc.OverflowError{Value: strconv.FormatInt(v13, 10), Type: "int32"})
+				}
+				v11 = int32(v13)
+			}
+			x.Map[k10] = v11
+		}
+		if err := goc.CheckDuplicateKey(r, len(x.Map) != n8); err != nil {
+			return fmt.Errorf("decoding VarintObject.Map: %w", err)
+		}
+	}
+	return nil
+}
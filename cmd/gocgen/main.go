@@ -0,0 +1,61 @@
+// Gocgen generates reflection-free EncodeTo and DecodeFrom methods for struct types,
+// implementing [goc.EncodeWriter] and [goc.DecodeReader].
+//
+// The generated methods produce the same bytes as the reflection-based goc encoder,
+// so peers using generated and non-generated code interoperate.
+//
+// Usage:
+//
+//	//go:generate go run github.com/samborkent/gorpc/cmd/gocgen -type=Request,Response
+//
+// Flags:
+//
+//	-type       comma-separated list of struct type names, defaults to all struct types in the package
+//	-output     output file name, defaults to goc_gen.go in the package directory
+//	-varint     generate code compatible with [goc.WithVarint]
+//	-canonical  generate code that writes map entries in canonical order, see [goc.WithCanonical]
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("gocgen: ")
+
+	typeNames := flag.String("type", "", "comma-separated list of struct type names")
+	output := flag.String("output", "goc_gen.go", "output file name")
+	varint := flag.Bool("varint", false, "generate code compatible with goc.WithVarint")
+	canonical := flag.Bool("canonical", false, "generate code that writes map entries in canonical order")
+	flag.Parse()
+
+	dir := "."
+	if flag.NArg() > 0 {
+		dir = flag.Arg(0)
+	}
+
+	var names []string
+	if *typeNames != "" {
+		names = strings.Split(*typeNames, ",")
+	}
+
+	outputPath := *output
+	if !filepath.IsAbs(outputPath) {
+		outputPath = filepath.Join(dir, outputPath)
+	}
+
+	src, err := generate(dir, filepath.Base(outputPath), names, *varint, *canonical)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if err := os.WriteFile(outputPath, src, 0o644); err != nil {
+		log.Fatal(fmt.Errorf("writing output: %w", err))
+	}
+}
@@ -3,6 +3,7 @@ package gorpc
 const (
 	HeaderAccept = "Accept"
 	HeaderContentType = "Content-Type"
+	HeaderContentLength = "Content-Length"
 	HeaderXContentTypeOptions = "X-Content-Type-Options"
 	HeaderMethodHash  = "X-Method-Hash"
 
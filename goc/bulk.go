@@ -0,0 +1,207 @@
+package goc
+
+import (
+	"encoding/binary"
+	"fmt"
+	"reflect"
+)
+
+// Maximum number of bytes decoded at once by bulk decoders,
+// so large slices are not buffered twice.
+const bulkChunkSize = 4096
+
+// bulkElemSize returns the encoded size of elements of type t if slices and arrays of t can be encoded
+// as one contiguous block of little-endian values.
+func bulkElemSize(t reflect.Type, enc intEncoding) (int, bool) {
+	if _, ok := stdlibCodecs[t]; ok || customEncodingOf(t) != customNone {
+		return 0, false
+	}
+
+	switch t.Kind() {
+	case reflect.Bool, reflect.Int8, reflect.Uint8:
+		return 1, true
+	case reflect.Int16, reflect.Uint16, reflect.Int32, reflect.Uint32, reflect.Int64, reflect.Uint64:
+		// Varints have no fixed size.
+		if enc == intVarint {
+			return 0, false
+		}
+
+		return int(t.Size()), true
+	case reflect.Float32, reflect.Float64, reflect.Complex64, reflect.Complex128:
+		return int(t.Size()), true
+	default:
+		return 0, false
+	}
+}
+
+// isByteArray reports whether t is an array of bytes, such as a UUID.
+// Its length is fixed, so it is encoded as its bytes without a length prefix.
+func isByteArray(t reflect.Type) bool {
+	if t.Kind() != reflect.Array || t.Elem().Kind() != reflect.Uint8 {
+		return false
+	}
+
+	_, ok := bulkElemSize(t.Elem(), intFixed)
+
+	return ok
+}
+
+// bulkSlice returns v as a slice with an unnamed slice type, so the fast paths of [binary.Append] apply.
+// Arrays that are not addressable are copied.
+func bulkSlice(v reflect.Value, sliceType reflect.Type) reflect.Value {
+	if v.Kind() == reflect.Array {
+		if !v.CanAddr() {
+			v = addressable(v).Elem()
+		}
+
+		v = v.Slice(0, v.Len())
+	}
+
+	if v.Type() != sliceType {
+		v = v.Convert(sliceType)
+	}
+
+	return v
+}
+
+// compileBulkEncoder encodes slices and arrays of fixed-size numbers as one contiguous block.
+func compileBulkEncoder(t reflect.Type, enc intEncoding) (encodeFunc, bool) {
+	_, ok := bulkElemSize(t.Elem(), enc)
+	if !ok {
+		return nil, false
+	}
+
+	sliceType := reflect.SliceOf(t.Elem())
+
+	if isByteArray(t) {
+		return func(e *encodeState, v reflect.Value) error {
+			e.buf = append(e.buf, bulkSlice(v, sliceType).Bytes()...)
+			return nil
+		}, true
+	}
+
+	return func(e *encodeState, v reflect.Value) error {
+		if err := e.appendLen(v.Len(), enc); err != nil {
+			return fmt.Errorf("encoding %s len: %w", v.Kind().String(), err)
+		}
+
+		if v.Len() == 0 {
+			return nil
+		}
+
+		s := bulkSlice(v, sliceType)
+
+		if t.Elem().Kind() == reflect.Uint8 {
+			e.buf = append(e.buf, s.Bytes()...)
+			return nil
+		}
+
+		buf, err := binary.Append(e.buf, binary.LittleEndian, s.Interface())
+		if err != nil {
+			return fmt.Errorf("encoding %s: %w", t.String(), err)
+		}
+
+		e.buf = buf
+
+		return nil
+	}, true
+}
+
+// compileBulkDecoder decodes slices and arrays encoded by [compileBulkEncoder].
+func compileBulkDecoder(t reflect.Type, enc intEncoding) (decodeFunc, bool) {
+	size, ok := bulkElemSize(t.Elem(), enc)
+	if !ok {
+		return nil, false
+	}
+
+	sliceType := reflect.SliceOf(t.Elem())
+
+	if isByteArray(t) {
+		return func(d *decodeState, v reflect.Value) error {
+			if err := d.readFull(bulkSlice(v, sliceType).Bytes()); err != nil {
+				return fmt.Errorf("decoding %s: %w", t.String(), err)
+			}
+
+			return nil
+		}, true
+	}
+
+	return func(d *decodeState, v reflect.Value) error {
+		length, err := d.readLen(enc)
+		if err != nil {
+			return fmt.Errorf("decoding %s length: %w", t.Kind().String(), err)
+		}
+
+		if t.Kind() == reflect.Array && length > v.Len() {
+			return fmt.Errorf("decoded length %d exceeds array length %d", length, v.Len())
+		}
+
+		if t.Kind() == reflect.Slice {
+			resetSlice(v)
+		} else {
+			clearArray(v, length)
+		}
+
+		if length == 0 {
+			return nil
+		}
+
+		if t.Kind() == reflect.Slice {
+			if err := d.checkLength(length); err != nil {
+				return fmt.Errorf("decoding %s: %w", t.String(), err)
+			}
+
+			if err := d.reserve(length * size); err != nil {
+				return fmt.Errorf("decoding %s: %w", t.String(), err)
+			}
+
+			// Allocate the underlying slice, or reuse its capacity.
+			// It grows as elements are read, so a length prefix alone cannot force a large allocation.
+			v.Grow(preallocLen(length, t.Elem().Size()))
+		}
+
+		chunkLen := max(bulkChunkSize/size, 1)
+		if t.Elem().Kind() == reflect.Uint8 {
+			chunkLen = maxPreallocBytes
+		}
+
+		for i := 0; i < length; i += chunkLen {
+			j := min(i+chunkLen, length)
+
+			if t.Kind() == reflect.Slice {
+				if j > v.Cap() {
+					v.Grow(min(max(j, 2*v.Cap()), length) - v.Len())
+				}
+
+				v.SetLen(j)
+			}
+
+			s := bulkSlice(v, sliceType).Slice(i, j)
+
+			if t.Elem().Kind() == reflect.Uint8 {
+				if err := d.readFull(s.Bytes()); err != nil {
+					return fmt.Errorf("decoding %s: %w", t.String(), err)
+				}
+
+				continue
+			}
+
+			b, err := d.readBytes((j - i) * size)
+			if err != nil {
+				return fmt.Errorf("decoding %s index %d: %w", t.String(), i, err)
+			}
+
+			if t.Elem().Kind() == reflect.Bool {
+				if err := d.checkBools(b); err != nil {
+					return fmt.Errorf("decoding %s index %d: %w", t.String(), i, err)
+				}
+			}
+
+			if _, err := binary.Decode(b, binary.LittleEndian, s.Interface()); err != nil {
+				return fmt.Errorf("decoding %s index %d: %w", t.String(), i, err)
+			}
+		}
+
+		return nil
+	}, true
+}
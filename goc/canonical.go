@@ -0,0 +1,48 @@
+package goc
+
+import (
+	"bytes"
+	"slices"
+)
+
+// EncodeCanonical encodes val like [Encode] with [WithCanonical], so equal values always produce identical bytes.
+func EncodeCanonical[T any](val T, options ...Option) ([]byte, error) {
+	return Encode(val, append(slices.Clip(options), WithCanonical())...)
+}
+
+// MapEntry holds the offsets of an encoded map entry in a buffer.
+type MapEntry struct {
+	// Start of the encoded key.
+	Start int
+	// End of the encoded key and start of the encoded value.
+	KeyEnd int
+	// End of the encoded value.
+	End int
+}
+
+// SortMapEntries sorts the map entries encoded in b[start:] by their encoded key bytes, and returns b.
+// The entries must be contiguous and cover b[start:].
+// It is used by code generated by gocgen with -canonical.
+func SortMapEntries(b []byte, start int, entries []MapEntry) []byte {
+	if len(entries) < 2 {
+		return b
+	}
+
+	slices.SortFunc(entries, func(x, y MapEntry) int {
+		if c := bytes.Compare(b[x.Start:x.KeyEnd], b[y.Start:y.KeyEnd]); c != 0 {
+			return c
+		}
+
+		// Distinct keys may have the same encoding, such as NaN floats, order them by their values.
+		return bytes.Compare(b[x.KeyEnd:x.End], b[y.KeyEnd:y.End])
+	})
+
+	encoded := slices.Clone(b[start:])
+	b = b[:start]
+
+	for _, entry := range entries {
+		b = append(b, encoded[entry.Start-start:entry.End-start]...)
+	}
+
+	return b
+}
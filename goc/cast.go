@@ -10,3 +10,11 @@ func castGeneric[B, A any](a A) (B, error) {
 
 	return b, nil
 }
+
+func deref[T any](v *T, err error) (T, error) {
+	if err != nil {
+		return *new(T), err
+	}
+
+	return *v, nil
+}
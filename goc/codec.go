@@ -0,0 +1,121 @@
+package goc
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"reflect"
+
+	isync "github.com/samborkent/gorpc/internal/sync"
+)
+
+// codec is the encoding of a type registered with [RegisterCodec].
+type codec struct {
+	encode func(w io.Writer, v reflect.Value) error
+	decode func(r io.Reader, v reflect.Value) error
+}
+
+// Codecs by type.
+var codecs isync.Map[reflect.Type, *codec]
+
+// RegisterCodec registers the encoding of values of type T, for types that cannot implement
+// an encoding interface themselves, such as types of third-party packages.
+// Registered codecs take precedence over the encoding interfaces T implements, and are used for values of T
+// at the top level and nested inside other values alike.
+//
+// Values are prefixed with the length of their encoding, also at the top level, so decode cannot read past
+// the value. decode must read every byte written by encode, otherwise [ErrTrailingBytes] is returned.
+// Code generated by gocgen encodes fields of types of other packages through goc, so it uses registered codecs too,
+// except for embedded fields, which it flattens.
+// Codecs must be registered before the first use of T, on both sides.
+//
+// RegisterCodec panics if encode or decode is nil, if T is a pointer or interface type,
+// if T already has a dedicated encoding, or if a codec for T is already registered.
+func RegisterCodec[T any](encode func(io.Writer, T) error, decode func(io.Reader) (T, error)) {
+	t := reflect.TypeFor[T]()
+
+	switch {
+	case encode == nil || decode == nil:
+		panic(fmt.Sprintf("goc: nil codec function for %s", t.String()))
+	case t.Kind() == reflect.Pointer || t.Kind() == reflect.Interface:
+		panic(fmt.Sprintf("goc: cannot register codec for %s type %s", t.Kind().String(), t.String()))
+	case isOptional(t):
+		panic(fmt.Sprintf("goc: cannot register codec for %s, it has a dedicated encoding", t.String()))
+	}
+
+	if _, ok := stdlibCodecs[t]; ok {
+		panic(fmt.Sprintf("goc: cannot register codec for %s, it has a dedicated encoding", t.String()))
+	}
+
+	c := &codec{
+		encode: func(w io.Writer, v reflect.Value) error {
+			val, _ := reflect.TypeAssert[T](v)
+			return encode(w, val)
+		},
+		decode: func(r io.Reader, v reflect.Value) error {
+			val, err := decode(r)
+			if err != nil {
+				return err
+			}
+
+			v.Set(reflect.ValueOf(&val).Elem())
+
+			return nil
+		},
+	}
+
+	if _, loaded := codecs.LoadOrStore(t, c); loaded {
+		panic(fmt.Sprintf("goc: codec for %s registered twice", t.String()))
+	}
+}
+
+// codecOf returns the registered codec of type t, if it has one.
+func codecOf(t reflect.Type) (*codec, bool) {
+	return codecs.Load(t)
+}
+
+func compileCodecEncoder(t reflect.Type, c *codec, enc intEncoding) encodeFunc {
+	return func(e *encodeState, v reflect.Value) error {
+		scratch := getEncodeState(nil, config{})
+		defer putEncodeState(scratch)
+
+		if err := c.encode(scratch, v); err != nil {
+			return fmt.Errorf("codec %s: %w", t.String(), err)
+		}
+
+		return e.appendFramed(scratch.buf, enc)
+	}
+}
+
+func compileCodecDecoder(t reflect.Type, c *codec, enc intEncoding) decodeFunc {
+	return func(d *decodeState, v reflect.Value) error {
+		encoded, err := d.readFramed(enc)
+		if err != nil {
+			return fmt.Errorf("codec %s: %w", t.String(), err)
+		}
+
+		r := bytes.NewReader(encoded)
+
+		if err := c.decode(r, v); err != nil {
+			return fmt.Errorf("codec %s: %w", t.String(), err)
+		}
+
+		if r.Len() != 0 {
+			return fmt.Errorf("codec %s: %w: %d bytes", t.String(), ErrTrailingBytes, r.Len())
+		}
+
+		return nil
+	}
+}
+
+func compileCodecSizer(t reflect.Type, c *codec, enc intEncoding) sizeFunc {
+	return func(v reflect.Value) (int, error) {
+		var w countingWriter
+
+		if err := c.encode(&w, v); err != nil {
+			return 0, fmt.Errorf("codec %s: %w", t.String(), err)
+		}
+
+		return lenSize(int(w), enc) + int(w), nil
+	}
+}
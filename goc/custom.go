@@ -0,0 +1,211 @@
+package goc
+
+import (
+	"encoding"
+	"fmt"
+	"io"
+	"reflect"
+)
+
+// customEncoding is the pair of encoding interfaces used for a nested value.
+type customEncoding uint8
+
+const (
+	customNone customEncoding = iota
+	// EncodeWriter and DecodeReader, the value is written unframed.
+	// DecodeFrom must read exactly the bytes written by EncodeTo.
+	customStream
+	// Encoder and Decoder, the value is prefixed with its length.
+	customBytes
+	// encoding.BinaryMarshaler and encoding.BinaryUnmarshaler, the value is prefixed with its length.
+	customBinary
+	// A codec registered with RegisterCodec, the value is prefixed with its length.
+	customCodec
+)
+
+// customEncodingOf returns the custom encoding of values of type t nested inside other values.
+// A type only uses a custom encoding if it implements both the encoding and the matching decoding interface,
+// so the encoder and decoder always agree. A registered codec takes precedence over the interfaces.
+func customEncodingOf(t reflect.Type) customEncoding {
+	if _, ok := codecOf(t); ok {
+		return customCodec
+	}
+
+	if t.Kind() == reflect.Pointer || t.Kind() == reflect.Interface {
+		return customNone
+	}
+
+	ptr := reflect.PointerTo(t)
+
+	switch {
+	case ptr.Implements(reflectEncodeWriter) && ptr.Implements(reflectDecodeReader):
+		return customStream
+	case ptr.Implements(reflectEncoder) && ptr.Implements(reflectDecoder):
+		return customBytes
+	case ptr.Implements(reflectBinaryMarshaller) && ptr.Implements(reflectBinaryUnmarshaller):
+		return customBinary
+	default:
+		return customNone
+	}
+}
+
+// addressable returns a pointer to v, copying v if it is not addressable,
+// so methods with pointer receivers can be called.
+func addressable(v reflect.Value) reflect.Value {
+	if v.CanAddr() {
+		return v.Addr()
+	}
+
+	ptr := reflect.New(v.Type())
+	ptr.Elem().Set(v)
+
+	return ptr
+}
+
+func compileCustomEncoder(t reflect.Type, enc intEncoding) (encodeFunc, bool) {
+	switch customEncodingOf(t) {
+	case customStream:
+		return func(e *encodeState, v reflect.Value) error {
+			encoder, _ := reflect.TypeAssert[EncodeWriter](addressable(v))
+
+			if err := encoder.EncodeTo(e); err != nil {
+				return fmt.Errorf("EncodeWriter: %w", err)
+			}
+
+			return nil
+		}, true
+	case customBytes:
+		return func(e *encodeState, v reflect.Value) error {
+			encoder, _ := reflect.TypeAssert[Encoder](addressable(v))
+
+			encoded, err := encoder.Encode()
+			if err != nil {
+				return fmt.Errorf("Encoder: %w", err)
+			}
+
+			return e.appendFramed(encoded, enc)
+		}, true
+	case customBinary:
+		return func(e *encodeState, v reflect.Value) error {
+			marshaler, _ := reflect.TypeAssert[encoding.BinaryMarshaler](addressable(v))
+
+			encoded, err := marshaler.MarshalBinary()
+			if err != nil {
+				return fmt.Errorf("BinaryMarshaler: %w", err)
+			}
+
+			return e.appendFramed(encoded, enc)
+		}, true
+	case customCodec:
+		c, _ := codecOf(t)
+
+		return compileCodecEncoder(t, c, enc), true
+	default:
+		return nil, false
+	}
+}
+
+func compileCustomDecoder(t reflect.Type, enc intEncoding) (decodeFunc, bool) {
+	switch customEncodingOf(t) {
+	case customStream:
+		return func(d *decodeState, v reflect.Value) error {
+			decoder, _ := reflect.TypeAssert[DecodeReader](v.Addr())
+
+			if err := decoder.DecodeFrom(d); err != nil {
+				return fmt.Errorf("DecodeReader: %w", err)
+			}
+
+			return nil
+		}, true
+	case customBytes:
+		return func(d *decodeState, v reflect.Value) error {
+			encoded, err := d.readFramed(enc)
+			if err != nil {
+				return fmt.Errorf("Decoder: %w", err)
+			}
+
+			decoder, _ := reflect.TypeAssert[Decoder](v.Addr())
+
+			if err := decoder.Decode(encoded); err != nil {
+				return fmt.Errorf("Decoder: %w", err)
+			}
+
+			return nil
+		}, true
+	case customBinary:
+		return func(d *decodeState, v reflect.Value) error {
+			encoded, err := d.readFramed(enc)
+			if err != nil {
+				return fmt.Errorf("BinaryUnmarshaler: %w", err)
+			}
+
+			unmarshaler, _ := reflect.TypeAssert[encoding.BinaryUnmarshaler](v.Addr())
+
+			if err := unmarshaler.UnmarshalBinary(encoded); err != nil {
+				return fmt.Errorf("BinaryUnmarshaler: %w", err)
+			}
+
+			return nil
+		}, true
+	case customCodec:
+		c, _ := codecOf(t)
+
+		return compileCodecDecoder(t, c, enc), true
+	default:
+		return nil, false
+	}
+}
+
+// appendFramed appends length-prefixed bytes produced by a custom encoder.
+func (e *encodeState) appendFramed(b []byte, enc intEncoding) error {
+	if err := e.appendLen(len(b), enc); err != nil {
+		return fmt.Errorf("length: %w", err)
+	}
+
+	e.buf = append(e.buf, b...)
+
+	return nil
+}
+
+// readFramed reads length-prefixed bytes for a custom decoder.
+// The returned slice is owned by the caller, as decoders may retain it.
+func (d *decodeState) readFramed(enc intEncoding) ([]byte, error) {
+	length, err := d.readLen(enc)
+	if err != nil {
+		return nil, fmt.Errorf("length: %w", err)
+	}
+
+	if err := d.reserve(length); err != nil {
+		return nil, err
+	}
+
+	return ReadAppend(d, make([]byte, 0, preallocLen(length, 1)), length)
+}
+
+// AppendField appends the encoding of *v as a value nested inside another value, such as a struct field.
+// It is used by code generated by gocgen for types it does not generate code for.
+func AppendField[T any](b []byte, v *T, options ...Option) ([]byte, error) {
+	cfg, err := newConfig(options)
+	if err != nil {
+		return b, err
+	}
+
+	e := encodeState{buf: b, canonical: cfg.canonical, refs: newRefs(cfg)}
+
+	if err := encoderFor(reflect.TypeFor[T](), cfg.intEncoding)(&e, reflect.ValueOf(v).Elem()); err != nil {
+		return b, err
+	}
+
+	return e.buf, nil
+}
+
+// DecodeField decodes a value nested inside another value, such as a struct field, into *v.
+// It is used by code generated by gocgen for types it does not generate code for.
+func DecodeField[T any](r io.Reader, v *T, options ...Option) error {
+	cfg, err := newConfig(options)
+	if err != nil {
+		return err
+	}
+
+	return decoderFor(reflect.TypeFor[T](), cfg.intEncoding)(newDecodeState(cfg.limit(r)), reflect.ValueOf(v).Elem())
+}
@@ -16,8 +16,9 @@ func decodeConcrete[T any](r io.Reader) (T, error) {
 	// TODO: avoid allocation
 	d := make([]byte, reflect.TypeFor[T]().Size())
 
-	n, err := r.Read(d)
-	if n == 0 && err != nil && !errors.Is(err, io.EOF) {
+	if _, err := io.ReadFull(r, d); err == io.EOF {
+		return zero, io.ErrUnexpectedEOF
+	} else if err != nil {
 		return zero, err
 	}
 
@@ -3,9 +3,10 @@ package goc
 import (
 	"bytes"
 	"encoding"
-	"errors"
+	"encoding/binary"
 	"fmt"
 	"io"
+	"math"
 	"reflect"
 )
 
@@ -17,21 +18,60 @@ type Decoder interface {
 	Decode([]byte) error
 }
 
-func Decode[T any](b []byte) (T, error) {
-	return DecodeFrom[T](bytes.NewReader(b))
+func Decode[T any](b []byte, options ...Option) (T, error) {
+	return DecodeFrom[T](bytes.NewReader(b), options...)
 }
 
-func DecodeFrom[T any](r io.Reader) (T, error) {
+func DecodeFrom[T any](r io.Reader, options ...Option) (T, error) {
+	cfg, err := newConfig(options)
+	if err != nil {
+		return *new(T), err
+	}
+
+	r = cfg.limit(r)
+
+	val, err := decodeFrom[T](r, cfg)
+	if err != nil {
+		return val, err
+	}
+
+	if err := cfg.checkTrailing(r); err != nil {
+		return *new(T), err
+	}
+
+	return val, nil
+}
+
+func decodeFrom[T any](r io.Reader, cfg config) (T, error) {
+	if t := reflect.TypeFor[T](); t.Kind() == reflect.Interface && !isTopLevelInterface(t) {
+		return *new(T), errTopLevelAny
+	}
+
+	if cfg.schemaHeader {
+		if err := readSchemaHeader(r, reflect.TypeFor[T](), cfg.intEncoding); err != nil {
+			return *new(T), err
+		}
+	}
+
 	val := new(T)
 
+	// Standard library types and types with a codec have dedicated encodings, even if they implement a decoding interface.
+	if hasDedicatedEncoding(reflect.TypeFor[T]()) {
+		if err := decodeValue(r, reflect.ValueOf(val), cfg.intEncoding); err != nil {
+			return *new(T), fmt.Errorf("decodeValue: %w", err)
+		}
+
+		return *val, nil
+	}
+
 	// Try to decode through interface implementation.
 	switch decoder := any(val).(type) {
 	case DecodeReader:
-		return decodeDecodeReader[T](r, decoder)
+		return deref(decodeDecodeReader[*T](r, decoder))
 	case Decoder:
-		return decodeDecoder[T](r, decoder)
+		return deref(decodeDecoder[*T](r, decoder))
 	case encoding.BinaryUnmarshaler:
-		return decodeBinaryUnmarshaler[T](r, decoder)
+		return deref(decodeBinaryUnmarshaler[*T](r, decoder))
 	}
 
 	switch decoder := any(*val).(type) {
@@ -45,44 +85,226 @@ func DecodeFrom[T any](r io.Reader) (T, error) {
 
 	var zero T
 
-	// Try to decode concrete type.
-	switch reflect.TypeOf(zero).Kind() {
+	// Try to decode concrete type. Values of interface types other than any are decoded like nested ones.
+	switch reflect.TypeFor[T]().Kind() {
+	case reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if cfg.intEncoding == intVarint {
+			break
+		}
+
+		fallthrough
 	case reflect.Bool,
-		reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
-		reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Int8,
+		reflect.Uint8,
 		reflect.Float32, reflect.Float64,
 		reflect.Complex64, reflect.Complex128:
+		// Named scalar types, and bools that are checked, are decoded through reflection.
+		if reflect.TypeFor[T]().PkgPath() != "" || cfg.strict && reflect.TypeFor[T]().Kind() == reflect.Bool {
+			break
+		}
+
 		val, err := decodeConcrete[T](r)
 		if err != nil {
-			return zero, fmt.Errorf("decoding %s: %w", reflect.TypeOf(zero).String(), err)
+			return zero, fmt.Errorf("decoding %s: %w", reflect.TypeFor[T]().String(), err)
 		}
 
 		return val, nil
 	case reflect.String:
-		strBytes, err := io.ReadAll(r)
+		str, err := readRawString(r, cfg)
 		if err != nil {
-			return zero, fmt.Errorf("reading encoded string: %w", err)
+			return zero, err
 		}
 
-		// TODO: avoid allocation
-		return castGeneric[T](string(strBytes))
+		return castGeneric[T](str)
 	}
 
 	// Decode through reflection.
-	if err := DecodeValue(r, reflect.ValueOf(val)); err != nil {
-		return zero, fmt.Errorf("DecodeValue: %w", err)
+	if err := decodeValue(r, reflect.ValueOf(val), cfg.intEncoding); err != nil {
+		return zero, fmt.Errorf("decodeValue: %w", err)
 	}
 
 	return *val, nil
 }
 
+// readRawString reads a top-level string, which is not length-prefixed.
+func readRawString(r io.Reader, cfg config) (string, error) {
+	// The length limit is enforced while reading.
+	limited := r
+	if cfg.limits.maxStringLength != 0 {
+		limited = io.LimitReader(r, int64(cfg.limits.maxStringLength)+1)
+	}
+
+	strBytes, err := io.ReadAll(limited)
+	if err != nil {
+		return "", fmt.Errorf("reading encoded string: %w", err)
+	}
+
+	if err := CheckStringLength(r, len(strBytes)); err != nil {
+		return "", fmt.Errorf("reading encoded string: %w", err)
+	}
+
+	if err := CheckUTF8(r, strBytes); err != nil {
+		return "", fmt.Errorf("reading encoded string: %w", err)
+	}
+
+	// TODO: avoid allocation
+	return string(strBytes), nil
+}
+
+// DecodeBytesInto decodes b into the value pointed to by ptr, see [DecodeInto].
+func DecodeBytesInto[T any](b []byte, ptr *T, options ...Option) error {
+	return DecodeInto(bytes.NewReader(b), ptr, options...)
+}
+
+// DecodeInto decodes a value from r into the value pointed to by ptr, reusing the memory it already holds.
+// Every part of the value is overwritten, so the result equals that of [DecodeFrom],
+// except that empty slices and maps that were non-nil stay non-nil:
+//   - Slices are resliced to the decoded length, their capacity is reused.
+//   - Array elements past the decoded length are set to their zero value.
+//   - Maps are cleared before decoded entries are added.
+//   - Non-nil pointers are decoded into the value they point to, nil pointers are allocated.
+//   - Strings, interface values and structs with numbered fields are replaced.
+//
+// Types with custom encodings decide themselves how to reuse memory.
+// If an error is returned, the value pointed to by ptr may be partially decoded.
+func DecodeInto[T any](r io.Reader, ptr *T, options ...Option) error {
+	if ptr == nil {
+		return ErrInvalidValue
+	}
+
+	cfg, err := newConfig(options)
+	if err != nil {
+		return err
+	}
+
+	r = cfg.limit(r)
+
+	if err := decodeInto(r, ptr, cfg); err != nil {
+		return err
+	}
+
+	return cfg.checkTrailing(r)
+}
+
+func decodeInto[T any](r io.Reader, ptr *T, cfg config) error {
+	t := reflect.TypeFor[T]()
+
+	if t.Kind() == reflect.Interface && !isTopLevelInterface(t) {
+		return errTopLevelAny
+	}
+
+	if cfg.schemaHeader {
+		if err := readSchemaHeader(r, t, cfg.intEncoding); err != nil {
+			return err
+		}
+	}
+
+	// Standard library types and types with a codec have dedicated encodings, even if they implement a decoding interface.
+	if hasDedicatedEncoding(t) {
+		if err := decodeValue(r, reflect.ValueOf(ptr), cfg.intEncoding); err != nil {
+			return fmt.Errorf("decodeValue: %w", err)
+		}
+
+		return nil
+	}
+
+	// Try to decode through interface implementation.
+	switch decoder := any(ptr).(type) {
+	case DecodeReader:
+		if err := decoder.DecodeFrom(r); err != nil {
+			return fmt.Errorf("DecodeFrom: %w", err)
+		}
+
+		return nil
+	case Decoder:
+		return decodeValueDecoder(r, reflect.ValueOf(decoder))
+	case encoding.BinaryUnmarshaler:
+		return decodeValueBinaryUnmarshaler(r, reflect.ValueOf(decoder))
+	}
+
+	// Try to decode concrete type.
+	switch t.Kind() {
+	case reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if cfg.intEncoding == intVarint {
+			break
+		}
+
+		fallthrough
+	case reflect.Bool,
+		reflect.Int8,
+		reflect.Uint8,
+		reflect.Float32, reflect.Float64,
+		reflect.Complex64, reflect.Complex128:
+		// Named scalar types, and bools that are checked, are decoded through reflection.
+		if t.PkgPath() != "" || cfg.strict && t.Kind() == reflect.Bool {
+			break
+		}
+
+		val, err := decodeConcrete[T](r)
+		if err != nil {
+			return fmt.Errorf("decoding %s: %w", t.String(), err)
+		}
+
+		*ptr = val
+
+		return nil
+	case reflect.String:
+		str, err := readRawString(r, cfg)
+		if err != nil {
+			return err
+		}
+
+		reflect.ValueOf(ptr).Elem().SetString(str)
+
+		return nil
+	}
+
+	// Decode through reflection.
+	if err := decodeValue(r, reflect.ValueOf(ptr), cfg.intEncoding); err != nil {
+		return fmt.Errorf("decodeValue: %w", err)
+	}
+
+	return nil
+}
+
 var (
 	reflectDecodeReader       = reflect.TypeFor[DecodeReader]()
 	reflectDecoder            = reflect.TypeFor[Decoder]()
 	reflectBinaryUnmarshaller = reflect.TypeFor[encoding.BinaryUnmarshaler]()
 )
 
-func DecodeValue(r io.Reader, v reflect.Value) error {
+func DecodeValue(r io.Reader, v reflect.Value, options ...Option) error {
+	cfg, err := newConfig(options)
+	if err != nil {
+		return err
+	}
+
+	if !v.IsValid() {
+		return ErrInvalidValue
+	}
+
+	r = cfg.limit(r)
+
+	if err := decodeValueConfig(r, v, cfg); err != nil {
+		return err
+	}
+
+	return cfg.checkTrailing(r)
+}
+
+func decodeValueConfig(r io.Reader, v reflect.Value, cfg config) error {
+	if cfg.schemaHeader {
+		if err := readSchemaHeader(r, v.Type(), cfg.intEncoding); err != nil {
+			return err
+		}
+	}
+
+	if hasDedicatedEncoding(v.Type()) {
+		return decodeValue(r, v, cfg.intEncoding)
+	}
+
 	if v.CanAddr() {
 		t := v.Type()
 
@@ -95,249 +317,657 @@ func DecodeValue(r io.Reader, v reflect.Value) error {
 		}
 	}
 
-	return decodeValue(r, v)
+	return decodeValue(r, v, cfg.intEncoding)
 }
 
-func decodeValue(r io.Reader, v reflect.Value) error {
+func decodeValue(r io.Reader, v reflect.Value, enc intEncoding) error {
 	if !v.IsValid() {
 		return ErrInvalidValue
 	}
 
-	indirections, err := numIndirections(v.Type())
+	v, err := indirectValue(v, true)
 	if err != nil {
 		return err
 	}
 
-	for range indirections {
-		v = reflect.Indirect(v)
+	d := newDecodeState(r)
+	d.trackRoot(v)
+
+	return decoderFor(v.Type(), enc)(d, v)
+}
+
+func compileDecoder(t reflect.Type, enc intEncoding) decodeFunc {
+	if f, ok := compileStdlibDecoder(t, enc); ok {
+		return f
 	}
 
-	t := v.Type()
+	if f, ok := compileCustomDecoder(t, enc); ok {
+		return f
+	}
 
-	var d []byte
+	if isOptional(t) {
+		return compileOptionalDecoder(t, enc)
+	}
 
-	// Read concrete type.
 	switch t.Kind() {
-	case reflect.Bool,
-		reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
-		reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
-		reflect.Float32, reflect.Float64,
-		reflect.Complex64, reflect.Complex128:
-		d = make([]byte, t.Size())
-
-		n, err := r.Read(d)
-		if n == 0 && err != nil && !errors.Is(err, io.EOF) {
-			return fmt.Errorf("reading %s: %w", t.String(), err)
+	case reflect.Pointer:
+		if _, err := numIndirections(t); err != nil {
+			return func(*decodeState, reflect.Value) error {
+				return err
+			}
 		}
-	}
 
-	switch v.Kind() {
-	case reflect.Bool:
-		v.SetBool(decodeBool(d[0]))
-		return nil
-	case reflect.Int:
-		// TODO: test
-		var header [1]byte
+		elemDecoder := decoderFor(t.Elem(), enc)
 
-		n, err := r.Read(header[:])
-		if n == 0 && err != nil && !errors.Is(err, io.EOF) {
-			return fmt.Errorf("reading int header: %w", err)
-		}
+		return func(d *decodeState, v reflect.Value) error {
+			marker, id, err := d.readPointer()
+			if err != nil {
+				return fmt.Errorf("decoding %s presence: %w", t.String(), err)
+			}
 
-		switch header[0] {
-		case 4:
-			d = make([]byte, 4)
+			switch marker {
+			case absent:
+				v.SetZero()
+				return nil
+			case reference:
+				return d.setReference(v, id)
+			}
 
-			n, err = r.Read(d)
-			if n == 0 && err != nil && !errors.Is(err, io.EOF) {
+			// Existing values are reused, see [DecodeInto].
+			if v.IsNil() {
+				v.Set(reflect.New(t.Elem()))
+			}
+
+			d.trackPointer(v)
+
+			return elemDecoder(d, v.Elem())
+		}
+	case reflect.Bool:
+		return func(d *decodeState, v reflect.Value) error {
+			b, err := d.read(1)
+			if err != nil {
 				return fmt.Errorf("reading %s: %w", t.String(), err)
 			}
 
-			v.SetInt(int64(decodeInt32(d)))
+			if err := d.checkBool(b[0]); err != nil {
+				return fmt.Errorf("decoding %s: %w", t.String(), err)
+			}
+
+			v.SetBool(decodeBool(b[0]))
+
 			return nil
-		case 8:
-			d = make([]byte, 8)
+		}
+	case reflect.Int8:
+		return func(d *decodeState, v reflect.Value) error {
+			b, err := d.read(1)
+			if err != nil {
+				return fmt.Errorf("reading %s: %w", t.String(), err)
+			}
 
-			n, err = r.Read(d)
-			if n == 0 && err != nil && !errors.Is(err, io.EOF) {
+			v.SetInt(int64(int8(b[0])))
+
+			return nil
+		}
+	case reflect.Uint8:
+		return func(d *decodeState, v reflect.Value) error {
+			b, err := d.read(1)
+			if err != nil {
 				return fmt.Errorf("reading %s: %w", t.String(), err)
 			}
 
-			v.SetInt(decodeInt64(d))
+			v.SetUint(uint64(b[0]))
+
 			return nil
-		default:
-			return fmt.Errorf("unknown int size %d encountered", header[0])
 		}
-	case reflect.Int8:
-		v.SetInt(int64(int8(d[0])))
-		return nil
-	case reflect.Int16:
-		v.SetInt(int64(decodeInt16(d)))
-		return nil
-	case reflect.Int32:
-		v.SetInt(int64(decodeInt32(d)))
-		return nil
-	case reflect.Int64:
-		v.SetInt(decodeInt64(d))
-		return nil
-	case reflect.Uint, reflect.Uintptr:
-		// TODO: test
-		var header [1]byte
+	case reflect.Int, reflect.Int16, reflect.Int32, reflect.Int64:
+		if enc == intVarint {
+			return func(d *decodeState, v reflect.Value) error {
+				i, err := binary.ReadVarint(d)
+				if err != nil {
+					return fmt.Errorf("reading %s: %w", t.String(), err)
+				}
 
-		n, err := r.Read(header[:])
-		if n == 0 && err != nil && !errors.Is(err, io.EOF) {
-			return fmt.Errorf("reading %s header: %w", t.Kind(), err)
+				if err := checkInt(i, t.Bits(), t.String()); err != nil {
+					return err
+				}
+
+				v.SetInt(i)
+
+				return nil
+			}
 		}
 
-		switch header[0] {
-		case 4:
-			d = make([]byte, 4)
+		if t.Kind() == reflect.Int {
+			return compileIntHeaderDecoder(t, t.Bits())
+		}
+
+		size := int(t.Size())
 
-			n, err = r.Read(d)
-			if n == 0 && err != nil && !errors.Is(err, io.EOF) {
+		return func(d *decodeState, v reflect.Value) error {
+			b, err := d.read(size)
+			if err != nil {
 				return fmt.Errorf("reading %s: %w", t.String(), err)
 			}
 
-			v.SetUint(uint64(decodeUint32(d)))
+			switch size {
+			case 2:
+				v.SetInt(int64(decodeInt16(b)))
+			case 4:
+				v.SetInt(int64(decodeInt32(b)))
+			default:
+				v.SetInt(decodeInt64(b))
+			}
+
 			return nil
-		case 8:
-			d = make([]byte, 8)
+		}
+	case reflect.Uint, reflect.Uintptr, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if enc == intVarint {
+			return func(d *decodeState, v reflect.Value) error {
+				u, err := binary.ReadUvarint(d)
+				if err != nil {
+					return fmt.Errorf("reading %s: %w", t.String(), err)
+				}
 
-			n, err = r.Read(d)
-			if n == 0 && err != nil && !errors.Is(err, io.EOF) {
+				if err := checkUint(u, t.Bits(), t.String()); err != nil {
+					return err
+				}
+
+				v.SetUint(u)
+
+				return nil
+			}
+		}
+
+		if t.Kind() == reflect.Uint || t.Kind() == reflect.Uintptr {
+			return compileIntHeaderDecoder(t, t.Bits())
+		}
+
+		size := int(t.Size())
+
+		return func(d *decodeState, v reflect.Value) error {
+			b, err := d.read(size)
+			if err != nil {
 				return fmt.Errorf("reading %s: %w", t.String(), err)
 			}
 
-			v.SetUint(decodeUint64(d))
+			switch size {
+			case 2:
+				v.SetUint(uint64(decodeUint16(b)))
+			case 4:
+				v.SetUint(uint64(decodeUint32(b)))
+			default:
+				v.SetUint(decodeUint64(b))
+			}
+
 			return nil
-		default:
-			return fmt.Errorf("unknown %s size %d encountered", t.Kind(), header[0])
 		}
-	case reflect.Uint8:
-		v.SetUint(uint64(d[0]))
-		return nil
-	case reflect.Uint16:
-		v.SetUint(uint64(decodeUint16(d)))
-		return nil
-	case reflect.Uint32:
-		v.SetUint(uint64(decodeUint32(d)))
-		return nil
-	case reflect.Uint64:
-		v.SetUint(decodeUint64(d))
-		return nil
 	case reflect.Float32:
-		v.SetFloat(float64(decodeFloat32(d)))
-		return nil
+		return func(d *decodeState, v reflect.Value) error {
+			b, err := d.read(4)
+			if err != nil {
+				return fmt.Errorf("reading %s: %w", t.String(), err)
+			}
+
+			v.SetFloat(float64(decodeFloat32(b)))
+
+			return nil
+		}
 	case reflect.Float64:
-		v.SetFloat(decodeFloat64(d))
-		return nil
+		return func(d *decodeState, v reflect.Value) error {
+			b, err := d.read(8)
+			if err != nil {
+				return fmt.Errorf("reading %s: %w", t.String(), err)
+			}
+
+			v.SetFloat(decodeFloat64(b))
+
+			return nil
+		}
 	case reflect.Complex64:
-		v.SetComplex(complex128(decodeComplex64(d)))
-		return nil
+		return func(d *decodeState, v reflect.Value) error {
+			b, err := d.read(8)
+			if err != nil {
+				return fmt.Errorf("reading %s: %w", t.String(), err)
+			}
+
+			v.SetComplex(complex128(decodeComplex64(b)))
+
+			return nil
+		}
 	case reflect.Complex128:
-		v.SetComplex(decodeComplex128(d))
-		return nil
-	case reflect.String:
-		length, err := decodeConcrete[uint32](r)
-		if err != nil {
-			return fmt.Errorf("decoding string length: %w", err)
+		return func(d *decodeState, v reflect.Value) error {
+			b, err := d.read(16)
+			if err != nil {
+				return fmt.Errorf("reading %s: %w", t.String(), err)
+			}
+
+			v.SetComplex(decodeComplex128(b))
+
+			return nil
 		}
+	case reflect.String:
+		return func(d *decodeState, v reflect.Value) error {
+			length, err := d.readLen(enc)
+			if err != nil {
+				return fmt.Errorf("decoding string length: %w", err)
+			}
+
+			if length == 0 {
+				v.SetString("")
+				return nil
+			}
+
+			if err := d.checkStringLength(length); err != nil {
+				return fmt.Errorf("decoding string: %w", err)
+			}
+
+			b, err := d.readBytes(length)
+			if err != nil {
+				return fmt.Errorf("reading encoded string: %w", err)
+			}
+
+			if err := d.checkUTF8(b); err != nil {
+				return fmt.Errorf("decoding string: %w", err)
+			}
+
+			v.SetString(string(b))
 
-		if length == 0 {
-			v.SetString("")
 			return nil
 		}
+	case reflect.Struct:
+		return compileStructDecoder(t, enc)
+	case reflect.Array:
+		if f, ok := compileBulkDecoder(t, enc); ok {
+			return f
+		}
+
+		elemDecoder := decoderFor(t.Elem(), enc)
+
+		return func(d *decodeState, v reflect.Value) error {
+			length, err := d.readLen(enc)
+			if err != nil {
+				return fmt.Errorf("decoding array length: %w", err)
+			}
 
-		// TODO: sync.Pool
-		d := make([]byte, length)
+			if length > v.Len() {
+				return fmt.Errorf("decoded length %d exceeds array length %d", length, v.Len())
+			}
+
+			for i := range length {
+				if err := elemDecoder(d, v.Index(i)); err != nil {
+					return fmt.Errorf("decoding array index %d of type %s: %w", i, t.Elem().String(), err)
+				}
+			}
 
-		n, err := r.Read(d)
-		if n == 0 && err != nil && !errors.Is(err, io.EOF) {
-			return fmt.Errorf("reading encoded string: %w", err)
+			clearArray(v, length)
+
+			return nil
+		}
+	case reflect.Slice:
+		if f, ok := compileBulkDecoder(t, enc); ok {
+			return f
 		}
 
-		// TODO: avoid allocation?
-		v.SetString(string(d))
-		return nil
-	case reflect.Struct:
-		for i := range v.NumField() {
-			if err := decodeValue(r, v.Field(i)); err != nil {
-				return fmt.Errorf("decoding struct field %d of type %s: %w", i, v.Field(i).Type().String(), err)
+		elemDecoder := decoderFor(t.Elem(), enc)
+
+		return func(d *decodeState, v reflect.Value) error {
+			length, err := d.readLen(enc)
+			if err != nil {
+				return fmt.Errorf("decoding slice length: %w", err)
+			}
+
+			if length == 0 {
+				resetSlice(v)
+				return nil
+			}
+
+			if err := d.checkLength(length); err != nil {
+				return fmt.Errorf("decoding slice: %w", err)
+			}
+
+			// Allocate underlying slice, or reuse its capacity.
+			// The slice grows as elements are decoded, so a length prefix alone cannot force a large allocation.
+			resetSlice(v)
+			v.Grow(preallocLen(length, t.Elem().Size()))
+
+			for i := range length {
+				if i == v.Cap() {
+					v.Grow(min(i, length-i))
+				}
+
+				v.SetLen(i + 1)
+
+				if err := elemDecoder(d, v.Index(i)); err != nil {
+					return fmt.Errorf("decoding slice index %d of type %s: %w", i, t.Elem().String(), err)
+				}
 			}
+
+			return nil
 		}
+	case reflect.Interface:
+		return func(d *decodeState, v reflect.Value) error {
+			if u, ok := unionOf(t); ok {
+				return u.decode(d, t, v, enc)
+			}
 
-		return nil
-	case reflect.Array, reflect.Slice:
-		length32, err := decodeConcrete[uint32](r)
-		if err != nil {
-			return fmt.Errorf("decoding %s length: %w", v.Kind(), err)
+			b, err := d.read(4)
+			if err != nil {
+				return fmt.Errorf("decoding %s type identifier: %w", t.String(), err)
+			}
+
+			id := decodeUint32(b)
+			if id == nilTypeID {
+				v.SetZero()
+				return nil
+			}
+
+			concreteType, err := registeredType(id)
+			if err != nil {
+				return fmt.Errorf("decoding %s: %w", t.String(), err)
+			}
+
+			if !concreteType.AssignableTo(t) {
+				return fmt.Errorf("decoding %s: registered type %s does not implement it", t.String(), concreteType.String())
+			}
+
+			concrete := reflect.New(concreteType).Elem()
+
+			if err := decoderFor(concreteType, enc)(d, concrete); err != nil {
+				return fmt.Errorf("decoding %s of type %s: %w", t.String(), concreteType.String(), err)
+			}
+
+			v.Set(concrete)
+
+			return nil
 		}
+	case reflect.Map:
+		keyDecoder := decoderFor(t.Key(), enc)
+		valueDecoder := decoderFor(t.Elem(), enc)
+
+		return func(d *decodeState, v reflect.Value) error {
+			length, err := d.readLen(enc)
+			if err != nil {
+				return fmt.Errorf("decoding map length: %w", err)
+			}
+
+			// Existing maps are cleared and refilled, see [DecodeInto].
+			if !v.IsNil() {
+				v.Clear()
+			}
+
+			if length == 0 {
+				return nil
+			}
+
+			if err := d.checkLength(length); err != nil {
+				return fmt.Errorf("decoding map: %w", err)
+			}
+
+			if v.IsNil() {
+				v.Set(reflect.MakeMapWithSize(t, preallocLen(length, t.Key().Size()+t.Elem().Size())))
+			}
+
+			key := reflect.New(t.Key()).Elem()
+			value := reflect.New(t.Elem()).Elem()
+
+			for range length {
+				key.SetZero()
+
+				if err := keyDecoder(d, key); err != nil {
+					return fmt.Errorf("decoding map key: %w", err)
+				}
+
+				value.SetZero()
+
+				if err := valueDecoder(d, value); err != nil {
+					return fmt.Errorf("decoding map value: %w", err)
+				}
+
+				v.SetMapIndex(key, value)
+			}
+
+			// The map was cleared, so it is smaller than decoded if keys were duplicated.
+			if d.strict() && v.Len() != length {
+				return fmt.Errorf("decoding map: %w", ErrDuplicateMapKey)
+			}
 
-		if length32 == 0 {
 			return nil
 		}
+	default:
+		return func(*decodeState, reflect.Value) error {
+			return fmt.Errorf("decoding of type %s is not supported", t.String())
+		}
+	}
+}
 
-		length := int(length32)
-		elemType := t.Elem()
+// compileIntHeaderDecoder decodes int, uint and uintptr prefixed with a 1-byte header containing their size.
+// Values written by a peer with a larger int size return an [*OverflowError] if they do not fit the given size in bits.
+func compileIntHeaderDecoder(t reflect.Type, size int) decodeFunc {
+	signed := t.Kind() == reflect.Int
 
-		// Calculate number of indirection for slice's underlying type.
-		indirections, err := numIndirections(elemType)
+	return func(d *decodeState, v reflect.Value) error {
+		header, err := d.read(1)
 		if err != nil {
-			return err
+			return fmt.Errorf("reading %s header: %w", t.Kind(), err)
 		}
 
-		// Indirect the underlying slice type.
-		for range indirections {
-			elemType = elemType.Elem()
-		}
+		var b []byte
 
-		// Allocate underlying slice.
-		if v.Kind() == reflect.Slice {
-			v.Grow(length)
-			v.SetLen(length)
+		switch header[0] {
+		case 4, 8:
+			if b, err = d.read(int(header[0])); err != nil {
+				return fmt.Errorf("reading %s: %w", t.String(), err)
+			}
+		default:
+			return fmt.Errorf("%w: unknown %s size %d encountered", ErrInvalidIntSize, t.Kind(), header[0])
 		}
 
-		// Decode slice with underlying type of variable size.
-		for i := range length {
-			if err := decodeValue(r, v.Index(i)); err != nil {
-				return fmt.Errorf("decoding %s index %d of type %s: %w", v.Kind().String(), i, elemType.String(), err)
+		if signed {
+			i := int64(decodeInt32(b))
+			if len(b) == 8 {
+				i = decodeInt64(b)
+			}
+
+			if err := checkInt(i, size, t.String()); err != nil {
+				return err
 			}
+
+			v.SetInt(i)
+
+			return nil
+		}
+
+		u := uint64(decodeUint32(b))
+		if len(b) == 8 {
+			u = decodeUint64(b)
+		}
+
+		if err := checkUint(u, size, t.String()); err != nil {
+			return err
 		}
 
+		v.SetUint(u)
+
 		return nil
-	case reflect.Map:
-		length32, err := decodeConcrete[uint32](r)
-		if err != nil {
-			return fmt.Errorf("decoding map length: %w", err)
+	}
+}
+
+// maxPreallocBytes is the memory allocated up front for the elements of a decoded slice or map.
+// Larger slices and maps grow as their elements are decoded.
+const maxPreallocBytes = 64 << 10
+
+// preallocLen returns the number of elements of the given size to allocate up front for a slice or map of length elements.
+func preallocLen(length int, size uintptr) int {
+	if size == 0 {
+		return length
+	}
+
+	return min(length, max(maxPreallocBytes/int(min(size, maxPreallocBytes)), 1))
+}
+
+// PreallocLen returns the number of elements of type E to allocate up front for a decoded slice of length elements.
+// It is used by code generated by gocgen.
+func PreallocLen[E any](length int) int {
+	return preallocLen(length, reflect.TypeFor[E]().Size())
+}
+
+// PreallocMapLen returns the number of entries with keys of type K and values of type V to allocate up front
+// for a decoded map of length entries. It is used by code generated by gocgen.
+func PreallocMapLen[K comparable, V any](length int) int {
+	return preallocLen(length, reflect.TypeFor[K]().Size()+reflect.TypeFor[V]().Size())
+}
+
+// resetSlice sets the length of a non-nil slice to zero, keeping its capacity.
+func resetSlice(v reflect.Value) {
+	if !v.IsNil() {
+		v.SetLen(0)
+	}
+}
+
+// clearArray sets the elements of an array past the decoded length to their zero value.
+func clearArray(v reflect.Value, length int) {
+	if v.CanAddr() {
+		v.Slice(length, v.Len()).Clear()
+		return
+	}
+
+	for i := length; i < v.Len(); i++ {
+		v.Index(i).SetZero()
+	}
+}
+
+type fieldDecoder struct {
+	structField
+
+	decoder decodeFunc
+}
+
+func compileStructDecoder(t reflect.Type, enc intEncoding) decodeFunc {
+	st := cachedStruct(t)
+	if st.err != nil {
+		return func(*decodeState, reflect.Value) error {
+			return st.err
 		}
+	}
 
-		if length32 == 0 {
-			return nil
+	decoders := make([]fieldDecoder, len(st.fields))
+
+	for i, field := range st.fields {
+		decoders[i] = fieldDecoder{
+			structField: field,
+			decoder:     decoderFor(field.typ, field.intEncoding(enc)),
 		}
+	}
+
+	if st.numbered {
+		return compileNumberedStructDecoder(decoders)
+	}
+
+	return func(d *decodeState, v reflect.Value) error {
+		for i := 0; i < len(decoders); i++ {
+			field := decoders[i]
+			value, _ := fieldValue(v, field.index)
 
-		length := int(length32)
+			if field.embedded {
+				ok, err := d.readPresence()
+				if err != nil {
+					return fmt.Errorf("decoding embedded %s presence: %w", field.typ.String(), err)
+				}
 
-		v.Set(reflect.MakeMapWithSize(t, length))
+				if err := setEmbedded(value, ok); err != nil {
+					return err
+				}
 
-		for range length {
-			key := reflect.New(t.Key())
+				if !ok {
+					i += field.promoted
+				}
 
-			// As
-			if err := decodeValue(r, key); err != nil {
-				return fmt.Errorf("decoding map key: %w", err)
+				continue
 			}
 
-			value := reflect.New(t.Elem())
+			if field.omitEmpty {
+				ok, err := d.readPresence()
+				if err != nil {
+					return fmt.Errorf("decoding struct field %s presence: %w", field.name, err)
+				}
 
-			if err := decodeValue(r, value); err != nil {
-				return fmt.Errorf("decoding map value: %w", err)
+				if !ok {
+					value.SetZero()
+					continue
+				}
 			}
 
-			v.SetMapIndex(key.Elem(), value.Elem())
+			if err := field.decoder(d, value); err != nil {
+				return fmt.Errorf("decoding struct field %s of type %s: %w", field.name, field.typ.String(), err)
+			}
 		}
 
 		return nil
-	default:
-		return fmt.Errorf("decoding of type %s is not supported", t.String())
+	}
+}
+
+// compileNumberedStructDecoder decodes fields encoded by compileNumberedStructEncoder in any order.
+// Unknown fields are skipped, missing fields are set to their zero value.
+// Bytes left over in a known field after decoding its value are skipped as well.
+func compileNumberedStructDecoder(decoders []fieldDecoder) decodeFunc {
+	byNumber := make(map[uint64]*fieldDecoder, len(decoders))
+	for i := range decoders {
+		byNumber[decoders[i].number] = &decoders[i]
+	}
+
+	return func(d *decodeState, v reflect.Value) error {
+		for _, field := range decoders {
+			if value, ok := fieldValue(v, field.index); ok {
+				value.SetZero()
+			}
+		}
+
+		for {
+			number, err := binary.ReadUvarint(d)
+			if err != nil {
+				return fmt.Errorf("decoding field number: %w", err)
+			}
+
+			if number == 0 {
+				return nil
+			}
+
+			length, err := binary.ReadUvarint(d)
+			if err != nil {
+				return fmt.Errorf("decoding field %d length: %w", number, err)
+			}
+
+			if length > math.MaxInt32 {
+				return fmt.Errorf("decoding field %d: maximum length of %d exceeded", number, math.MaxInt32)
+			}
+
+			field, ok := byNumber[number]
+			if !ok {
+				if err := d.skip(int(length)); err != nil {
+					return fmt.Errorf("skipping unknown field %d: %w", number, err)
+				}
+
+				continue
+			}
+
+			end := d.offset + int(length)
+
+			value, err := settableField(v, field.index)
+			if err != nil {
+				return fmt.Errorf("decoding struct field %s: %w", field.name, err)
+			}
+
+			if err := field.decoder(d, value); err != nil {
+				return fmt.Errorf("decoding struct field %s of type %s: %w", field.name, field.typ.String(), err)
+			}
+
+			if d.offset > end {
+				return fmt.Errorf("decoding struct field %s: read %d bytes past its length of %d", field.name, d.offset-end, length)
+			}
+
+			if err := d.skip(end - d.offset); err != nil {
+				return fmt.Errorf("skipping remainder of struct field %s: %w", field.name, err)
+			}
+		}
 	}
 }
@@ -0,0 +1,512 @@
+package goc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Maximum number of payload bytes and value characters shown on a line of [Dump].
+const (
+	dumpHexBytes   = 16
+	dumpValueChars = 64
+)
+
+// Dump writes an annotated tree of payload, decoded as a value of type t, to w.
+// Each line shows the offset and bytes of a part of the payload, its path and type, and its length or decoded value:
+//
+//	000000  01                                                 .Bool bool = true
+//	000001  02 00 00 00                                        .Names []string len=2
+//	000005  01 00 00 00 61                                     .Names[0] string = "a"
+//
+// Paths start at the root value ".", struct fields are written as .Field, slice, array and map entries as [i],
+// map keys as [i].key. Types with custom or dedicated encodings are shown as a single value.
+//
+// If the payload does not decode as t, the line where decoding diverged is marked with "!!"
+// and the error is returned, wrapped with the path and offset. Trailing bytes are reported as [ErrTrailingBytes].
+// The same options as for decoding apply, the number of bytes is limited to the length of the payload.
+func Dump(w io.Writer, payload []byte, t reflect.Type, options ...Option) error {
+	cfg, err := newConfig(options)
+	if err != nil {
+		return err
+	}
+
+	if t == nil {
+		return ErrInvalidValue
+	}
+
+	p := &dumper{
+		w:             w,
+		payload:       payload,
+		limitsPayload: cfg.limitToPayload(payload),
+	}
+
+	p.d = newDecodeState(cfg.limit(bytes.NewReader(payload)))
+
+	if cfg.schemaHeader {
+		if err := readSchemaHeader(p.d, t, cfg.intEncoding); err != nil {
+			return p.fail(0, "schema header", nil, err)
+		}
+
+		p.line(0, "schema header", nil, "")
+	}
+
+	if err := p.root(t, cfg); err != nil {
+		return err
+	}
+
+	if p.d.offset < len(payload) {
+		start := p.d.offset
+		p.d.offset = len(payload)
+
+		return p.fail(start, "trailing", nil, fmt.Errorf("%w: %d bytes", ErrTrailingBytes, len(payload)-start))
+	}
+
+	return p.err
+}
+
+// dumper walks a payload for [Dump].
+type dumper struct {
+	w       io.Writer
+	payload []byte
+	d       *decodeState
+	// Set if the byte limit is the length of the payload, rather than a decode option.
+	limitsPayload bool
+	// First error writing to w.
+	err error
+}
+
+// line writes the bytes read since start, annotated with path, type t and desc.
+func (p *dumper) line(start int, path string, t reflect.Type, desc string) {
+	if p.err != nil {
+		return
+	}
+
+	b := p.payload[start:p.d.offset]
+
+	hexBytes := hex.EncodeToString(b[:min(len(b), dumpHexBytes)])
+	hexCol := make([]byte, 0, dumpHexBytes*3+2)
+
+	for i := 0; i < len(hexBytes); i += 2 {
+		if i > 0 {
+			hexCol = append(hexCol, ' ')
+		}
+
+		hexCol = append(hexCol, hexBytes[i:i+2]...)
+	}
+
+	if len(b) > dumpHexBytes {
+		hexCol = append(hexCol, " …"...)
+	}
+
+	var annotation strings.Builder
+
+	annotation.WriteString(path)
+
+	if t != nil {
+		annotation.WriteString(" " + t.String())
+	}
+
+	if desc != "" {
+		annotation.WriteString(" " + desc)
+	}
+
+	_, p.err = fmt.Fprintf(p.w, "%06x  %-*s  %s\n", start, dumpHexBytes*3+1, hexCol, annotation.String())
+}
+
+// fail marks the line where decoding diverged and returns err wrapped with its path and the offset of the line,
+// in hex like the offset column.
+func (p *dumper) fail(start int, path string, t reflect.Type, err error) error {
+	if p.limitsPayload {
+		err = payloadEndError(err)
+	}
+
+	p.line(start, path, t, "!! "+err.Error())
+
+	if p.err != nil {
+		return p.err
+	}
+
+	return fmt.Errorf("%s at offset 0x%06x: %w", path, start, err)
+}
+
+// root dumps a top-level value, which is encoded like [Encode] does.
+func (p *dumper) root(t reflect.Type, cfg config) error {
+	// Top-level pointers are dereferenced.
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	if hasDedicatedEncoding(t) {
+		return p.value(0, ".", t, cfg.intEncoding)
+	}
+
+	// Top-level custom encodings are not framed and read the rest of the payload.
+	ptr := reflect.New(t)
+
+	var err error
+
+	switch {
+	case ptr.Type().Implements(reflectDecodeReader):
+		err = decodeValueDecodeReader(p.d, ptr)
+	case ptr.Type().Implements(reflectDecoder):
+		err = decodeValueDecoder(p.d, ptr)
+	case ptr.Type().Implements(reflectBinaryUnmarshaller):
+		err = decodeValueBinaryUnmarshaler(p.d, ptr)
+	case t.Kind() == reflect.String:
+		// Top-level strings are not length-prefixed.
+		var s string
+
+		s, err = readRawString(p.d, cfg)
+		if err == nil {
+			ptr.Elem().SetString(s)
+		}
+	default:
+		return p.value(0, ".", t, cfg.intEncoding)
+	}
+
+	if err != nil {
+		return p.fail(0, ".", t, err)
+	}
+
+	p.line(0, ".", t, "= "+formatDumpValue(ptr.Elem()))
+
+	return nil
+}
+
+// value dumps a value of type t, the line of which starts at start.
+func (p *dumper) value(start int, path string, t reflect.Type, enc intEncoding) error {
+	if isDumpLeaf(t, enc) {
+		v := reflect.New(t).Elem()
+
+		if err := decoderFor(t, enc)(p.d, v); err != nil {
+			return p.fail(start, path, t, err)
+		}
+
+		desc := "= " + formatDumpValue(v)
+		if t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+			desc = "len=" + strconv.Itoa(v.Len()) + " " + desc
+		}
+
+		p.line(start, path, t, desc)
+
+		return nil
+	}
+
+	if isOptional(t) {
+		ok, err := p.d.readPresence()
+		if err != nil {
+			return p.fail(start, path, t, err)
+		}
+
+		if !ok {
+			p.line(start, path, t, "= unset")
+			return nil
+		}
+
+		return p.value(start, path, t.Field(0).Type, enc)
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		marker, id, err := p.d.readPointer()
+		if err != nil {
+			return p.fail(start, path, t, err)
+		}
+
+		switch marker {
+		case absent:
+			p.line(start, path, t, "= nil")
+			return nil
+		case reference:
+			p.line(start, path, t, fmt.Sprintf("= ref %d", id))
+			return nil
+		}
+
+		return p.value(start, path, t.Elem(), enc)
+	case reflect.Interface:
+		if u, ok := unionOf(t); ok {
+			return p.unionValue(start, path, t, u, enc)
+		}
+
+		b, err := p.d.read(4)
+		if err != nil {
+			return p.fail(start, path, t, fmt.Errorf("type identifier: %w", err))
+		}
+
+		id := decodeUint32(b)
+		if id == nilTypeID {
+			p.line(start, path, t, "= nil")
+			return nil
+		}
+
+		concrete, err := registeredType(id)
+		if err != nil {
+			return p.fail(start, path, t, err)
+		}
+
+		if !concrete.AssignableTo(t) {
+			return p.fail(start, path, t, fmt.Errorf("registered type %s does not implement it", concrete.String()))
+		}
+
+		p.line(start, path, t, fmt.Sprintf("type=%s id=%#x", concrete.String(), id))
+
+		return p.value(p.d.offset, path, concrete, enc)
+	case reflect.Struct:
+		return p.structValue(start, path, t, enc)
+	case reflect.Array, reflect.Slice:
+		length, err := p.d.readLen(enc)
+		if err != nil {
+			return p.fail(start, path, t, fmt.Errorf("length: %w", err))
+		}
+
+		if t.Kind() == reflect.Array && length > t.Len() {
+			return p.fail(start, path, t, fmt.Errorf("decoded length %d exceeds array length %d", length, t.Len()))
+		}
+
+		if err := p.d.checkLength(length); err != nil {
+			return p.fail(start, path, t, err)
+		}
+
+		p.line(start, path, t, "len="+strconv.Itoa(length))
+
+		for i := range length {
+			if err := p.value(p.d.offset, path+"["+strconv.Itoa(i)+"]", t.Elem(), enc); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	case reflect.Map:
+		length, err := p.d.readLen(enc)
+		if err != nil {
+			return p.fail(start, path, t, fmt.Errorf("length: %w", err))
+		}
+
+		if err := p.d.checkLength(length); err != nil {
+			return p.fail(start, path, t, err)
+		}
+
+		p.line(start, path, t, "len="+strconv.Itoa(length))
+
+		for i := range length {
+			entry := path + "[" + strconv.Itoa(i) + "]"
+
+			if err := p.value(p.d.offset, entry+".key", t.Key(), enc); err != nil {
+				return err
+			}
+
+			if err := p.value(p.d.offset, entry, t.Elem(), enc); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	default:
+		return p.fail(start, path, t, fmt.Errorf("decoding of type %s is not supported", t.String()))
+	}
+}
+
+func (p *dumper) structValue(start int, path string, t reflect.Type, enc intEncoding) error {
+	st := cachedStruct(t)
+	if st.err != nil {
+		return p.fail(start, path, t, st.err)
+	}
+
+	p.line(start, path, t, "")
+
+	if st.numbered {
+		return p.numberedStruct(path, st, enc)
+	}
+
+	for i := 0; i < len(st.fields); i++ {
+		field := st.fields[i]
+		fieldPath := joinDumpPath(path, field.name)
+		fieldStart := p.d.offset
+
+		if field.embedded {
+			ok, err := p.d.readPresence()
+			if err != nil {
+				return p.fail(fieldStart, fieldPath, field.typ, fmt.Errorf("presence: %w", err))
+			}
+
+			if !ok {
+				p.line(fieldStart, fieldPath, field.typ, "= nil")
+				i += field.promoted
+
+				continue
+			}
+
+			p.line(fieldStart, fieldPath, field.typ, "embedded")
+
+			continue
+		}
+
+		if field.omitEmpty {
+			ok, err := p.d.readPresence()
+			if err != nil {
+				return p.fail(fieldStart, fieldPath, field.typ, fmt.Errorf("presence: %w", err))
+			}
+
+			if !ok {
+				p.line(fieldStart, fieldPath, field.typ, "omitted")
+				continue
+			}
+		}
+
+		if err := p.value(fieldStart, fieldPath, field.typ, field.intEncoding(enc)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// numberedStruct dumps the fields of a struct with numbered fields, see [cachedStruct].
+func (p *dumper) numberedStruct(path string, st structType, enc intEncoding) error {
+	byNumber := make(map[uint64]structField, len(st.fields))
+	for _, field := range st.fields {
+		byNumber[field.number] = field
+	}
+
+	for {
+		start := p.d.offset
+
+		number, err := binary.ReadUvarint(p.d)
+		if err != nil {
+			return p.fail(start, path, nil, fmt.Errorf("field number: %w", err))
+		}
+
+		if number == 0 {
+			p.line(start, path, nil, "end of fields")
+			return nil
+		}
+
+		length, err := binary.ReadUvarint(p.d)
+		if err != nil {
+			return p.fail(start, path, nil, fmt.Errorf("field %d length: %w", number, err))
+		}
+
+		if length > math.MaxInt32 {
+			return p.fail(start, path, nil, fmt.Errorf("field %d: maximum length of %d exceeded", number, math.MaxInt32))
+		}
+
+		field, ok := byNumber[number]
+		if !ok {
+			fieldPath := path + ".#" + strconv.FormatUint(number, 10)
+
+			if err := p.d.skip(int(length)); err != nil {
+				return p.fail(start, fieldPath, nil, fmt.Errorf("skipping unknown field: %w", err))
+			}
+
+			p.line(start, fieldPath, nil, "unknown field, skipped len="+strconv.FormatUint(length, 10))
+
+			continue
+		}
+
+		fieldPath := joinDumpPath(path, field.name)
+		end := p.d.offset + int(length)
+
+		p.line(start, fieldPath, nil, fmt.Sprintf("field %d len=%d", number, length))
+
+		if err := p.value(p.d.offset, fieldPath, field.typ, field.intEncoding(enc)); err != nil {
+			return err
+		}
+
+		if p.d.offset > end {
+			return p.fail(end, fieldPath, nil, fmt.Errorf("read %d bytes past its length of %d", p.d.offset-end, length))
+		}
+
+		if p.d.offset < end {
+			remainder := p.d.offset
+
+			if err := p.d.skip(end - p.d.offset); err != nil {
+				return p.fail(remainder, fieldPath, nil, fmt.Errorf("skipping remainder: %w", err))
+			}
+
+			p.line(remainder, fieldPath, nil, "skipped remainder")
+		}
+	}
+}
+
+// unionValue dumps a value of union type t.
+func (p *dumper) unionValue(start int, path string, t reflect.Type, u *union, enc intEncoding) error {
+	index, err := binary.ReadUvarint(p.d)
+	if err != nil {
+		return p.fail(start, path, t, fmt.Errorf("variant index: %w", err))
+	}
+
+	if index == 0 {
+		p.line(start, path, t, "= nil")
+		return nil
+	}
+
+	variant, err := u.variant(index)
+	if err != nil {
+		return p.fail(start, path, t, err)
+	}
+
+	p.line(start, path, t, fmt.Sprintf("variant=%d type=%s", index, variant.String()))
+
+	return p.value(p.d.offset, path, variant, enc)
+}
+
+// isDumpLeaf reports whether values of type t are shown as a single value by [Dump].
+func isDumpLeaf(t reflect.Type, enc intEncoding) bool {
+	if _, ok := stdlibCodecs[t]; ok || customEncodingOf(t) != customNone {
+		return true
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer, reflect.Interface, reflect.Struct, reflect.Map:
+		return false
+	case reflect.Array, reflect.Slice:
+		// Blocks of fixed-size numbers are not split up.
+		_, ok := bulkElemSize(t.Elem(), enc)
+		return ok
+	default:
+		return true
+	}
+}
+
+func joinDumpPath(path, name string) string {
+	if path == "." {
+		return "." + name
+	}
+
+	return path + "." + name
+}
+
+// formatDumpValue formats a decoded value, shortening long values.
+func formatDumpValue(v reflect.Value) string {
+	var s string
+
+	switch {
+	case v.Kind() == reflect.String:
+		s = strconv.Quote(v.String())
+	case (v.Kind() == reflect.Slice || v.Kind() == reflect.Array) && v.Type().Elem().Kind() == reflect.Uint8:
+		s = fmt.Sprintf("%#x", v.Interface())
+	default:
+		// Values with custom encodings are formatted through their pointer, so methods with pointer receivers apply.
+		if v.CanAddr() {
+			if stringer, ok := v.Addr().Interface().(fmt.Stringer); ok {
+				s = stringer.String()
+				break
+			}
+		}
+
+		s = fmt.Sprintf("%v", v.Interface())
+	}
+
+	if len(s) > dumpValueChars {
+		s = strings.ToValidUTF8(s[:dumpValueChars], "") + "…"
+	}
+
+	return s
+}
@@ -2,53 +2,43 @@ package goc
 
 import (
 	"encoding/binary"
-	"io"
 	"math"
-	"reflect"
 )
 
-func encodeConcrete[T any](w io.Writer, v T) error {
-	// TODO: use sync.Pool
-	d := make([]byte, 0, reflect.TypeFor[T]().Size())
-
+// appendConcrete appends a fixed-size scalar without reflection.
+// It returns false for named scalar types, which are encoded through reflection.
+func appendConcrete[T any](b []byte, v T) ([]byte, bool) {
 	// TODO: add int, uint, uintptr?
 	switch t := any(v).(type) {
 	case bool:
-		d = append(d, encodeBool(t))
+		return append(b, encodeBool(t)), true
 	case int8:
-		d = append(d, byte(t))
+		return append(b, byte(t)), true
 	case int16:
-		d = append(d, encodeInt16(t)...)
+		return binary.LittleEndian.AppendUint16(b, uint16(t)), true
 	case int32:
-		d = append(d, encodeInt32(t)...)
+		return binary.LittleEndian.AppendUint32(b, uint32(t)), true
 	case int64:
-		d = append(d, encodeInt64(t)...)
+		return binary.LittleEndian.AppendUint64(b, uint64(t)), true
 	case uint8:
-		d = append(d, t)
+		return append(b, t), true
 	case uint16:
-		d = append(d, encodeUint16(t)...)
+		return binary.LittleEndian.AppendUint16(b, t), true
 	case uint32:
-		d = append(d, encodeUint32(t)...)
+		return binary.LittleEndian.AppendUint32(b, t), true
 	case uint64:
-		d = append(d, encodeUint64(t)...)
+		return binary.LittleEndian.AppendUint64(b, t), true
 	case float32:
-		d = append(d, encodeFloat32(t)...)
+		return binary.LittleEndian.AppendUint32(b, math.Float32bits(t)), true
 	case float64:
-		d = append(d, encodeFloat64(t)...)
+		return binary.LittleEndian.AppendUint64(b, math.Float64bits(t)), true
 	case complex64:
-		d = append(d, encodeComplex64(t)...)
+		return appendComplex64(b, t), true
 	case complex128:
-		d = append(d, encodeComplex128(t)...)
+		return appendComplex128(b, t), true
 	default:
-		panic("encodeConcrete received a non-concrete type: " + reflect.TypeFor[T]().String())
-	}
-
-	_, err := w.Write(d)
-	if err != nil {
-		return err
+		return b, false
 	}
-
-	return nil
 }
 
 func encodeBool(b bool) byte {
@@ -59,60 +49,12 @@ func encodeBool(b bool) byte {
 	}
 }
 
-func encodeInt16(v int16) []byte {
-	return encodeUint16(uint16(v))
-}
-
-func encodeUint16(v uint16) []byte {
-	var b [2]byte
-	binary.LittleEndian.PutUint16(b[:], v)
-	return b[:]
-}
-
-func encodeInt32(v int32) []byte {
-	return encodeUint32(uint32(v))
-}
-
-func encodeUint32(v uint32) []byte {
-	var b [4]byte
-	binary.LittleEndian.PutUint32(b[:], v)
-	return b[:]
-}
-
-func encodeInt64(v int64) []byte {
-	return encodeUint64(uint64(v))
+func appendComplex64(b []byte, v complex64) []byte {
+	b = binary.LittleEndian.AppendUint32(b, math.Float32bits(real(v)))
+	return binary.LittleEndian.AppendUint32(b, math.Float32bits(imag(v)))
 }
 
-func encodeUint64(v uint64) []byte {
-	var b [8]byte
-	binary.LittleEndian.PutUint64(b[:], v)
-	return b[:]
-}
-
-func encodeFloat32(v float32) []byte {
-	return encodeUint32(math.Float32bits(v))
-}
-
-func encodeFloat64(v float64) []byte {
-	return encodeUint64(math.Float64bits(v))
-}
-
-func encodeComplex64(v complex64) []byte {
-	// TODO: use sync.Pool
-	d := make([]byte, 0, 8)
-
-	d = append(d, encodeFloat32(real(v))...)
-	d = append(d, encodeFloat32(imag(v))...)
-
-	return d
-}
-
-func encodeComplex128(v complex128) []byte {
-	// TODO: use sync.Pool
-	d := make([]byte, 0, 8)
-
-	d = append(d, encodeFloat64(real(v))...)
-	d = append(d, encodeFloat64(imag(v))...)
-
-	return d
+func appendComplex128(b []byte, v complex128) []byte {
+	b = binary.LittleEndian.AppendUint64(b, math.Float64bits(real(v)))
+	return binary.LittleEndian.AppendUint64(b, math.Float64bits(imag(v)))
 }
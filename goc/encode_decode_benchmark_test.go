@@ -195,6 +195,47 @@ func BenchmarkGocEncode(b *testing.B) {
 	}
 }
 
+func BenchmarkGocAppend(b *testing.B) {
+	object = newObject()
+
+	b.Run("Encode", func(b *testing.B) {
+		b.ReportAllocs()
+
+		for b.Loop() {
+			if _, err := goc.Encode(object); err != nil {
+				b.Fatal("Encode error: " + err.Error())
+			}
+		}
+	})
+	b.Run("EncodeTo", func(b *testing.B) {
+		b.ReportAllocs()
+
+		buf := new(bytes.Buffer)
+
+		for b.Loop() {
+			buf.Reset()
+
+			if err := goc.EncodeTo(buf, object); err != nil {
+				b.Fatal("EncodeTo error: " + err.Error())
+			}
+		}
+	})
+	b.Run("Append", func(b *testing.B) {
+		b.ReportAllocs()
+
+		var (
+			buf []byte
+			err error
+		)
+
+		for b.Loop() {
+			if buf, err = goc.Append(buf[:0], object); err != nil {
+				b.Fatal("Append error: " + err.Error())
+			}
+		}
+	})
+}
+
 func BenchmarkGocDecode(b *testing.B) {
 	b.Helper()
 
@@ -222,6 +263,188 @@ func BenchmarkGocDecode(b *testing.B) {
 	}
 }
 
+// BenchmarkGocDecodeInto compares decoding into a reused value with allocating a new value for every decode.
+func BenchmarkGocDecodeInto(b *testing.B) {
+	data, err := goc.Encode(newObject())
+	if err != nil {
+		b.Fatal("Encode error: " + err.Error())
+	}
+
+	b.Run("DecodeFrom", func(b *testing.B) {
+		b.ReportAllocs()
+		b.SetBytes(int64(len(data)))
+
+		for b.Loop() {
+			o, err := goc.DecodeFrom[Object](bytes.NewReader(data))
+			if err != nil {
+				b.Fatal("DecodeFrom error: " + err.Error())
+			}
+
+			object = o
+		}
+	})
+	b.Run("DecodeInto", func(b *testing.B) {
+		b.ReportAllocs()
+		b.SetBytes(int64(len(data)))
+
+		r := bytes.NewReader(data)
+
+		for b.Loop() {
+			r.Reset(data)
+
+			if err := goc.DecodeInto(r, &object); err != nil {
+				b.Fatal("DecodeInto error: " + err.Error())
+			}
+		}
+	})
+	b.Run("float64/Decode", func(b *testing.B) {
+		benchmarkDecodeInto(b, false, newBlobs().bulkFloats)
+	})
+	b.Run("float64/DecodeBytesInto", func(b *testing.B) {
+		benchmarkDecodeInto(b, true, newBlobs().bulkFloats)
+	})
+}
+
+func benchmarkDecodeInto[T any](b *testing.B, reuse bool, val T) {
+	data, err := goc.Encode(val)
+	if err != nil {
+		b.Fatal("Encode error: " + err.Error())
+	}
+
+	b.ReportAllocs()
+	b.SetBytes(int64(len(data)))
+
+	var decoded T
+
+	for b.Loop() {
+		if !reuse {
+			if decoded, err = goc.Decode[T](data); err != nil {
+				b.Fatal("Decode error: " + err.Error())
+			}
+
+			continue
+		}
+
+		if err := goc.DecodeBytesInto(data, &decoded); err != nil {
+			b.Fatal("DecodeBytesInto error: " + err.Error())
+		}
+	}
+}
+
+// Slices of single-field structs encode to the same bytes as slices of their field type,
+// but are encoded element by element, so they measure the gain of bulk encoding.
+type (
+	element[T any] struct{ V T }
+	blobs          struct {
+		bulkBytes    []byte
+		elemBytes    []element[byte]
+		bulkFloats   []float64
+		elemFloats   []element[float64]
+		bulkComplex  [256]complex128
+		elemComplex  [256]element[complex128]
+		bulkUint16s  []uint16
+		elemUint16s  []element[uint16]
+		bulkIntArray [1024]int32
+		elemIntArray [1024]element[int32]
+	}
+)
+
+func newBlobs() *blobs {
+	blobs := &blobs{
+		bulkBytes:   make([]byte, 4096),
+		elemBytes:   make([]element[byte], 4096),
+		bulkFloats:  make([]float64, 4096),
+		elemFloats:  make([]element[float64], 4096),
+		bulkUint16s: make([]uint16, 4096),
+		elemUint16s: make([]element[uint16], 4096),
+	}
+
+	_, _ = cryptorand.Read(blobs.bulkBytes)
+
+	for i := range 4096 {
+		blobs.elemBytes[i].V = blobs.bulkBytes[i]
+		blobs.bulkFloats[i] = mathrand.Float64()
+		blobs.elemFloats[i].V = blobs.bulkFloats[i]
+		blobs.bulkUint16s[i] = uint16(mathrand.Uint32())
+		blobs.elemUint16s[i].V = blobs.bulkUint16s[i]
+	}
+
+	for i := range blobs.bulkComplex {
+		blobs.bulkComplex[i] = complex(mathrand.Float64(), mathrand.Float64())
+		blobs.elemComplex[i].V = blobs.bulkComplex[i]
+	}
+
+	for i := range blobs.bulkIntArray {
+		blobs.bulkIntArray[i] = mathrand.Int32()
+		blobs.elemIntArray[i].V = blobs.bulkIntArray[i]
+	}
+
+	return blobs
+}
+
+func BenchmarkGocEncodeBulk(b *testing.B) {
+	blobs := newBlobs()
+
+	benchmarkEncode(b, "bytes/bulk", blobs.bulkBytes)
+	benchmarkEncode(b, "bytes/element", blobs.elemBytes)
+	benchmarkEncode(b, "float64/bulk", blobs.bulkFloats)
+	benchmarkEncode(b, "float64/element", blobs.elemFloats)
+	benchmarkEncode(b, "uint16/bulk", blobs.bulkUint16s)
+	benchmarkEncode(b, "uint16/element", blobs.elemUint16s)
+	benchmarkEncode(b, "complex128-array/bulk", &blobs.bulkComplex)
+	benchmarkEncode(b, "complex128-array/element", &blobs.elemComplex)
+	benchmarkEncode(b, "int32-array/bulk", &blobs.bulkIntArray)
+	benchmarkEncode(b, "int32-array/element", &blobs.elemIntArray)
+}
+
+func BenchmarkGocDecodeBulk(b *testing.B) {
+	blobs := newBlobs()
+
+	benchmarkDecode(b, "bytes/bulk", blobs.bulkBytes)
+	benchmarkDecode(b, "bytes/element", blobs.elemBytes)
+	benchmarkDecode(b, "float64/bulk", blobs.bulkFloats)
+	benchmarkDecode(b, "float64/element", blobs.elemFloats)
+	benchmarkDecode(b, "uint16/bulk", blobs.bulkUint16s)
+	benchmarkDecode(b, "uint16/element", blobs.elemUint16s)
+	benchmarkDecode(b, "complex128-array/bulk", blobs.bulkComplex)
+	benchmarkDecode(b, "complex128-array/element", blobs.elemComplex)
+	benchmarkDecode(b, "int32-array/bulk", blobs.bulkIntArray)
+	benchmarkDecode(b, "int32-array/element", blobs.elemIntArray)
+}
+
+func benchmarkEncode[T any](b *testing.B, name string, val T) {
+	b.Run(name, func(b *testing.B) {
+		buf := new(bytes.Buffer)
+
+		for b.Loop() {
+			buf.Reset()
+
+			if err := goc.EncodeTo(buf, val); err != nil {
+				b.Fatal("EncodeTo error: " + err.Error())
+			}
+		}
+
+		b.SetBytes(int64(buf.Len()))
+	})
+}
+
+func benchmarkDecode[T any](b *testing.B, name string, val T) {
+	b.Run(name, func(b *testing.B) {
+		data, err := goc.Encode(val)
+		if err != nil {
+			b.Fatal("Encode error: " + err.Error())
+		}
+
+		b.SetBytes(int64(len(data)))
+
+		for b.Loop() {
+			if _, err := goc.Decode[T](data); err != nil {
+				b.Fatal("Decode error: " + err.Error())
+			}
+		}
+	})
+}
+
 func newObject() Object {
 	return Object{
 		Num: mathrand.Uint64(),
@@ -1,8 +1,8 @@
 package goc
 
 import (
-	"bytes"
 	"encoding"
+	"encoding/binary"
 	"fmt"
 	"io"
 	"math"
@@ -17,73 +17,154 @@ type Encoder interface {
 	Encode() ([]byte, error)
 }
 
-func Encode[T any](val T) ([]byte, error) {
-	buf := new(bytes.Buffer)
+func Encode[T any](val T, options ...Option) ([]byte, error) {
+	cfg, err := newConfig(options)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf []byte
 
-	if err := EncodeTo(buf, val); err != nil {
+	// Allocate the exact size up front. Top-level values with an encoding method that cannot report their size
+	// are not sized, as that would encode them twice.
+	// Values with references are sized by encoding them, so they are not sized either.
+	if boxed := any(val); !cfg.references && (isTopLevelInterface(reflect.TypeFor[T]()) || !hasEncodingMethod(boxed) || isSizer(boxed)) {
+		if size, err := sizeOf(val, cfg); err == nil && size > 0 {
+			buf = make([]byte, 0, size)
+		}
+	}
+
+	b, err := appendEncoded(buf, val, cfg)
+	if err != nil {
 		return nil, err
 	}
 
-	return buf.Bytes(), nil
+	return b, nil
+}
+
+func EncodeTo[T any](w io.Writer, val T, options ...Option) error {
+	buf := getBuffer()
+	defer putBuffer(buf)
+
+	b, err := Append((*buf)[:0], val, options...)
+	if err != nil {
+		return err
+	}
+
+	*buf = b
+
+	if _, err := w.Write(b); err != nil {
+		return fmt.Errorf("writing %T: %w", val, err)
+	}
+
+	return nil
 }
 
-func EncodeTo[T any](w io.Writer, val T) error {
+// Append appends the encoding of val to dst and returns the extended buffer, like [strconv.AppendInt].
+// If an error is returned, dst is returned unchanged.
+func Append[T any](dst []byte, val T, options ...Option) ([]byte, error) {
+	cfg, err := newConfig(options)
+	if err != nil {
+		return dst, err
+	}
+
+	b, err := appendEncoded(dst, val, cfg)
+	if err != nil {
+		return dst, err
+	}
+
+	return b, nil
+}
+
+func appendEncoded[T any](b []byte, val T, cfg config) ([]byte, error) {
+	// Values of interface types other than any are encoded like nested ones, with their type identifier
+	// or union variant index, so they can be decoded as the same interface type.
+	if t := reflect.TypeFor[T](); isTopLevelInterface(t) {
+		if cfg.schemaHeader {
+			b = appendSchemaHeader(b, t, cfg.intEncoding)
+		}
+
+		return appendValue(b, reflect.ValueOf(&val).Elem(), cfg)
+	}
+
+	// Box val once, every conversion to an interface may allocate.
+	boxed := any(val)
+
+	if cfg.schemaHeader {
+		t := reflect.TypeOf(boxed)
+		if t == nil {
+			return b, ErrInvalidValue
+		}
+
+		b = appendSchemaHeader(b, t, cfg.intEncoding)
+	}
+
+	// Standard library types and types with a codec have dedicated encodings, even if they implement an encoding interface.
+	if hasDedicatedEncoding(reflect.TypeOf(boxed)) {
+		return appendValue(b, reflect.ValueOf(boxed), cfg)
+	}
+
 	// Try to encode through interface implementation.
-	switch encoder := any(val).(type) {
+	switch encoder := boxed.(type) {
 	case EncodeWriter:
-		if err := encoder.EncodeTo(w); err != nil {
-			return fmt.Errorf("EncodeWriter: %w", err)
+		e := getEncodeState(b, cfg)
+		defer putEncodeState(e)
+
+		if err := encoder.EncodeTo(e); err != nil {
+			return b, fmt.Errorf("EncodeWriter: %w", err)
 		}
 
-		return nil
+		return e.buf, nil
 	case Encoder:
 		encoded, err := encoder.Encode()
 		if err != nil {
-			return fmt.Errorf("Encoder: %w", err)
+			return b, fmt.Errorf("Encoder: %w", err)
 		}
 
-		_, err = w.Write(encoded)
-		if err != nil {
-			return fmt.Errorf("Encoder: write: %w", err)
-		}
+		return append(b, encoded...), nil
 	case encoding.BinaryMarshaler:
-		encoded, err := encoder.MarshalBinary()
-		if err != nil {
-			return fmt.Errorf("BinaryMarshaler: %w", err)
+		if appender, ok := encoder.(encoding.BinaryAppender); ok {
+			appended, err := appender.AppendBinary(b)
+			if err != nil {
+				return b, fmt.Errorf("BinaryAppender: %w", err)
+			}
+
+			return appended, nil
 		}
 
-		_, err = w.Write(encoded)
+		encoded, err := encoder.MarshalBinary()
 		if err != nil {
-			return fmt.Errorf("BinaryMarshaler: write: %w", err)
+			return b, fmt.Errorf("BinaryMarshaler: %w", err)
 		}
-	}
 
-	v := reflect.ValueOf(val)
+		return append(b, encoded...), nil
+	}
 
 	// Try to encode concrete type.
-	switch v.Kind() {
+	switch reflect.TypeFor[T]().Kind() {
+	case reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if cfg.intEncoding == intVarint {
+			return appendValue(b, reflect.ValueOf(boxed), cfg)
+		}
+
+		fallthrough
 	case reflect.Bool,
-		reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
-		reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Int8,
+		reflect.Uint8,
 		reflect.Float32, reflect.Float64,
 		reflect.Complex64, reflect.Complex128:
-		if err := encodeConcrete(w, val); err != nil {
-			return fmt.Errorf("encoding %s: %w", v.Type().String(), err)
+		if b, ok := appendConcrete(b, val); ok {
+			return b, nil
 		}
 
-		return nil
+		return appendValue(b, reflect.ValueOf(boxed), cfg)
 	case reflect.String:
-		// TODO: get rid of allocations
-		_, err := w.Write([]byte(reflect.ValueOf(val).String()))
-		if err != nil {
-			return fmt.Errorf("encoding string: %w", err)
-		}
-
-		return nil
+		return append(b, reflect.ValueOf(boxed).String()...), nil
 	}
 
 	// Encode through reflection.
-	return encodeValue(w, v)
+	return appendValue(b, reflect.ValueOf(boxed), cfg)
 }
 
 var (
@@ -92,7 +173,26 @@ var (
 	reflectBinaryMarshaller = reflect.TypeFor[encoding.BinaryMarshaler]()
 )
 
-func EncodeValue(w io.Writer, v reflect.Value) error {
+func EncodeValue(w io.Writer, v reflect.Value, options ...Option) error {
+	cfg, err := newConfig(options)
+	if err != nil {
+		return err
+	}
+
+	if !v.IsValid() {
+		return ErrInvalidValue
+	}
+
+	if cfg.schemaHeader {
+		if err := writeSchemaHeader(w, v.Type(), cfg.intEncoding); err != nil {
+			return err
+		}
+	}
+
+	if hasDedicatedEncoding(v.Type()) {
+		return encodeValue(w, v, cfg)
+	}
+
 	if v.Type().Implements(reflectEncodeWriter) {
 		encodeWriter, _ := reflect.TypeAssert[EncodeWriter](v)
 
@@ -113,6 +213,8 @@ func EncodeValue(w io.Writer, v reflect.Value) error {
 		if err != nil {
 			return fmt.Errorf("Encoder: write: %w", err)
 		}
+
+		return nil
 	} else if v.Type().Implements(reflectBinaryMarshaller) {
 		binaryMarshaler, _ := reflect.TypeAssert[encoding.BinaryMarshaler](v)
 
@@ -125,383 +227,420 @@ func EncodeValue(w io.Writer, v reflect.Value) error {
 		if err != nil {
 			return fmt.Errorf("BinaryMarshaler: write: %w", err)
 		}
+
+		return nil
 	}
 
-	return encodeValue(w, v)
+	return encodeValue(w, v, cfg)
 }
 
-func encodeValue(w io.Writer, v reflect.Value) error {
-	if !v.IsValid() {
-		return ErrInvalidValue
+func encodeValue(w io.Writer, v reflect.Value, cfg config) error {
+	buf := getBuffer()
+	defer putBuffer(buf)
+
+	b, err := appendValue((*buf)[:0], v, cfg)
+	if err != nil {
+		return err
 	}
 
-	indirections, err := numIndirections(v.Type())
+	*buf = b
+
+	_, err = w.Write(b)
 	if err != nil {
 		return err
 	}
 
-	for range indirections {
-		v = reflect.Indirect(v)
+	return nil
+}
+
+// appendValue appends the encoding of v through reflection.
+func appendValue(b []byte, v reflect.Value, cfg config) ([]byte, error) {
+	if !v.IsValid() {
+		return b, ErrInvalidValue
 	}
 
-	switch v.Kind() {
-	case reflect.Bool:
-		if err := encodeConcrete(w, v.Bool()); err != nil {
-			return fmt.Errorf("encoding bool: %w", err)
+	v, err := indirectValue(v, false)
+	if err != nil {
+		return b, err
+	}
+
+	e := getEncodeState(b, cfg)
+	defer putEncodeState(e)
+
+	e.trackRoot(v)
+
+	if err := encoderFor(v.Type(), cfg.intEncoding)(e, v); err != nil {
+		return b, err
+	}
+
+	return e.buf, nil
+}
+
+func compileEncoder(t reflect.Type, enc intEncoding) encodeFunc {
+	if f, ok := compileStdlibEncoder(t, enc); ok {
+		return f
+	}
+
+	if f, ok := compileCustomEncoder(t, enc); ok {
+		return f
+	}
+
+	if isOptional(t) {
+		return compileOptionalEncoder(t, enc)
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		if _, err := numIndirections(t); err != nil {
+			return func(*encodeState, reflect.Value) error {
+				return err
+			}
 		}
 
-		return nil
-	case reflect.Int:
-		// TODO: test
-		switch v.Type().Size() {
-		case 4:
-			if err := encodeConcrete(w, uint8(4)); err != nil {
-				return fmt.Errorf("encoding 32-bit int header: %w", err)
+		elemEncoder := encoderFor(t.Elem(), enc)
+
+		return func(e *encodeState, v reflect.Value) error {
+			if v.IsNil() {
+				e.buf = append(e.buf, absent)
+				return nil
 			}
 
-			if err := encodeConcrete(w, int32(v.Int())); err != nil {
-				return fmt.Errorf("encoding 32-bit int: %w", err)
+			if !e.appendPointer(v) {
+				return nil
 			}
 
+			return elemEncoder(e, v.Elem())
+		}
+	case reflect.Bool:
+		return func(e *encodeState, v reflect.Value) error {
+			e.buf = append(e.buf, encodeBool(v.Bool()))
+			return nil
+		}
+	case reflect.Int8:
+		return func(e *encodeState, v reflect.Value) error {
+			e.buf = append(e.buf, byte(v.Int()))
 			return nil
-		case 8:
-			if err := encodeConcrete(w, uint8(8)); err != nil {
-				return fmt.Errorf("encoding 64-bit int header: %w", err)
+		}
+	case reflect.Uint8:
+		return func(e *encodeState, v reflect.Value) error {
+			e.buf = append(e.buf, byte(v.Uint()))
+			return nil
+		}
+	case reflect.Int, reflect.Int16, reflect.Int32, reflect.Int64:
+		if enc == intVarint {
+			return func(e *encodeState, v reflect.Value) error {
+				e.buf = binary.AppendVarint(e.buf, v.Int())
+				return nil
 			}
+		}
 
-			if err := encodeConcrete(w, v.Int()); err != nil {
-				return fmt.Errorf("encoding 64-bit int: %w", err)
+		switch t.Kind() {
+		case reflect.Int:
+			return compileIntHeaderEncoder(t, t.Size())
+		case reflect.Int16:
+			return func(e *encodeState, v reflect.Value) error {
+				e.buf = binary.LittleEndian.AppendUint16(e.buf, uint16(v.Int()))
+				return nil
+			}
+		case reflect.Int32:
+			return func(e *encodeState, v reflect.Value) error {
+				e.buf = binary.LittleEndian.AppendUint32(e.buf, uint32(v.Int()))
+				return nil
 			}
+		default:
+			return func(e *encodeState, v reflect.Value) error {
+				e.buf = binary.LittleEndian.AppendUint64(e.buf, uint64(v.Int()))
+				return nil
+			}
+		}
+	case reflect.Uint, reflect.Uintptr, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if enc == intVarint {
+			return func(e *encodeState, v reflect.Value) error {
+				e.buf = binary.AppendUvarint(e.buf, v.Uint())
+				return nil
+			}
+		}
 
-			return nil
+		switch t.Kind() {
+		case reflect.Uint, reflect.Uintptr:
+			return compileIntHeaderEncoder(t, t.Size())
+		case reflect.Uint16:
+			return func(e *encodeState, v reflect.Value) error {
+				e.buf = binary.LittleEndian.AppendUint16(e.buf, uint16(v.Uint()))
+				return nil
+			}
+		case reflect.Uint32:
+			return func(e *encodeState, v reflect.Value) error {
+				e.buf = binary.LittleEndian.AppendUint32(e.buf, uint32(v.Uint()))
+				return nil
+			}
 		default:
-			return fmt.Errorf("unknown int size %d encountered", v.Type().Size())
+			return func(e *encodeState, v reflect.Value) error {
+				e.buf = binary.LittleEndian.AppendUint64(e.buf, v.Uint())
+				return nil
+			}
 		}
-	case reflect.Int8:
-		if err := encodeConcrete(w, int8(v.Int())); err != nil {
-			return fmt.Errorf("encoding int8: %w", err)
+	case reflect.Float32:
+		return func(e *encodeState, v reflect.Value) error {
+			e.buf = binary.LittleEndian.AppendUint32(e.buf, math.Float32bits(float32(v.Float())))
+			return nil
 		}
+	case reflect.Float64:
+		return func(e *encodeState, v reflect.Value) error {
+			e.buf = binary.LittleEndian.AppendUint64(e.buf, math.Float64bits(v.Float()))
+			return nil
+		}
+	case reflect.Complex64:
+		return func(e *encodeState, v reflect.Value) error {
+			c := complex64(v.Complex())
+			e.buf = binary.LittleEndian.AppendUint32(e.buf, math.Float32bits(real(c)))
+			e.buf = binary.LittleEndian.AppendUint32(e.buf, math.Float32bits(imag(c)))
 
-		return nil
-	case reflect.Int16:
-		if err := encodeConcrete(w, int16(v.Int())); err != nil {
-			return fmt.Errorf("encoding int16: %w", err)
+			return nil
 		}
+	case reflect.Complex128:
+		return func(e *encodeState, v reflect.Value) error {
+			c := v.Complex()
+			e.buf = binary.LittleEndian.AppendUint64(e.buf, math.Float64bits(real(c)))
+			e.buf = binary.LittleEndian.AppendUint64(e.buf, math.Float64bits(imag(c)))
 
-		return nil
-	case reflect.Int32:
-		if err := encodeConcrete(w, int32(v.Int())); err != nil {
-			return fmt.Errorf("encoding int32: %w", err)
+			return nil
 		}
+	case reflect.String:
+		return func(e *encodeState, v reflect.Value) error {
+			if err := e.appendLen(v.Len(), enc); err != nil {
+				return fmt.Errorf("encoding string len: %w", err)
+			}
 
-		return nil
-	case reflect.Int64:
-		if err := encodeConcrete(w, v.Int()); err != nil {
-			return fmt.Errorf("encoding int64: %w", err)
+			e.buf = append(e.buf, v.String()...)
+
+			return nil
+		}
+	case reflect.Struct:
+		return compileStructEncoder(t, enc)
+	case reflect.Array, reflect.Slice:
+		if f, ok := compileBulkEncoder(t, enc); ok {
+			return f
 		}
 
-		return nil
-	case reflect.Uint, reflect.Uintptr:
-		// TODO: decode
-		// TODO: test
-		switch v.Type().Size() {
-		case 4:
-			if err := encodeConcrete(w, uint8(4)); err != nil {
-				return fmt.Errorf("encoding 32-bit %s header: %w", v.Kind().String(), err)
+		elemEncoder := encoderFor(t.Elem(), enc)
+
+		return func(e *encodeState, v reflect.Value) error {
+			if err := e.appendLen(v.Len(), enc); err != nil {
+				return fmt.Errorf("encoding %s len: %w", v.Kind().String(), err)
 			}
 
-			if err := encodeConcrete(w, uint32(v.Uint())); err != nil {
-				return fmt.Errorf("encoding 32-bit %s: %w", v.Type().String(), err)
+			for i := range v.Len() {
+				if err := elemEncoder(e, v.Index(i)); err != nil {
+					return fmt.Errorf("encoding %s index %d of type %s: %w", v.Kind().String(), i, t.Elem().String(), err)
+				}
 			}
 
 			return nil
-		case 8:
-			if err := encodeConcrete(w, uint8(8)); err != nil {
-				return fmt.Errorf("encoding 64-bit %s header: %w", v.Kind().String(), err)
+		}
+	case reflect.Interface:
+		return func(e *encodeState, v reflect.Value) error {
+			if u, ok := unionOf(t); ok {
+				return u.encode(e, t, v, enc)
 			}
 
-			if err := encodeConcrete(w, v.Uint()); err != nil {
-				return fmt.Errorf("encoding 64-bit %s: %w", v.Type().String(), err)
+			if v.IsNil() {
+				e.buf = binary.LittleEndian.AppendUint32(e.buf, nilTypeID)
+				return nil
 			}
 
-			return nil
-		default:
-			return fmt.Errorf("unknown int size %d encountered", v.Type().Size())
-		}
-	case reflect.Uint8:
-		if err := encodeConcrete(w, uint8(v.Uint())); err != nil {
-			return fmt.Errorf("encoding uint8: %w", err)
-		}
+			concrete := v.Elem()
 
-		return nil
-	case reflect.Uint16:
-		if err := encodeConcrete(w, uint16(v.Uint())); err != nil {
-			return fmt.Errorf("encoding uint16: %w", err)
-		}
+			id, err := registeredID(concrete.Type())
+			if err != nil {
+				return fmt.Errorf("encoding %s: %w", t.String(), err)
+			}
 
-		return nil
-	case reflect.Uint32:
-		if err := encodeConcrete(w, uint32(v.Uint())); err != nil {
-			return fmt.Errorf("encoding uint32: %w", err)
-		}
+			e.buf = binary.LittleEndian.AppendUint32(e.buf, id)
 
-		return nil
-	case reflect.Uint64:
-		if err := encodeConcrete(w, v.Uint()); err != nil {
-			return fmt.Errorf("encoding uint64: %w", err)
+			return encoderFor(concrete.Type(), enc)(e, concrete)
 		}
+	case reflect.Map:
+		keyEncoder := encoderFor(t.Key(), enc)
+		valueEncoder := encoderFor(t.Elem(), enc)
 
-		return nil
-	case reflect.Float32:
-		if err := encodeConcrete(w, float32(v.Float())); err != nil {
-			return fmt.Errorf("encoding float32: %w", err)
-		}
+		return func(e *encodeState, v reflect.Value) error {
+			if err := e.appendLen(v.Len(), enc); err != nil {
+				return fmt.Errorf("encoding map len: %w", err)
+			}
 
-		return nil
-	case reflect.Float64:
-		if err := encodeConcrete(w, v.Float()); err != nil {
-			return fmt.Errorf("encoding float64: %w", err)
-		}
+			if v.Len() == 0 {
+				return nil
+			}
 
-		return nil
-	case reflect.Complex64:
-		if err := encodeConcrete(w, complex64(v.Complex())); err != nil {
-			return fmt.Errorf("encoding complex64: %w", err)
-		}
+			// Reuse key and value to avoid allocating on every iteration.
+			key := reflect.New(t.Key()).Elem()
+			value := reflect.New(t.Elem()).Elem()
+			iter := v.MapRange()
 
-		return nil
-	case reflect.Complex128:
-		if err := encodeConcrete(w, v.Complex()); err != nil {
-			return fmt.Errorf("encoding complex128: %w", err)
-		}
+			var entries []MapEntry
 
-		return nil
-	case reflect.String:
-		if v.Len() > math.MaxInt32 {
-			return fmt.Errorf("maximum string size of %d bytes exceeded", math.MaxInt32)
-		}
+			start := len(e.buf)
+			if e.canonical {
+				entries = make([]MapEntry, 0, v.Len())
+			}
 
-		if err := encodeConcrete(w, uint32(v.Len())); err != nil {
-			return fmt.Errorf("encoding string len: %w", err)
-		}
+			for iter.Next() {
+				entryStart := len(e.buf)
 
-		// _, err := w.Write(convert.StringToBytes(v.String()))
-		_, err := w.Write([]byte(v.String()))
-		if err != nil {
-			return fmt.Errorf("encoding string: %w", err)
-		}
+				key.SetIterKey(iter)
 
-		return nil
-	case reflect.Struct:
-		for i := range v.NumField() {
-			if err := encodeValue(w, v.Field(i)); err != nil {
-				return fmt.Errorf("encoding struct field %d of type %s: %w", i, v.Field(i).Type().String(), err)
+				if err := keyEncoder(e, key); err != nil {
+					return fmt.Errorf("encoding map key: %w", err)
+				}
+
+				keyEnd := len(e.buf)
+
+				value.SetIterValue(iter)
+
+				if err := valueEncoder(e, value); err != nil {
+					return fmt.Errorf("encoding map value: %w", err)
+				}
+
+				if e.canonical {
+					entries = append(entries, MapEntry{Start: entryStart, KeyEnd: keyEnd, End: len(e.buf)})
+				}
 			}
-		}
 
-		return nil
-	case reflect.Array, reflect.Slice:
-		if err := encodeConcrete(w, uint32(v.Len())); err != nil {
-			return fmt.Errorf("encoding slice len: %w", err)
-		}
+			if e.canonical {
+				e.buf = SortMapEntries(e.buf, start, entries)
+			}
 
-		if v.Len() == 0 {
 			return nil
 		}
+	default:
+		return func(*encodeState, reflect.Value) error {
+			return fmt.Errorf("encoding of type %s is not supported", t.String())
+		}
+	}
+}
 
-		elemType := v.Type().Elem()
+// compileIntHeaderEncoder encodes int, uint and uintptr with a 1-byte header containing their size in bytes.
+func compileIntHeaderEncoder(t reflect.Type, size uintptr) encodeFunc {
+	signed := t.Kind() == reflect.Int
 
-		// Calculate number of indirection for slice's underlying type.
-		indirections, err := numIndirections(elemType)
-		if err != nil {
-			return err
+	switch size {
+	case 4:
+		return func(e *encodeState, v reflect.Value) error {
+			e.buf = append(e.buf, 4)
+
+			if signed {
+				e.buf = binary.LittleEndian.AppendUint32(e.buf, uint32(v.Int()))
+			} else {
+				e.buf = binary.LittleEndian.AppendUint32(e.buf, uint32(v.Uint()))
+			}
+
+			return nil
 		}
+	case 8:
+		return func(e *encodeState, v reflect.Value) error {
+			e.buf = append(e.buf, 8)
 
-		// Indirect the underlying slice type.
-		for range indirections {
-			elemType = elemType.Elem()
-		}
-
-		// TODO: maybe re-enable if possible without unsafe use
-		// // Encode slice of fixed-size types.
-		// if v.Kind() == reflect.Slice {
-		// 	switch elemType.Kind() {
-		// 	case reflect.Bool:
-		// 		boolSlice, ok := reflect.TypeAssert[[]bool](v)
-		// 		if !ok {
-		// 			return ErrTypeAssertion
-		// 		}
-
-		// 		if err := encodeConcreteSlice(w, boolSlice); err != nil {
-		// 			return fmt.Errorf("encoding []%s: %w", elemType.String(), err)
-		// 		}
-
-		// 		return nil
-		// 	case reflect.Int8:
-		// 		int8Slice, ok := reflect.TypeAssert[[]int8](v)
-		// 		if !ok {
-		// 			return ErrTypeAssertion
-		// 		}
-
-		// 		if err := encodeConcreteSlice(w, int8Slice); err != nil {
-		// 			return fmt.Errorf("encoding []%s: %w", elemType.String(), err)
-		// 		}
-
-		// 		return nil
-		// 	case reflect.Int16:
-		// 		int16Slice, ok := reflect.TypeAssert[[]int16](v)
-		// 		if !ok {
-		// 			return ErrTypeAssertion
-		// 		}
-
-		// 		if err := encodeConcreteSlice(w, int16Slice); err != nil {
-		// 			return fmt.Errorf("encoding []%s: %w", elemType.String(), err)
-		// 		}
-
-		// 		return nil
-		// 	case reflect.Int32:
-		// 		int32Slice, ok := reflect.TypeAssert[[]int32](v)
-		// 		if !ok {
-		// 			return ErrTypeAssertion
-		// 		}
-
-		// 		if err := encodeConcreteSlice(w, int32Slice); err != nil {
-		// 			return fmt.Errorf("encoding []%s: %w", elemType.String(), err)
-		// 		}
-
-		// 		return nil
-		// 	case reflect.Int64:
-		// 		int64Slice, ok := reflect.TypeAssert[[]int64](v)
-		// 		if !ok {
-		// 			return ErrTypeAssertion
-		// 		}
-
-		// 		if err := encodeConcreteSlice(w, int64Slice); err != nil {
-		// 			return fmt.Errorf("encoding []%s: %w", elemType.String(), err)
-		// 		}
-
-		// 		return nil
-		// 	case reflect.Uint8:
-		// 		if _, err := w.Write(v.Bytes()); err != nil {
-		// 			return fmt.Errorf("encoding []%s: %w", elemType.String(), err)
-		// 		}
-
-		// 		return nil
-		// 	case reflect.Uint16:
-		// 		uint16Slice, ok := reflect.TypeAssert[[]uint16](v)
-		// 		if !ok {
-		// 			return ErrTypeAssertion
-		// 		}
-
-		// 		if err := encodeConcreteSlice(w, uint16Slice); err != nil {
-		// 			return fmt.Errorf("encoding []%s: %w", elemType.String(), err)
-		// 		}
-
-		// 		return nil
-		// 	case reflect.Uint32:
-		// 		uint32Slice, ok := reflect.TypeAssert[[]uint32](v)
-		// 		if !ok {
-		// 			return ErrTypeAssertion
-		// 		}
-
-		// 		if err := encodeConcreteSlice(w, uint32Slice); err != nil {
-		// 			return fmt.Errorf("encoding []%s: %w", elemType.String(), err)
-		// 		}
-
-		// 		return nil
-		// 	case reflect.Uint64:
-		// 		uint64Slice, ok := reflect.TypeAssert[[]uint64](v)
-		// 		if !ok {
-		// 			return ErrTypeAssertion
-		// 		}
-
-		// 		if err := encodeConcreteSlice(w, uint64Slice); err != nil {
-		// 			return fmt.Errorf("encoding []%s: %w", elemType.String(), err)
-		// 		}
-
-		// 		return nil
-		// 	case reflect.Float32:
-		// 		float32Slice, ok := reflect.TypeAssert[[]float32](v)
-		// 		if !ok {
-		// 			return ErrTypeAssertion
-		// 		}
-
-		// 		if err := encodeConcreteSlice(w, float32Slice); err != nil {
-		// 			return fmt.Errorf("encoding []%s: %w", elemType.String(), err)
-		// 		}
-
-		// 		return nil
-		// 	case reflect.Float64:
-		// 		float64Slice, ok := reflect.TypeAssert[[]float64](v)
-		// 		if !ok {
-		// 			return ErrTypeAssertion
-		// 		}
-
-		// 		if err := encodeConcreteSlice(w, float64Slice); err != nil {
-		// 			return fmt.Errorf("encoding []%s: %w", elemType.String(), err)
-		// 		}
-
-		// 		return nil
-		// 	case reflect.Complex64:
-		// 		complex64Slice, ok := reflect.TypeAssert[[]complex64](v)
-		// 		if !ok {
-		// 			return ErrTypeAssertion
-		// 		}
-
-		// 		if err := encodeConcreteSlice(w, complex64Slice); err != nil {
-		// 			return fmt.Errorf("encoding []%s: %w", elemType.String(), err)
-		// 		}
-
-		// 		return nil
-		// 	case reflect.Complex128:
-		// 		complex128Slice, ok := reflect.TypeAssert[[]complex128](v)
-		// 		if !ok {
-		// 			return ErrTypeAssertion
-		// 		}
-
-		// 		if err := encodeConcreteSlice(w, complex128Slice); err != nil {
-		// 			return fmt.Errorf("encoding []%s: %w", elemType.String(), err)
-		// 		}
-
-		// 		return nil
-		// 	}
-		// }
-
-		// Encode slice with underlying type of variable size.
-		for i := range v.Len() {
-			if err := encodeValue(w, v.Index(i)); err != nil {
-				return fmt.Errorf("encoding %s index %d of type %s: %w", v.Kind().String(), i, v.Index(i).Type().String(), err)
+			if signed {
+				e.buf = binary.LittleEndian.AppendUint64(e.buf, uint64(v.Int()))
+			} else {
+				e.buf = binary.LittleEndian.AppendUint64(e.buf, v.Uint())
 			}
+
+			return nil
 		}
+	default:
+		return func(*encodeState, reflect.Value) error {
+			return fmt.Errorf("unknown int size %d encountered", size)
+		}
+	}
+}
 
-		return nil
-	case reflect.Map:
-		if err := encodeConcrete(w, uint32(v.Len())); err != nil {
-			return fmt.Errorf("encoding map len: %w", err)
+type fieldEncoder struct {
+	structField
+
+	encoder encodeFunc
+}
+
+func compileStructEncoder(t reflect.Type, enc intEncoding) encodeFunc {
+	st := cachedStruct(t)
+	if st.err != nil {
+		return func(*encodeState, reflect.Value) error {
+			return st.err
 		}
+	}
 
-		if v.Len() == 0 {
-			return nil
+	encoders := make([]fieldEncoder, len(st.fields))
+
+	for i, field := range st.fields {
+		encoders[i] = fieldEncoder{
+			structField: field,
+			encoder:     encoderFor(field.typ, field.intEncoding(enc)),
 		}
+	}
+
+	if st.numbered {
+		return compileNumberedStructEncoder(encoders)
+	}
 
-		iter := v.MapRange()
+	return func(e *encodeState, v reflect.Value) error {
+		for i := 0; i < len(encoders); i++ {
+			field := encoders[i]
+			value, _ := fieldValue(v, field.index)
 
-		for iter.Next() {
-			key := iter.Key()
+			if field.embedded {
+				if value.IsNil() {
+					e.buf = append(e.buf, absent)
+					i += field.promoted
 
-			if err := encodeValue(w, key); err != nil {
-				return fmt.Errorf("encoding map key: %w", err)
+					continue
+				}
+
+				e.buf = append(e.buf, present)
+
+				continue
 			}
 
-			value := iter.Value()
+			if field.omitEmpty {
+				if isEmptyValue(value) {
+					e.buf = append(e.buf, absent)
+					continue
+				}
 
-			if err := encodeValue(w, value); err != nil {
-				return fmt.Errorf("encoding map value: %w", err)
+				e.buf = append(e.buf, present)
+			}
+
+			if err := field.encoder(e, value); err != nil {
+				return fmt.Errorf("encoding struct field %s of type %s: %w", field.name, field.typ.String(), err)
 			}
 		}
 
 		return nil
-	default:
-		return fmt.Errorf("encoding of type %s if not supported", v.Type().String())
+	}
+}
+
+// compileNumberedStructEncoder encodes each field as its number, the length of its value and the value,
+// followed by a terminating zero field number. Empty fields tagged with omitempty are left out.
+func compileNumberedStructEncoder(encoders []fieldEncoder) encodeFunc {
+	return func(e *encodeState, v reflect.Value) error {
+		for _, field := range encoders {
+			value, ok := fieldValue(v, field.index)
+			if !ok || field.omitEmpty && isEmptyValue(value) {
+				continue
+			}
+
+			e.buf = binary.AppendUvarint(e.buf, field.number)
+			start := len(e.buf)
+
+			if err := field.encoder(e, value); err != nil {
+				return fmt.Errorf("encoding struct field %s of type %s: %w", field.name, field.typ.String(), err)
+			}
+
+			e.insertLen(start)
+		}
+
+		e.buf = append(e.buf, 0)
+
+		return nil
 	}
 }
@@ -0,0 +1,411 @@
+package goc
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	isync "github.com/samborkent/gorpc/internal/sync"
+)
+
+const tagName = "goc"
+
+// fieldTag is the parsed goc struct tag of a field.
+//
+// The tag is a comma-separated list of options:
+//   - "-" skips the field,
+//   - "varint" and "fixed" select the integer encoding of the field, see [WithVarint],
+//   - "omitempty" encodes empty values as a single absent marker,
+//   - a positive number encodes the struct with numbered fields,
+//   - "name=" followed by a name renames the field.
+//
+// Unknown options, and both "varint" and "fixed", two different numbers or two different names, are an error.
+type fieldTag struct {
+	name string
+	// Set if the tag renames the field.
+	named     bool
+	skip      bool
+	enc       intEncoding
+	setEnc    bool
+	omitEmpty bool
+	number    uint64
+}
+
+func parseTag(field reflect.StructField) (fieldTag, error) {
+	tag := fieldTag{name: field.Name}
+
+	value, ok := field.Tag.Lookup(tagName)
+	if !ok {
+		return tag, nil
+	}
+
+	if value == "-" {
+		tag.skip = true
+		return tag, nil
+	}
+
+	for i, option := range strings.Split(value, ",") {
+		switch option {
+		case "varint", "fixed":
+			enc := intFixed
+			if option == "varint" {
+				enc = intVarint
+			}
+
+			if tag.setEnc && tag.enc != enc {
+				return tag, fmt.Errorf("field %s: conflicting options %q", field.Name, value)
+			}
+
+			tag.enc = enc
+			tag.setEnc = true
+		case "omitempty":
+			tag.omitEmpty = true
+		default:
+			if name, ok := strings.CutPrefix(option, "name="); ok && name != "" {
+				if tag.named && tag.name != name {
+					return tag, fmt.Errorf("field %s: conflicting options %q", field.Name, value)
+				}
+
+				tag.name = name
+				tag.named = true
+
+				continue
+			}
+
+			if option != "" && option[0] >= '0' && option[0] <= '9' {
+				number, err := strconv.ParseUint(option, 10, 32)
+				if err != nil || number == 0 {
+					return tag, fmt.Errorf("field %s: invalid field number %q", field.Name, option)
+				}
+
+				if tag.number != 0 && tag.number != number {
+					return tag, fmt.Errorf("field %s: conflicting options %q", field.Name, value)
+				}
+
+				tag.number = number
+
+				continue
+			}
+
+			// An empty first option is allowed, for tags such as ",omitempty".
+			if i > 0 || option != "" {
+				return tag, fmt.Errorf("field %s: unknown option %q", field.Name, option)
+			}
+		}
+	}
+
+	return tag, nil
+}
+
+// structField describes an encoded struct field.
+type structField struct {
+	fieldTag
+
+	// Index sequence of the field, longer than one for fields promoted from embedded structs.
+	index []int
+	typ   reflect.Type
+
+	// Set for embedded struct pointers of structs without numbered fields,
+	// which are encoded as a presence marker followed by the promoted fields.
+	embedded bool
+	// Number of following fields promoted through the embedded pointer.
+	promoted int
+}
+
+// intEncoding returns the integer encoding of the field within a value encoded with enc.
+// The encoding applies to all values nested inside the field.
+func (f structField) intEncoding(enc intEncoding) intEncoding {
+	if f.setEnc {
+		return f.enc
+	}
+
+	return enc
+}
+
+// structType describes the encoded fields of a struct type.
+type structType struct {
+	fields []structField
+	// Set if the fields are numbered, see [cachedStruct].
+	numbered bool
+	err      error
+}
+
+var structCache isync.Map[reflect.Type, structType]
+
+// cachedStruct returns the encoded fields of struct type t in declaration order.
+// Unexported fields and fields tagged with "-" are left out.
+//
+// The fields of embedded structs and struct pointers are promoted, as if they were declared in place of the embedded field,
+// following the visibility rules of Go: a field hides fields with the same name nested deeper,
+// and fields with the same name at the same depth are an error.
+// An embedded pointer is preceded by a presence marker, so it decodes as nil if it was nil.
+// Embedded fields with a name or number in their tag, and embedded types with a custom or standard library encoding,
+// are encoded as regular fields. Embedded interfaces are an error.
+//
+// If any field has a number, all fields must have a unique number, and the struct is encoded as a sequence of
+// uvarint field number, uvarint value length and value, terminated by field number 0.
+// Fields promoted through a nil embedded pointer are left out.
+// Decoders skip unknown fields and leave missing fields at their zero value,
+// so fields can be added, removed and reordered without breaking older peers.
+func cachedStruct(t reflect.Type) structType {
+	if st, ok := structCache.Load(t); ok {
+		return st
+	}
+
+	st := newStructType(t)
+	structCache.Store(t, st)
+
+	return st
+}
+
+func newStructType(t reflect.Type) structType {
+	// Find the depth of the shallowest field with each name, to hide deeper fields.
+	depths := make(map[string]int)
+
+	if err := walkFields(t, nil, []reflect.Type{t}, func(field structField, depth int) error {
+		if field.embedded {
+			return nil
+		}
+
+		if shallowest, ok := depths[field.name]; !ok || depth < shallowest {
+			depths[field.name] = depth
+		}
+
+		return nil
+	}); err != nil {
+		return structType{err: fmt.Errorf("struct %s: %w", t.String(), err)}
+	}
+
+	st := structType{fields: make([]structField, 0, t.NumField())}
+	seen := make(map[string]bool, len(depths))
+
+	err := walkFields(t, nil, []reflect.Type{t}, func(field structField, depth int) error {
+		if field.embedded {
+			st.fields = append(st.fields, field)
+			return nil
+		}
+
+		if depth > depths[field.name] {
+			return nil
+		}
+
+		if seen[field.name] {
+			return fmt.Errorf("field %s is ambiguous, it is promoted from multiple embedded structs", field.name)
+		}
+
+		seen[field.name] = true
+
+		if field.number != 0 {
+			st.numbered = true
+		}
+
+		st.fields = append(st.fields, field)
+
+		return nil
+	})
+	if err != nil {
+		return structType{err: fmt.Errorf("struct %s: %w", t.String(), err)}
+	}
+
+	countPromoted(st.fields)
+
+	if !st.numbered {
+		return st
+	}
+
+	// Numbered fields are left out if they are promoted through a nil pointer, so embedded pointers are not encoded.
+	st.fields = slices.DeleteFunc(st.fields, func(field structField) bool {
+		return field.embedded
+	})
+
+	numbers := make(map[uint64]string, len(st.fields))
+
+	for _, field := range st.fields {
+		if field.number == 0 {
+			return structType{err: fmt.Errorf("struct %s: field %s has no field number", t.String(), field.name)}
+		}
+
+		if other, ok := numbers[field.number]; ok {
+			return structType{err: fmt.Errorf("struct %s: fields %s and %s have the same field number %d", t.String(), other, field.name, field.number)}
+		}
+
+		numbers[field.number] = field.name
+	}
+
+	return st
+}
+
+// walkFields calls fn for the encoded fields of struct type t in declaration order, descending into embedded structs.
+// Embedded pointers are passed before the fields promoted through them, with their depth.
+// The index of the fields is prefixed with index, embedding holds t and the structs embedding it to detect cycles.
+func walkFields(t reflect.Type, index []int, embedding []reflect.Type, fn func(field structField, depth int) error) error {
+	depth := len(embedding) - 1
+
+	for i := range t.NumField() {
+		field := t.Field(i)
+
+		tag, err := parseTag(field)
+		if err != nil {
+			return err
+		}
+
+		if tag.skip {
+			continue
+		}
+
+		fieldIndex := append(slices.Clip(index), i)
+
+		if field.Anonymous && !tag.named && tag.number == 0 {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+
+			if embedded.Kind() == reflect.Interface {
+				return fmt.Errorf("embedded interface %s is not supported, give the field a name or tag it with \"-\"", field.Type.String())
+			}
+
+			if flattened(embedded) {
+				if tag.omitEmpty {
+					return fmt.Errorf("embedded field %s: omitempty requires a field name", field.Name)
+				}
+
+				if slices.Contains(embedding, embedded) {
+					return fmt.Errorf("embedded struct %s embeds itself", embedded.String())
+				}
+
+				if field.Type.Kind() == reflect.Pointer {
+					if err := fn(structField{fieldTag: tag, index: fieldIndex, typ: field.Type, embedded: true}, depth); err != nil {
+						return err
+					}
+				}
+
+				// The integer encoding of the embedded field applies to the promoted fields that do not set their own.
+				if err := walkFields(embedded, fieldIndex, append(embedding, embedded), func(promoted structField, depth int) error {
+					if tag.setEnc && !promoted.setEnc {
+						promoted.enc, promoted.setEnc = tag.enc, true
+					}
+
+					return fn(promoted, depth)
+				}); err != nil {
+					return err
+				}
+
+				continue
+			}
+		}
+
+		if !field.IsExported() {
+			continue
+		}
+
+		if err := fn(structField{fieldTag: tag, index: fieldIndex, typ: field.Type}, depth); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// flattened reports whether the fields of embedded type t are promoted, see [cachedStruct].
+func flattened(t reflect.Type) bool {
+	if _, ok := stdlibCodecs[t]; ok || isOptional(t) {
+		return false
+	}
+
+	return t.Kind() == reflect.Struct && customEncodingOf(t) == customNone
+}
+
+// countPromoted sets the number of fields promoted through each embedded pointer in fields.
+// The promoted fields follow the pointer, and are all fields whose index starts with the index of the pointer.
+func countPromoted(fields []structField) {
+	for i := range fields {
+		if !fields[i].embedded {
+			continue
+		}
+
+		for _, field := range fields[i+1:] {
+			if len(field.index) <= len(fields[i].index) || !slices.Equal(field.index[:len(fields[i].index)], fields[i].index) {
+				break
+			}
+
+			fields[i].promoted++
+		}
+	}
+}
+
+// fieldValue returns the field of struct v with the given index,
+// or false if the field is promoted through a nil embedded pointer.
+func fieldValue(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+
+			v = v.Elem()
+		}
+
+		v = v.Field(x)
+	}
+
+	return v, true
+}
+
+// settableField returns the field of struct v with the given index, allocating nil embedded pointers on the way.
+func settableField(v reflect.Value, index []int) (reflect.Value, error) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if err := setEmbedded(v, true); err != nil {
+				return reflect.Value{}, err
+			}
+
+			v = v.Elem()
+		}
+
+		v = v.Field(x)
+	}
+
+	return v, nil
+}
+
+// setEmbedded allocates the embedded pointer v if it is nil and present is set, or sets it to nil if present is not set.
+// Existing values are reused, see [DecodeInto].
+func setEmbedded(v reflect.Value, present bool) error {
+	if v.IsNil() == !present {
+		return nil
+	}
+
+	// Embedded pointers to unexported structs can be read, but not set.
+	if !v.CanSet() {
+		return fmt.Errorf("cannot set embedded pointer to unexported struct %s", v.Type().Elem().String())
+	}
+
+	if present {
+		v.Set(reflect.New(v.Type().Elem()))
+	} else {
+		v.SetZero()
+	}
+
+	return nil
+}
+
+// isEmptyValue reports whether v is empty for the omitempty option:
+// false, 0, a nil pointer or interface, an empty string, slice or map, or a zero array or struct.
+// Negative zero floats are not empty, so they survive a round trip.
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.String, reflect.Slice, reflect.Map:
+		return v.Len() == 0
+	case reflect.Float32, reflect.Float64:
+		return math.Float64bits(v.Float()) == 0
+	case reflect.Complex64, reflect.Complex128:
+		c := v.Complex()
+		return math.Float64bits(real(c)) == 0 && math.Float64bits(imag(c)) == 0
+	default:
+		return v.IsZero()
+	}
+}
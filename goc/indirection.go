@@ -38,3 +38,34 @@ func numIndirections(t reflect.Type) (int, error) {
 
 	return indirections, nil
 }
+
+// Presence markers precede every pointer nested inside a value.
+const (
+	absent  byte = 0
+	present byte = 1
+)
+
+// indirectValue dereferences the outermost pointers of a top-level value.
+// Only pointers nested inside a value are encoded with a presence marker,
+// so encoding a T and a *T produces the same bytes.
+// If allocate is set, nil pointers are allocated where possible.
+func indirectValue(v reflect.Value, allocate bool) (reflect.Value, error) {
+	indirections, err := numIndirections(v.Type())
+	if err != nil {
+		return v, err
+	}
+
+	for range indirections {
+		if v.IsNil() {
+			if !allocate || !v.CanSet() {
+				return v, ErrInvalidValue
+			}
+
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+
+		v = v.Elem()
+	}
+
+	return v, nil
+}
@@ -0,0 +1,77 @@
+package goc
+
+import (
+	"errors"
+	"fmt"
+	"math/bits"
+	"strconv"
+)
+
+var ErrOverflow = errors.New("integer overflow")
+
+// OverflowError is returned when a decoded integer does not fit the type it is decoded into,
+// such as an int written by a 64-bit peer that is too large for the int of a 32-bit peer.
+// It matches [ErrOverflow] with [errors.Is].
+type OverflowError struct {
+	// Decoded value in decimal.
+	Value string
+	// Type the value was decoded into.
+	Type string
+}
+
+func (e *OverflowError) Error() string {
+	return fmt.Sprintf("%s: value %s overflows %s", ErrOverflow.Error(), e.Value, e.Type)
+}
+
+func (e *OverflowError) Is(target error) bool {
+	return target == ErrOverflow
+}
+
+// checkInt returns an [*OverflowError] if i does not fit a signed integer type of the given size in bits.
+func checkInt(i int64, size int, typ string) error {
+	if size < 64 && (i < -1<<(size-1) || i > 1<<(size-1)-1) {
+		return &OverflowError{Value: strconv.FormatInt(i, 10), Type: typ}
+	}
+
+	return nil
+}
+
+// checkUint returns an [*OverflowError] if u does not fit an unsigned integer type of the given size in bits.
+func checkUint(u uint64, size int, typ string) error {
+	if size < 64 && u > 1<<size-1 {
+		return &OverflowError{Value: strconv.FormatUint(u, 10), Type: typ}
+	}
+
+	return nil
+}
+
+// ToInt converts a decoded integer to int, or returns an [*OverflowError] if it does not fit the int of this platform.
+// It is used by code generated by gocgen.
+func ToInt(i int64) (int, error) {
+	if err := checkInt(i, strconv.IntSize, "int"); err != nil {
+		return 0, err
+	}
+
+	return int(i), nil
+}
+
+// ToUint converts a decoded integer to uint, or returns an [*OverflowError] if it does not fit the uint of this platform.
+// It is used by code generated by gocgen.
+func ToUint(u uint64) (uint, error) {
+	if err := checkUint(u, bits.UintSize, "uint"); err != nil {
+		return 0, err
+	}
+
+	return uint(u), nil
+}
+
+// ToUintptr converts a decoded integer to uintptr,
+// or returns an [*OverflowError] if it does not fit the uintptr of this platform.
+// It is used by code generated by gocgen.
+func ToUintptr(u uint64) (uintptr, error) {
+	if err := checkUint(u, bits.UintSize, "uintptr"); err != nil {
+		return 0, err
+	}
+
+	return uintptr(u), nil
+}
@@ -0,0 +1,1433 @@
+package goc
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+var ErrInvalidJSON = errors.New("invalid JSON for goc type")
+
+var errJSONReferences = errors.New("JSON transcoding does not support references")
+
+// ToJSON transcodes a goc payload, encoded from a value of type t, to JSON.
+// The JSON follows the structure of t and keeps everything needed to restore the payload byte for byte with [FromJSON]:
+//   - Integers are JSON numbers of any size.
+//   - Floats are JSON numbers, or the strings "NaN", "+Inf" and "-Inf".
+//     NaNs other than [math.NaN] keep their bits, such as "NaN(0x7ff8000000000002)".
+//   - Complex numbers are arrays of their real and imaginary part.
+//   - Strings must be valid UTF-8. Byte slices and arrays are base64 strings.
+//   - Structs are objects keyed by field name, without omitted fields.
+//     Fields promoted from embedded structs are members of the struct, nil embedded pointers are null.
+//     Unknown numbered fields are kept as "#n" with their base64 encoded bytes.
+//   - Maps with string, integer, float or bool keys are objects, other maps are arrays of [key, value] pairs.
+//     Entries keep the order of the payload.
+//   - Nil pointers and interfaces are null, other interface values are objects holding the registered "type" name and the "value".
+//     Union values hold the type name of their variant, such as "*pkg.NotFound".
+//   - Unset [Optional] values are null, set values are their value.
+//   - time.Time is an RFC 3339 string followed by its location in brackets unless it is UTC,
+//     such as "2025-01-02T03:04:05+01:00[Europe/Amsterdam]".
+//   - Other standard library types with dedicated encodings are their text form, big.Int is a JSON number.
+//   - Values with custom encodings are base64 strings of their encoded bytes.
+//
+// Trailing bytes after the value are an error. The same options as for decoding apply, except [WithReferences].
+func ToJSON(payload []byte, t reflect.Type, options ...Option) ([]byte, error) {
+	cfg, err := newConfig(options)
+	if err != nil {
+		return nil, err
+	}
+
+	if t == nil {
+		return nil, ErrInvalidValue
+	}
+
+	if cfg.references {
+		return nil, errJSONReferences
+	}
+
+	limited := cfg.limitToPayload(payload)
+
+	j := &jsonWriter{
+		payload: payload,
+		d:       newDecodeState(cfg.limit(bytes.NewReader(payload))),
+	}
+
+	if err := j.root(t, cfg); err != nil {
+		if limited {
+			err = payloadEndError(err)
+		}
+
+		return nil, fmt.Errorf("transcoding %s to JSON at offset %d: %w", t.String(), j.d.offset, err)
+	}
+
+	if j.d.offset < len(payload) {
+		return nil, fmt.Errorf("transcoding %s to JSON: %w: %d bytes", t.String(), ErrTrailingBytes, len(payload)-j.d.offset)
+	}
+
+	return j.buf, nil
+}
+
+// FromJSON transcodes JSON in the form written by [ToJSON] to a goc payload of a value of type t.
+// Struct fields missing from the JSON are encoded as their zero value, or left out of structs with numbered fields.
+// Unknown fields are an error.
+// Numbers that do not fit their integer or float type are reported as [ErrInvalidJSON].
+// Platform-sized integers are encoded with the size of the platform, like [Encode] does.
+// The same options as for encoding apply, except that map entries keep the order of the JSON.
+func FromJSON(data []byte, t reflect.Type, options ...Option) ([]byte, error) {
+	cfg, err := newConfig(options)
+	if err != nil {
+		return nil, err
+	}
+
+	if t == nil {
+		return nil, ErrInvalidValue
+	}
+
+	if cfg.references {
+		return nil, errJSONReferences
+	}
+
+	if !json.Valid(data) {
+		return nil, fmt.Errorf("transcoding JSON to %s: %w: syntax error", t.String(), ErrInvalidJSON)
+	}
+
+	e := &encodeState{}
+
+	if cfg.schemaHeader {
+		e.buf = appendSchemaHeader(e.buf, t, cfg.intEncoding)
+	}
+
+	if err := jsonRoot(e, data, t, cfg.intEncoding); err != nil {
+		return nil, fmt.Errorf("transcoding JSON to %s: %w", t.String(), err)
+	}
+
+	return e.buf, nil
+}
+
+// hasTopLevelEncoding reports whether top-level values of type t are decoded through a decoding interface,
+// which reads the unframed rest of the payload.
+func hasTopLevelEncoding(t reflect.Type) bool {
+	if hasDedicatedEncoding(t) {
+		return false
+	}
+
+	ptr := reflect.PointerTo(t)
+
+	return ptr.Implements(reflectDecodeReader) || ptr.Implements(reflectDecoder) || ptr.Implements(reflectBinaryUnmarshaller)
+}
+
+// jsonWriter transcodes a payload to JSON for [ToJSON].
+type jsonWriter struct {
+	payload []byte
+	d       *decodeState
+	buf     []byte
+}
+
+func (j *jsonWriter) root(t reflect.Type, cfg config) error {
+	if cfg.schemaHeader {
+		if err := readSchemaHeader(j.d, t, cfg.intEncoding); err != nil {
+			return err
+		}
+	}
+
+	// Top-level pointers are dereferenced.
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch {
+	case hasTopLevelEncoding(t):
+		j.buf = appendJSONBytes(j.buf, j.payload[j.d.offset:])
+
+		return j.d.skip(len(j.payload) - j.d.offset)
+	case t.Kind() == reflect.String:
+		// Top-level strings are not length-prefixed.
+		s, err := readRawString(j.d, cfg)
+		if err != nil {
+			return err
+		}
+
+		return j.appendString(s)
+	default:
+		return j.value(t, cfg.intEncoding)
+	}
+}
+
+// decode decodes a value of type t with its regular decoder.
+func (j *jsonWriter) decode(t reflect.Type, enc intEncoding) (reflect.Value, error) {
+	v := reflect.New(t).Elem()
+
+	if err := decoderFor(t, enc)(j.d, v); err != nil {
+		return v, err
+	}
+
+	return v, nil
+}
+
+func (j *jsonWriter) appendString(s string) error {
+	if !utf8.ValidString(s) {
+		return ErrInvalidUTF8
+	}
+
+	j.buf = appendJSONString(j.buf, s)
+
+	return nil
+}
+
+func (j *jsonWriter) appendFloat(size int) error {
+	b, err := j.d.read(size)
+	if err != nil {
+		return err
+	}
+
+	if size == 4 {
+		j.buf = appendJSONFloat(j.buf, uint64(decodeUint32(b)), 32)
+	} else {
+		j.buf = appendJSONFloat(j.buf, decodeUint64(b), 64)
+	}
+
+	return nil
+}
+
+func (j *jsonWriter) value(t reflect.Type, enc intEncoding) error {
+	if _, ok := stdlibCodecs[t]; ok {
+		return j.stdlib(t, enc)
+	}
+
+	switch customEncodingOf(t) {
+	case customStream:
+		start := j.d.offset
+
+		if _, err := j.decode(t, enc); err != nil {
+			return err
+		}
+
+		j.buf = appendJSONBytes(j.buf, j.payload[start:j.d.offset])
+
+		return nil
+	case customBytes, customBinary, customCodec:
+		b, err := j.d.readFramed(enc)
+		if err != nil {
+			return fmt.Errorf("decoding %s: %w", t.String(), err)
+		}
+
+		j.buf = appendJSONBytes(j.buf, b)
+
+		return nil
+	}
+
+	if isOptional(t) {
+		ok, err := j.d.readPresence()
+		if err != nil {
+			return fmt.Errorf("decoding %s presence: %w", t.String(), err)
+		}
+
+		if !ok {
+			j.buf = append(j.buf, "null"...)
+			return nil
+		}
+
+		return j.value(t.Field(0).Type, enc)
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		v, err := j.decode(t, enc)
+		if err != nil {
+			return err
+		}
+
+		j.buf = strconv.AppendBool(j.buf, v.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v, err := j.decode(t, enc)
+		if err != nil {
+			return err
+		}
+
+		j.buf = strconv.AppendInt(j.buf, v.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		v, err := j.decode(t, enc)
+		if err != nil {
+			return err
+		}
+
+		j.buf = strconv.AppendUint(j.buf, v.Uint(), 10)
+	case reflect.Float32, reflect.Float64:
+		if err := j.appendFloat(int(t.Size())); err != nil {
+			return fmt.Errorf("reading %s: %w", t.String(), err)
+		}
+	case reflect.Complex64, reflect.Complex128:
+		j.buf = append(j.buf, '[')
+
+		if err := j.appendFloat(int(t.Size()) / 2); err != nil {
+			return fmt.Errorf("reading %s: %w", t.String(), err)
+		}
+
+		j.buf = append(j.buf, ',')
+
+		if err := j.appendFloat(int(t.Size()) / 2); err != nil {
+			return fmt.Errorf("reading %s: %w", t.String(), err)
+		}
+
+		j.buf = append(j.buf, ']')
+	case reflect.String:
+		v, err := j.decode(t, enc)
+		if err != nil {
+			return err
+		}
+
+		return j.appendString(v.String())
+	case reflect.Pointer:
+		ok, err := j.d.readPresence()
+		if err != nil {
+			return fmt.Errorf("decoding %s presence: %w", t.String(), err)
+		}
+
+		if !ok {
+			j.buf = append(j.buf, "null"...)
+			return nil
+		}
+
+		return j.value(t.Elem(), enc)
+	case reflect.Interface:
+		return j.interfaceValue(t, enc)
+	case reflect.Struct:
+		return j.structValue(t, enc)
+	case reflect.Array, reflect.Slice:
+		if isByteSequence(t) {
+			v, err := j.decode(t, enc)
+			if err != nil {
+				return err
+			}
+
+			j.buf = appendJSONBytes(j.buf, v.Bytes())
+
+			return nil
+		}
+
+		length, err := j.d.readLen(enc)
+		if err != nil {
+			return fmt.Errorf("decoding %s length: %w", t.String(), err)
+		}
+
+		if t.Kind() == reflect.Array && length > t.Len() {
+			return fmt.Errorf("decoded length %d exceeds array length %d", length, t.Len())
+		}
+
+		if err := j.d.checkLength(length); err != nil {
+			return fmt.Errorf("decoding %s: %w", t.String(), err)
+		}
+
+		j.buf = append(j.buf, '[')
+
+		for i := range length {
+			if i > 0 {
+				j.buf = append(j.buf, ',')
+			}
+
+			if err := j.value(t.Elem(), enc); err != nil {
+				return fmt.Errorf("index %d: %w", i, err)
+			}
+		}
+
+		j.buf = append(j.buf, ']')
+	case reflect.Map:
+		return j.mapValue(t, enc)
+	default:
+		return fmt.Errorf("decoding of type %s is not supported", t.String())
+	}
+
+	return nil
+}
+
+func (j *jsonWriter) interfaceValue(t reflect.Type, enc intEncoding) error {
+	if u, ok := unionOf(t); ok {
+		return j.unionValue(t, u, enc)
+	}
+
+	b, err := j.d.read(4)
+	if err != nil {
+		return fmt.Errorf("decoding %s type identifier: %w", t.String(), err)
+	}
+
+	id := decodeUint32(b)
+	if id == nilTypeID {
+		j.buf = append(j.buf, "null"...)
+		return nil
+	}
+
+	concrete, err := registeredType(id)
+	if err != nil {
+		return fmt.Errorf("decoding %s: %w", t.String(), err)
+	}
+
+	if !concrete.AssignableTo(t) {
+		return fmt.Errorf("decoding %s: registered type %s does not implement it", t.String(), concrete.String())
+	}
+
+	name, err := registeredName(id)
+	if err != nil {
+		return fmt.Errorf("decoding %s: %w", t.String(), err)
+	}
+
+	j.buf = append(j.buf, `{"type":`...)
+	j.buf = appendJSONString(j.buf, name)
+	j.buf = append(j.buf, `,"value":`...)
+
+	if err := j.value(concrete, enc); err != nil {
+		return fmt.Errorf("%s of type %s: %w", t.String(), concrete.String(), err)
+	}
+
+	j.buf = append(j.buf, '}')
+
+	return nil
+}
+
+// unionValue writes a value of union type t like an interface value, with the variant type as its type name.
+func (j *jsonWriter) unionValue(t reflect.Type, u *union, enc intEncoding) error {
+	index, err := binary.ReadUvarint(j.d)
+	if err != nil {
+		return fmt.Errorf("decoding %s variant index: %w", t.String(), err)
+	}
+
+	if index == 0 {
+		j.buf = append(j.buf, "null"...)
+		return nil
+	}
+
+	variant, err := u.variant(index)
+	if err != nil {
+		return fmt.Errorf("decoding %s: %w", t.String(), err)
+	}
+
+	j.buf = append(j.buf, `{"type":`...)
+	j.buf = appendJSONString(j.buf, variant.String())
+	j.buf = append(j.buf, `,"value":`...)
+
+	if err := j.value(variant, enc); err != nil {
+		return fmt.Errorf("%s variant %s: %w", t.String(), variant.String(), err)
+	}
+
+	j.buf = append(j.buf, '}')
+
+	return nil
+}
+
+func (j *jsonWriter) structValue(t reflect.Type, enc intEncoding) error {
+	st := cachedStruct(t)
+	if st.err != nil {
+		return st.err
+	}
+
+	if st.numbered {
+		return j.numberedStruct(st, enc)
+	}
+
+	j.buf = append(j.buf, '{')
+
+	first := true
+
+	for i := 0; i < len(st.fields); i++ {
+		field := st.fields[i]
+
+		if field.omitEmpty || field.embedded {
+			ok, err := j.d.readPresence()
+			if err != nil {
+				return fmt.Errorf("field %s presence: %w", field.name, err)
+			}
+
+			// Promoted fields are written in place of the embedded pointer, a nil pointer is written as null.
+			if field.embedded {
+				if !ok {
+					if !first {
+						j.buf = append(j.buf, ',')
+					}
+
+					first = false
+
+					j.buf = appendJSONString(j.buf, field.name)
+					j.buf = append(j.buf, ":null"...)
+					i += field.promoted
+				}
+
+				continue
+			}
+
+			if !ok {
+				continue
+			}
+		}
+
+		if !first {
+			j.buf = append(j.buf, ',')
+		}
+
+		first = false
+
+		j.buf = appendJSONString(j.buf, field.name)
+		j.buf = append(j.buf, ':')
+
+		if err := j.value(field.typ, field.intEncoding(enc)); err != nil {
+			return fmt.Errorf("field %s: %w", field.name, err)
+		}
+	}
+
+	j.buf = append(j.buf, '}')
+
+	return nil
+}
+
+// numberedStruct writes the fields of a struct with numbered fields in the order of the payload.
+func (j *jsonWriter) numberedStruct(st structType, enc intEncoding) error {
+	byNumber := make(map[uint64]structField, len(st.fields))
+	for _, field := range st.fields {
+		byNumber[field.number] = field
+	}
+
+	j.buf = append(j.buf, '{')
+
+	for i := 0; ; i++ {
+		number, err := binary.ReadUvarint(j.d)
+		if err != nil {
+			return fmt.Errorf("decoding field number: %w", err)
+		}
+
+		if number == 0 {
+			j.buf = append(j.buf, '}')
+			return nil
+		}
+
+		length, err := binary.ReadUvarint(j.d)
+		if err != nil {
+			return fmt.Errorf("decoding field %d length: %w", number, err)
+		}
+
+		if length > math.MaxInt32 {
+			return fmt.Errorf("decoding field %d: maximum length of %d exceeded", number, math.MaxInt32)
+		}
+
+		if i > 0 {
+			j.buf = append(j.buf, ',')
+		}
+
+		field, ok := byNumber[number]
+		if !ok {
+			start := j.d.offset
+
+			if err := j.d.skip(int(length)); err != nil {
+				return fmt.Errorf("reading unknown field %d: %w", number, err)
+			}
+
+			j.buf = appendJSONString(j.buf, "#"+strconv.FormatUint(number, 10))
+			j.buf = append(j.buf, ':')
+			j.buf = appendJSONBytes(j.buf, j.payload[start:j.d.offset])
+
+			continue
+		}
+
+		end := j.d.offset + int(length)
+
+		j.buf = appendJSONString(j.buf, field.name)
+		j.buf = append(j.buf, ':')
+
+		if err := j.value(field.typ, field.intEncoding(enc)); err != nil {
+			return fmt.Errorf("field %s: %w", field.name, err)
+		}
+
+		// Bytes after the value cannot be restored, so they are not skipped like the decoder does.
+		if j.d.offset != end {
+			return fmt.Errorf("field %s: value of %d bytes does not match its length of %d", field.name, j.d.offset-end+int(length), length)
+		}
+	}
+}
+
+func (j *jsonWriter) mapValue(t reflect.Type, enc intEncoding) error {
+	length, err := j.d.readLen(enc)
+	if err != nil {
+		return fmt.Errorf("decoding %s length: %w", t.String(), err)
+	}
+
+	if err := j.d.checkLength(length); err != nil {
+		return fmt.Errorf("decoding %s: %w", t.String(), err)
+	}
+
+	object := isJSONKey(t.Key())
+
+	if object {
+		j.buf = append(j.buf, '{')
+	} else {
+		j.buf = append(j.buf, '[')
+	}
+
+	for i := range length {
+		if i > 0 {
+			j.buf = append(j.buf, ',')
+		}
+
+		if !object {
+			j.buf = append(j.buf, '[')
+		}
+
+		keyStart := len(j.buf)
+
+		if err := j.value(t.Key(), enc); err != nil {
+			return fmt.Errorf("map key %d: %w", i, err)
+		}
+
+		if object {
+			// Object keys are strings, numbers and bools are written as their text.
+			if key := j.buf[keyStart:]; key[0] != '"' {
+				j.buf = appendJSONString(j.buf[:keyStart], string(key))
+			}
+
+			j.buf = append(j.buf, ':')
+		} else {
+			j.buf = append(j.buf, ',')
+		}
+
+		if err := j.value(t.Elem(), enc); err != nil {
+			return fmt.Errorf("map value %d: %w", i, err)
+		}
+
+		if !object {
+			j.buf = append(j.buf, ']')
+		}
+	}
+
+	if object {
+		j.buf = append(j.buf, '}')
+	} else {
+		j.buf = append(j.buf, ']')
+	}
+
+	return nil
+}
+
+func (j *jsonWriter) stdlib(t reflect.Type, enc intEncoding) error {
+	switch t {
+	case reflect.TypeFor[time.Time]():
+		// The location name and offset are read as encoded, so they are restored exactly.
+		b, err := j.d.read(12)
+		if err != nil {
+			return fmt.Errorf("decoding time.Time: %w", err)
+		}
+
+		sec, nsec := int64(decodeUint64(b[:8])), decodeUint32(b[8:12])
+		if nsec >= uint32(time.Second) {
+			return fmt.Errorf("decoding time.Time: invalid nanoseconds %d", nsec)
+		}
+
+		name, err := j.d.readString(enc)
+		if err != nil {
+			return fmt.Errorf("decoding time.Time location: %w", err)
+		}
+
+		b, err = j.d.read(4)
+		if err != nil {
+			return fmt.Errorf("decoding time.Time offset: %w", err)
+		}
+
+		offset := int(int32(decodeUint32(b)))
+		tm := time.Unix(sec, int64(nsec)).In(time.FixedZone(name, offset))
+
+		if tm.Year() < 0 || tm.Year() > 9999 {
+			return fmt.Errorf("time %s is outside the range of RFC 3339", tm.String())
+		}
+
+		s := tm.Format(time.RFC3339Nano)
+		if name != "UTC" || offset != 0 {
+			s += "[" + name + "]"
+		}
+
+		return j.appendString(s)
+	case reflect.TypeFor[url.URL]():
+		// The string form is kept as encoded, rather than as parsed.
+		s, err := j.d.readString(enc)
+		if err != nil {
+			return fmt.Errorf("decoding url.URL: %w", err)
+		}
+
+		return j.appendString(s)
+	}
+
+	v, err := j.decode(t, enc)
+	if err != nil {
+		return err
+	}
+
+	if x, ok := reflect.TypeAssert[*big.Int](v.Addr()); ok {
+		j.buf = x.Append(j.buf, 10)
+		return nil
+	}
+
+	marshaler, ok := reflect.TypeAssert[encoding.TextMarshaler](v.Addr())
+	if !ok {
+		return fmt.Errorf("type %s has no text form", t.String())
+	}
+
+	text, err := marshaler.MarshalText()
+	if err != nil {
+		return fmt.Errorf("formatting %s: %w", t.String(), err)
+	}
+
+	return j.appendString(string(text))
+}
+
+// isByteSequence reports whether t is a slice or array of bytes without a custom encoding, written as base64.
+func isByteSequence(t reflect.Type) bool {
+	elem := t.Elem()
+
+	if _, ok := stdlibCodecs[elem]; ok || customEncodingOf(elem) != customNone {
+		return false
+	}
+
+	return elem.Kind() == reflect.Uint8
+}
+
+// isJSONKey reports whether map keys of type t are written as JSON object keys.
+func isJSONKey(t reflect.Type) bool {
+	if _, ok := stdlibCodecs[t]; ok || customEncodingOf(t) != customNone {
+		return false
+	}
+
+	switch t.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return true
+	default:
+		return false
+	}
+}
+
+// appendJSONString appends s as a JSON string, s must be valid UTF-8.
+func appendJSONString(b []byte, s string) []byte {
+	const hexDigits = "0123456789abcdef"
+
+	b = append(b, '"')
+
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '"' || c == '\\':
+			b = append(b, '\\', c)
+		case c == '\n':
+			b = append(b, '\\', 'n')
+		case c == '\r':
+			b = append(b, '\\', 'r')
+		case c == '\t':
+			b = append(b, '\\', 't')
+		case c < 0x20:
+			b = append(b, '\\', 'u', '0', '0', hexDigits[c>>4], hexDigits[c&0xf])
+		default:
+			b = append(b, c)
+		}
+	}
+
+	return append(b, '"')
+}
+
+func appendJSONBytes(b, data []byte) []byte {
+	b = append(b, '"')
+	b = base64.StdEncoding.AppendEncode(b, data)
+
+	return append(b, '"')
+}
+
+// NaN bits written as "NaN", those of [math.NaN].
+var (
+	canonicalNaN32 = uint64(math.Float32bits(float32(math.NaN())))
+	canonicalNaN64 = math.Float64bits(math.NaN())
+)
+
+// appendJSONFloat appends the float with the given bits and bit size.
+func appendJSONFloat(b []byte, bits uint64, bitSize int) []byte {
+	f := math.Float64frombits(bits)
+	canonicalNaN := canonicalNaN64
+
+	if bitSize == 32 {
+		f = float64(math.Float32frombits(uint32(bits)))
+		canonicalNaN = canonicalNaN32
+	}
+
+	switch {
+	case math.IsNaN(f) && bits == canonicalNaN:
+		return append(b, `"NaN"`...)
+	case math.IsNaN(f):
+		return fmt.Appendf(b, `"NaN(%#x)"`, bits)
+	case math.IsInf(f, 1):
+		return append(b, `"+Inf"`...)
+	case math.IsInf(f, -1):
+		return append(b, `"-Inf"`...)
+	default:
+		return strconv.AppendFloat(b, f, 'g', -1, bitSize)
+	}
+}
+
+// parseJSONFloat parses a float written by [appendJSONFloat] and returns its bits.
+func parseJSONFloat(raw json.RawMessage, bitSize int) (uint64, error) {
+	var s string
+
+	if raw[0] != '"' {
+		s = string(raw)
+	} else if err := json.Unmarshal(raw, &s); err != nil {
+		return 0, err
+	}
+
+	canonicalNaN := canonicalNaN64
+	if bitSize == 32 {
+		canonicalNaN = canonicalNaN32
+	}
+
+	switch {
+	case s == "NaN":
+		return canonicalNaN, nil
+	case strings.HasPrefix(s, "NaN(") && strings.HasSuffix(s, ")"):
+		bits, err := strconv.ParseUint(s[len("NaN("):len(s)-1], 0, bitSize)
+		if err != nil {
+			return 0, fmt.Errorf("%w: NaN bits %s", ErrInvalidJSON, s)
+		}
+
+		return bits, nil
+	case s == "+Inf" && raw[0] == '"':
+		return floatBits(math.Inf(1), bitSize), nil
+	case s == "-Inf" && raw[0] == '"':
+		return floatBits(math.Inf(-1), bitSize), nil
+	case raw[0] == '"':
+		return 0, fmt.Errorf("%w: float %s", ErrInvalidJSON, raw)
+	}
+
+	f, err := strconv.ParseFloat(s, bitSize)
+	if err != nil {
+		return 0, fmt.Errorf("%w: float %s", ErrInvalidJSON, s)
+	}
+
+	return floatBits(f, bitSize), nil
+}
+
+func floatBits(f float64, bitSize int) uint64 {
+	if bitSize == 32 {
+		return uint64(math.Float32bits(float32(f)))
+	}
+
+	return math.Float64bits(f)
+}
+
+// jsonEntry is a member of a JSON object.
+type jsonEntry struct {
+	key   string
+	value json.RawMessage
+}
+
+// jsonObject returns the members of a JSON object in order.
+func jsonObject(raw json.RawMessage) ([]jsonEntry, error) {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+
+	if token, err := dec.Token(); err != nil || token != json.Delim('{') {
+		return nil, fmt.Errorf("%w: expected object, got %s", ErrInvalidJSON, raw)
+	}
+
+	var entries []jsonEntry
+
+	for dec.More() {
+		token, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+
+		var value json.RawMessage
+
+		if err := dec.Decode(&value); err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, jsonEntry{key: token.(string), value: value})
+	}
+
+	return entries, nil
+}
+
+// jsonArray returns the elements of a JSON array.
+func jsonArray(raw json.RawMessage) ([]json.RawMessage, error) {
+	if raw[0] != '[' {
+		return nil, fmt.Errorf("%w: expected array, got %s", ErrInvalidJSON, raw)
+	}
+
+	var elems []json.RawMessage
+
+	if err := json.Unmarshal(raw, &elems); err != nil {
+		return nil, err
+	}
+
+	return elems, nil
+}
+
+// unmarshalJSON unmarshals raw into v, reporting type mismatches as [ErrInvalidJSON].
+func unmarshalJSON(raw json.RawMessage, v any) error {
+	if err := json.Unmarshal(raw, v); err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidJSON, err.Error())
+	}
+
+	return nil
+}
+
+func isJSONNull(raw json.RawMessage) bool {
+	return string(raw) == "null"
+}
+
+func jsonBytes(raw json.RawMessage) ([]byte, error) {
+	var s string
+
+	if err := unmarshalJSON(raw, &s); err != nil {
+		return nil, err
+	}
+
+	b, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidJSON, err.Error())
+	}
+
+	return b, nil
+}
+
+func jsonRoot(e *encodeState, raw json.RawMessage, t reflect.Type, enc intEncoding) error {
+	raw = bytes.TrimSpace(raw)
+
+	// Top-level pointers are dereferenced.
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch {
+	case hasTopLevelEncoding(t):
+		b, err := jsonBytes(raw)
+		if err != nil {
+			return err
+		}
+
+		e.buf = append(e.buf, b...)
+
+		return nil
+	case t.Kind() == reflect.String:
+		// Top-level strings are not length-prefixed.
+		var s string
+
+		if err := unmarshalJSON(raw, &s); err != nil {
+			return err
+		}
+
+		e.buf = append(e.buf, s...)
+
+		return nil
+	default:
+		return fromJSON(e, raw, t, enc)
+	}
+}
+
+// encodeJSONValue encodes v, decoded from JSON, with its regular encoder.
+func encodeJSONValue(e *encodeState, v reflect.Value, enc intEncoding) error {
+	return encoderFor(v.Type(), enc)(e, v)
+}
+
+func fromJSON(e *encodeState, raw json.RawMessage, t reflect.Type, enc intEncoding) error {
+	if _, ok := stdlibCodecs[t]; ok {
+		return stdlibFromJSON(e, raw, t, enc)
+	}
+
+	switch customEncodingOf(t) {
+	case customStream:
+		b, err := jsonBytes(raw)
+		if err != nil {
+			return err
+		}
+
+		e.buf = append(e.buf, b...)
+
+		return nil
+	case customBytes, customBinary, customCodec:
+		b, err := jsonBytes(raw)
+		if err != nil {
+			return err
+		}
+
+		return e.appendFramed(b, enc)
+	}
+
+	if isOptional(t) {
+		if isJSONNull(raw) {
+			e.buf = append(e.buf, absent)
+			return nil
+		}
+
+		e.buf = append(e.buf, present)
+
+		return fromJSON(e, raw, t.Field(0).Type, enc)
+	}
+
+	v := reflect.New(t).Elem()
+
+	switch t.Kind() {
+	case reflect.Bool:
+		var b bool
+
+		if err := unmarshalJSON(raw, &b); err != nil {
+			return err
+		}
+
+		v.SetBool(b)
+
+		return encodeJSONValue(e, v, enc)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(string(raw), 10, 64)
+		if err != nil || v.OverflowInt(i) {
+			return fmt.Errorf("%w: %s is not a valid %s", ErrInvalidJSON, raw, t.String())
+		}
+
+		v.SetInt(i)
+
+		return encodeJSONValue(e, v, enc)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		u, err := strconv.ParseUint(string(raw), 10, 64)
+		if err != nil || v.OverflowUint(u) {
+			return fmt.Errorf("%w: %s is not a valid %s", ErrInvalidJSON, raw, t.String())
+		}
+
+		v.SetUint(u)
+
+		return encodeJSONValue(e, v, enc)
+	case reflect.Float32, reflect.Float64:
+		bits, err := parseJSONFloat(raw, t.Bits())
+		if err != nil {
+			return err
+		}
+
+		e.buf = appendFloatBits(e.buf, bits, t.Bits())
+
+		return nil
+	case reflect.Complex64, reflect.Complex128:
+		parts, err := jsonArray(raw)
+		if err != nil {
+			return err
+		}
+
+		if len(parts) != 2 {
+			return fmt.Errorf("%w: %s is not a pair of real and imaginary part", ErrInvalidJSON, raw)
+		}
+
+		for _, part := range parts {
+			bits, err := parseJSONFloat(part, t.Bits()/2)
+			if err != nil {
+				return err
+			}
+
+			e.buf = appendFloatBits(e.buf, bits, t.Bits()/2)
+		}
+
+		return nil
+	case reflect.String:
+		var s string
+
+		if err := unmarshalJSON(raw, &s); err != nil {
+			return err
+		}
+
+		v.SetString(s)
+
+		return encodeJSONValue(e, v, enc)
+	case reflect.Pointer:
+		if isJSONNull(raw) {
+			e.buf = append(e.buf, absent)
+			return nil
+		}
+
+		e.buf = append(e.buf, present)
+
+		return fromJSON(e, raw, t.Elem(), enc)
+	case reflect.Interface:
+		return interfaceFromJSON(e, raw, t, enc)
+	case reflect.Struct:
+		return structFromJSON(e, raw, t, enc)
+	case reflect.Array, reflect.Slice:
+		if isByteSequence(t) {
+			b, err := jsonBytes(raw)
+			if err != nil {
+				return err
+			}
+
+			if t.Kind() == reflect.Array && len(b) != t.Len() {
+				return fmt.Errorf("%w: %d bytes for %s", ErrInvalidJSON, len(b), t.String())
+			}
+
+			if t.Kind() == reflect.Slice {
+				v.Set(reflect.MakeSlice(t, len(b), len(b)))
+			}
+
+			copy(v.Bytes(), b)
+
+			return encodeJSONValue(e, v, enc)
+		}
+
+		elems, err := jsonArray(raw)
+		if err != nil {
+			return err
+		}
+
+		if t.Kind() == reflect.Array && len(elems) != t.Len() {
+			return fmt.Errorf("%w: %d elements for %s", ErrInvalidJSON, len(elems), t.String())
+		}
+
+		if err := e.appendLen(len(elems), enc); err != nil {
+			return err
+		}
+
+		for i, elem := range elems {
+			if err := fromJSON(e, elem, t.Elem(), enc); err != nil {
+				return fmt.Errorf("index %d: %w", i, err)
+			}
+		}
+
+		return nil
+	case reflect.Map:
+		return mapFromJSON(e, raw, t, enc)
+	default:
+		return fmt.Errorf("encoding of type %s is not supported", t.String())
+	}
+}
+
+func appendFloatBits(b []byte, bits uint64, bitSize int) []byte {
+	if bitSize == 32 {
+		return binary.LittleEndian.AppendUint32(b, uint32(bits))
+	}
+
+	return binary.LittleEndian.AppendUint64(b, bits)
+}
+
+func interfaceFromJSON(e *encodeState, raw json.RawMessage, t reflect.Type, enc intEncoding) error {
+	u, isUnion := unionOf(t)
+
+	if isJSONNull(raw) {
+		if isUnion {
+			e.buf = append(e.buf, 0)
+		} else {
+			e.buf = binary.LittleEndian.AppendUint32(e.buf, nilTypeID)
+		}
+
+		return nil
+	}
+
+	entries, err := jsonObject(raw)
+	if err != nil {
+		return err
+	}
+
+	var (
+		name  string
+		value json.RawMessage
+	)
+
+	for _, entry := range entries {
+		switch entry.key {
+		case "type":
+			if err := unmarshalJSON(entry.value, &name); err != nil {
+				return err
+			}
+		case "value":
+			value = entry.value
+		default:
+			return fmt.Errorf("%w: unknown member %q of %s", ErrInvalidJSON, entry.key, t.String())
+		}
+	}
+
+	if name == "" || value == nil {
+		return fmt.Errorf("%w: %s needs a type and a value", ErrInvalidJSON, t.String())
+	}
+
+	if isUnion {
+		variant, index, err := u.variantNamed(name)
+		if err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidJSON, err)
+		}
+
+		e.buf = binary.AppendUvarint(e.buf, index)
+
+		if err := fromJSON(e, value, variant, enc); err != nil {
+			return fmt.Errorf("%s variant %s: %w", t.String(), variant.String(), err)
+		}
+
+		return nil
+	}
+
+	id := typeID(name)
+
+	concrete, err := registeredType(id)
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+
+	if !concrete.AssignableTo(t) {
+		return fmt.Errorf("registered type %s does not implement %s", concrete.String(), t.String())
+	}
+
+	e.buf = binary.LittleEndian.AppendUint32(e.buf, id)
+
+	if err := fromJSON(e, value, concrete, enc); err != nil {
+		return fmt.Errorf("%s of type %s: %w", t.String(), concrete.String(), err)
+	}
+
+	return nil
+}
+
+func structFromJSON(e *encodeState, raw json.RawMessage, t reflect.Type, enc intEncoding) error {
+	st := cachedStruct(t)
+	if st.err != nil {
+		return st.err
+	}
+
+	entries, err := jsonObject(raw)
+	if err != nil {
+		return err
+	}
+
+	byName := make(map[string]structField, len(st.fields))
+	for _, field := range st.fields {
+		byName[field.name] = field
+	}
+
+	if st.numbered {
+		return numberedStructFromJSON(e, entries, byName, enc)
+	}
+
+	values := make(map[string]json.RawMessage, len(entries))
+
+	for _, entry := range entries {
+		if _, ok := byName[entry.key]; !ok {
+			return fmt.Errorf("%w: unknown field %q of %s", ErrInvalidJSON, entry.key, t.String())
+		}
+
+		values[entry.key] = entry.value
+	}
+
+	for i := 0; i < len(st.fields); i++ {
+		field := st.fields[i]
+		value, ok := values[field.name]
+
+		if field.embedded {
+			if ok && !isJSONNull(value) {
+				return fmt.Errorf("%w: embedded %s must be null or left out", ErrInvalidJSON, field.name)
+			}
+
+			if ok {
+				e.buf = append(e.buf, absent)
+				i += field.promoted
+
+				continue
+			}
+
+			e.buf = append(e.buf, present)
+
+			continue
+		}
+
+		if field.omitEmpty {
+			if !ok {
+				e.buf = append(e.buf, absent)
+				continue
+			}
+
+			e.buf = append(e.buf, present)
+		}
+
+		fieldEnc := field.intEncoding(enc)
+
+		if !ok {
+			if err := encodeJSONValue(e, reflect.New(field.typ).Elem(), fieldEnc); err != nil {
+				return fmt.Errorf("field %s: %w", field.name, err)
+			}
+
+			continue
+		}
+
+		if err := fromJSON(e, value, field.typ, fieldEnc); err != nil {
+			return fmt.Errorf("field %s: %w", field.name, err)
+		}
+	}
+
+	return nil
+}
+
+// numberedStructFromJSON writes the fields of a struct with numbered fields in the order of the JSON.
+func numberedStructFromJSON(e *encodeState, entries []jsonEntry, byName map[string]structField, enc intEncoding) error {
+	for _, entry := range entries {
+		if number, ok := strings.CutPrefix(entry.key, "#"); ok {
+			n, err := strconv.ParseUint(number, 10, 32)
+			if err != nil || n == 0 {
+				return fmt.Errorf("%w: invalid field number %q", ErrInvalidJSON, entry.key)
+			}
+
+			b, err := jsonBytes(entry.value)
+			if err != nil {
+				return fmt.Errorf("field %s: %w", entry.key, err)
+			}
+
+			e.buf = binary.AppendUvarint(e.buf, n)
+			e.buf = binary.AppendUvarint(e.buf, uint64(len(b)))
+			e.buf = append(e.buf, b...)
+
+			continue
+		}
+
+		field, ok := byName[entry.key]
+		if !ok {
+			return fmt.Errorf("%w: unknown field %q", ErrInvalidJSON, entry.key)
+		}
+
+		e.buf = binary.AppendUvarint(e.buf, field.number)
+		start := len(e.buf)
+
+		if err := fromJSON(e, entry.value, field.typ, field.intEncoding(enc)); err != nil {
+			return fmt.Errorf("field %s: %w", field.name, err)
+		}
+
+		e.insertLen(start)
+	}
+
+	e.buf = append(e.buf, 0)
+
+	return nil
+}
+
+func mapFromJSON(e *encodeState, raw json.RawMessage, t reflect.Type, enc intEncoding) error {
+	var entries []jsonEntry
+
+	if isJSONKey(t.Key()) {
+		members, err := jsonObject(raw)
+		if err != nil {
+			return err
+		}
+
+		for _, member := range members {
+			// Keys of other types than strings hold the text of their JSON value.
+			key := json.RawMessage(appendJSONString(nil, member.key))
+			if t.Key().Kind() != reflect.String && json.Valid([]byte(member.key)) {
+				key = json.RawMessage(member.key)
+			}
+
+			entries = append(entries, jsonEntry{key: string(key), value: member.value})
+		}
+	} else {
+		pairs, err := jsonArray(raw)
+		if err != nil {
+			return err
+		}
+
+		for _, pair := range pairs {
+			elems, err := jsonArray(pair)
+			if err != nil {
+				return err
+			}
+
+			if len(elems) != 2 {
+				return fmt.Errorf("%w: map entry %s is not a key-value pair", ErrInvalidJSON, pair)
+			}
+
+			entries = append(entries, jsonEntry{key: string(elems[0]), value: elems[1]})
+		}
+	}
+
+	if err := e.appendLen(len(entries), enc); err != nil {
+		return err
+	}
+
+	for i, entry := range entries {
+		if err := fromJSON(e, json.RawMessage(entry.key), t.Key(), enc); err != nil {
+			return fmt.Errorf("map key %d: %w", i, err)
+		}
+
+		if err := fromJSON(e, entry.value, t.Elem(), enc); err != nil {
+			return fmt.Errorf("map value %d: %w", i, err)
+		}
+	}
+
+	return nil
+}
+
+func stdlibFromJSON(e *encodeState, raw json.RawMessage, t reflect.Type, enc intEncoding) error {
+	v := reflect.New(t).Elem()
+
+	if t == reflect.TypeFor[big.Int]() {
+		x, _ := reflect.TypeAssert[*big.Int](v.Addr())
+
+		if _, ok := x.SetString(string(raw), 10); !ok {
+			return fmt.Errorf("%w: %s is not a valid big.Int", ErrInvalidJSON, raw)
+		}
+
+		return encodeJSONValue(e, v, enc)
+	}
+
+	var s string
+
+	if err := unmarshalJSON(raw, &s); err != nil {
+		return err
+	}
+
+	switch t {
+	case reflect.TypeFor[time.Time]():
+		return appendJSONTime(e, s, enc)
+	case reflect.TypeFor[url.URL]():
+		if _, err := url.Parse(s); err != nil {
+			return fmt.Errorf("%w: %s", ErrInvalidJSON, err.Error())
+		}
+
+		return e.appendString(s, enc)
+	}
+
+	unmarshaler, ok := reflect.TypeAssert[encoding.TextUnmarshaler](v.Addr())
+	if !ok {
+		return fmt.Errorf("type %s has no text form", t.String())
+	}
+
+	if err := unmarshaler.UnmarshalText([]byte(s)); err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidJSON, err.Error())
+	}
+
+	return encodeJSONValue(e, v, enc)
+}
+
+// appendJSONTime appends the encoding of a time.Time written by [jsonWriter.stdlib], see [encodeTime].
+func appendJSONTime(e *encodeState, s string, enc intEncoding) error {
+	name := "UTC"
+
+	if i := strings.IndexByte(s, '['); i >= 0 && strings.HasSuffix(s, "]") {
+		s, name = s[:i], s[i+1:len(s)-1]
+	}
+
+	tm, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidJSON, err.Error())
+	}
+
+	_, offset := tm.Zone()
+
+	e.buf = binary.LittleEndian.AppendUint64(e.buf, uint64(tm.Unix()))
+	e.buf = binary.LittleEndian.AppendUint32(e.buf, uint32(tm.Nanosecond()))
+
+	if err := e.appendString(name, enc); err != nil {
+		return fmt.Errorf("location: %w", err)
+	}
+
+	e.buf = binary.LittleEndian.AppendUint32(e.buf, uint32(int32(offset)))
+
+	return nil
+}
@@ -0,0 +1,339 @@
+package goc
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+)
+
+var (
+	ErrMaxBytes        = errors.New("maximum number of bytes exceeded")
+	ErrMaxLength       = errors.New("maximum collection length exceeded")
+	ErrMaxStringLength = errors.New("maximum string length exceeded")
+	ErrMaxDepth        = errors.New("maximum nesting depth exceeded")
+)
+
+// LimitError is returned when a decoded value exceeds a limit set with a decode option.
+// It wraps [ErrMaxBytes], [ErrMaxLength], [ErrMaxStringLength] or [ErrMaxDepth].
+type LimitError struct {
+	Err error
+	// The configured limit.
+	Max int
+	// The decoded length or depth, or the least number of bytes needed to decode the value.
+	Got int
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("%s: %d exceeds %d", e.Err.Error(), e.Got, e.Max)
+}
+
+func (e *LimitError) Unwrap() error {
+	return e.Err
+}
+
+// WithMaxBytes limits the number of bytes read to decode a value.
+func WithMaxBytes(n int) Option {
+	return func(cfg *config) error {
+		if cfg.limits.maxBytes != 0 {
+			return ErrOptionDuplicate
+		}
+
+		if n <= 0 {
+			return fmt.Errorf("WithMaxBytes: invalid limit %d", n)
+		}
+
+		cfg.limits.maxBytes = n
+
+		return nil
+	}
+}
+
+// WithMaxLength limits the length of decoded slices and maps.
+func WithMaxLength(n int) Option {
+	return func(cfg *config) error {
+		if cfg.limits.maxLength != 0 {
+			return ErrOptionDuplicate
+		}
+
+		if n <= 0 {
+			return fmt.Errorf("WithMaxLength: invalid limit %d", n)
+		}
+
+		cfg.limits.maxLength = n
+
+		return nil
+	}
+}
+
+// WithMaxStringLength limits the length of decoded strings.
+func WithMaxStringLength(n int) Option {
+	return func(cfg *config) error {
+		if cfg.limits.maxStringLength != 0 {
+			return ErrOptionDuplicate
+		}
+
+		if n <= 0 {
+			return fmt.Errorf("WithMaxStringLength: invalid limit %d", n)
+		}
+
+		cfg.limits.maxStringLength = n
+
+		return nil
+	}
+}
+
+// WithMaxDepth limits the nesting depth of decoded structs, arrays, slices, maps and interfaces.
+// Code generated by gocgen only counts the depth of values it does not generate code for.
+func WithMaxDepth(n int) Option {
+	return func(cfg *config) error {
+		if cfg.limits.maxDepth != 0 {
+			return ErrOptionDuplicate
+		}
+
+		if n <= 0 {
+			return fmt.Errorf("WithMaxDepth: invalid limit %d", n)
+		}
+
+		cfg.limits.maxDepth = n
+
+		return nil
+	}
+}
+
+// decodeLimits are the resource limits of a decode call, zero limits are not enforced.
+type decodeLimits struct {
+	maxBytes        int
+	maxLength       int
+	maxStringLength int
+	maxDepth        int
+}
+
+// limitReader enforces the limits of a decode call.
+// It is the reader of all decoding state of the call, including code generated by gocgen,
+// so limits are enforced across values with custom decoders.
+// It also carries whether decoding is strict, see [WithStrict], and whether references are tracked, see [WithReferences].
+type limitReader struct {
+	decodeLimits
+	strict     bool
+	references bool
+
+	r          io.Reader
+	byteReader io.ByteReader
+
+	// Number of bytes that can be read before exceeding maxBytes.
+	remaining int
+	// Current nesting depth.
+	depth int
+}
+
+// limit wraps r in a [limitReader] if any limits are set, decoding is strict or references are tracked.
+func (cfg config) limit(r io.Reader) io.Reader {
+	if cfg.limits == (decodeLimits{}) && !cfg.strict && !cfg.references {
+		return r
+	}
+
+	l := &limitReader{
+		decodeLimits: cfg.limits,
+		strict:       cfg.strict,
+		references:   cfg.references,
+		r:            r,
+	}
+
+	l.reset()
+
+	if byteReader, ok := r.(io.ByteReader); ok {
+		l.byteReader = byteReader
+	}
+
+	return l
+}
+
+// reset prepares the limits for decoding the next value.
+func (l *limitReader) reset() {
+	l.remaining = l.maxBytes
+	l.depth = 0
+}
+
+func (l *limitReader) Read(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	if l.maxBytes == 0 {
+		return l.r.Read(p)
+	}
+
+	if l.remaining == 0 {
+		return 0, l.exceeded()
+	}
+
+	n, err := l.r.Read(p[:min(len(p), l.remaining)])
+	l.remaining -= n
+
+	return n, err
+}
+
+func (l *limitReader) ReadByte() (byte, error) {
+	if l.maxBytes != 0 {
+		if l.remaining == 0 {
+			return 0, l.exceeded()
+		}
+
+		l.remaining--
+	}
+
+	if l.byteReader != nil {
+		return l.byteReader.ReadByte()
+	}
+
+	var b [1]byte
+
+	if _, err := io.ReadFull(l.r, b[:]); err != nil {
+		return 0, err
+	}
+
+	return b[0], nil
+}
+
+// exceeded returns an error once all allowed bytes have been read.
+// Reading past the limit is only an error if the input continues, so one more byte is read to check.
+func (l *limitReader) exceeded() error {
+	var b [1]byte
+
+	if _, err := io.ReadFull(l.r, b[:]); err != nil {
+		return err
+	}
+
+	return &LimitError{Err: ErrMaxBytes, Max: l.maxBytes, Got: l.maxBytes + 1}
+}
+
+// reserve checks that n more bytes can be read, before allocating memory for them.
+func (l *limitReader) reserve(n int) error {
+	if l.maxBytes != 0 && n > l.remaining {
+		// Lengths up to math.MaxInt32 can overflow the total with a 32-bit int.
+		return &LimitError{Err: ErrMaxBytes, Max: l.maxBytes, Got: l.maxBytes - l.remaining + min(n, math.MaxInt-l.maxBytes)}
+	}
+
+	return nil
+}
+
+func (l *limitReader) checkLength(length int) error {
+	if l.maxLength != 0 && length > l.maxLength {
+		return &LimitError{Err: ErrMaxLength, Max: l.maxLength, Got: length}
+	}
+
+	return nil
+}
+
+func (l *limitReader) checkStringLength(length int) error {
+	if l.maxStringLength != 0 && length > l.maxStringLength {
+		return &LimitError{Err: ErrMaxStringLength, Max: l.maxStringLength, Got: length}
+	}
+
+	return nil
+}
+
+// limitToPayload limits the number of bytes read to the length of payload if no byte limit is set,
+// so length prefixes cannot claim more bytes than the payload holds. It reports whether it did.
+func (cfg *config) limitToPayload(payload []byte) bool {
+	if cfg.limits.maxBytes != 0 || len(payload) == 0 {
+		return false
+	}
+
+	cfg.limits.maxBytes = len(payload)
+
+	return true
+}
+
+// payloadEndError reports exceeding the limit set by [config.limitToPayload] as running out of input.
+func payloadEndError(err error) error {
+	var limitErr *LimitError
+	if errors.As(err, &limitErr) && limitErr.Err == ErrMaxBytes {
+		return fmt.Errorf("%w: %d bytes needed, payload has %d", io.ErrUnexpectedEOF, limitErr.Got, limitErr.Max)
+	}
+
+	return err
+}
+
+// limitsOf returns the limits enforced on r, or nil.
+func limitsOf(r io.Reader) *limitReader {
+	switch r := r.(type) {
+	case *limitReader:
+		return r
+	case *decodeState:
+		return r.limits
+	case *io.LimitedReader:
+		// Used by code generated by gocgen for numbered fields.
+		return limitsOf(r.R)
+	default:
+		return nil
+	}
+}
+
+// CheckLength returns a [*LimitError] if a slice or map of the given length exceeds the limits enforced on r.
+// It is used by code generated by gocgen.
+func CheckLength(r io.Reader, length int) error {
+	if l := limitsOf(r); l != nil {
+		return l.checkLength(length)
+	}
+
+	return nil
+}
+
+// CheckStringLength returns a [*LimitError] if a string of the given length exceeds the limits enforced on r.
+// It is used by code generated by gocgen.
+func CheckStringLength(r io.Reader, length int) error {
+	if l := limitsOf(r); l != nil {
+		return l.checkStringLength(length)
+	}
+
+	return nil
+}
+
+// checkLength checks the length of a decoded slice or map, see [WithMaxLength].
+func (d *decodeState) checkLength(length int) error {
+	if d.limits != nil {
+		return d.limits.checkLength(length)
+	}
+
+	return nil
+}
+
+// checkStringLength checks the length of a decoded string, see [WithMaxStringLength].
+func (d *decodeState) checkStringLength(length int) error {
+	if d.limits != nil {
+		return d.limits.checkStringLength(length)
+	}
+
+	return nil
+}
+
+// reserve checks that n more bytes can be read, see [WithMaxBytes].
+func (d *decodeState) reserve(n int) error {
+	if d.limits != nil {
+		return d.limits.reserve(n)
+	}
+
+	return nil
+}
+
+// nested counts the nesting depth of values decoded by f, see [WithMaxDepth].
+func nested(f decodeFunc) decodeFunc {
+	return func(d *decodeState, v reflect.Value) error {
+		if d.limits == nil || d.limits.maxDepth == 0 {
+			return f(d, v)
+		}
+
+		if d.limits.depth == d.limits.maxDepth {
+			return &LimitError{Err: ErrMaxDepth, Max: d.limits.maxDepth, Got: d.limits.depth + 1}
+		}
+
+		d.limits.depth++
+		err := f(d, v)
+		d.limits.depth--
+
+		return err
+	}
+}
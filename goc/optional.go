@@ -0,0 +1,221 @@
+package goc
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"time"
+	"unsafe"
+)
+
+// Optional holds a value of type T that is either set or not, so an unset value can be told apart from a zero value
+// without using a pointer, such as the fields of a partial update request.
+// It is encoded as a presence byte followed by the value if it is set, like a nested pointer.
+// T can be any type goc encodes. The zero value is not set.
+//
+// Optional implements [sql.Scanner] and [driver.Valuer], so it can be used in place of [sql.Null]
+// and the typed variants such as [sql.NullString].
+// Values of those can be converted with [OptionalFromNull] and functions such as [OptionalFromNullString].
+type Optional[T any] struct {
+	value T
+	set   bool
+}
+
+// OptionalOf returns an Optional holding v.
+func OptionalOf[T any](v T) Optional[T] {
+	return Optional[T]{value: v, set: true}
+}
+
+// OptionalFromNull returns an Optional holding the value of n if it is valid.
+func OptionalFromNull[T any](n sql.Null[T]) Optional[T] {
+	return optionalIf(n.V, n.Valid)
+}
+
+// OptionalFromNullString returns an Optional holding the value of n if it is valid.
+func OptionalFromNullString(n sql.NullString) Optional[string] {
+	return optionalIf(n.String, n.Valid)
+}
+
+// OptionalFromNullInt64 returns an Optional holding the value of n if it is valid.
+func OptionalFromNullInt64(n sql.NullInt64) Optional[int64] {
+	return optionalIf(n.Int64, n.Valid)
+}
+
+// OptionalFromNullInt32 returns an Optional holding the value of n if it is valid.
+func OptionalFromNullInt32(n sql.NullInt32) Optional[int32] {
+	return optionalIf(n.Int32, n.Valid)
+}
+
+// OptionalFromNullInt16 returns an Optional holding the value of n if it is valid.
+func OptionalFromNullInt16(n sql.NullInt16) Optional[int16] {
+	return optionalIf(n.Int16, n.Valid)
+}
+
+// OptionalFromNullByte returns an Optional holding the value of n if it is valid.
+func OptionalFromNullByte(n sql.NullByte) Optional[byte] {
+	return optionalIf(n.Byte, n.Valid)
+}
+
+// OptionalFromNullFloat64 returns an Optional holding the value of n if it is valid.
+func OptionalFromNullFloat64(n sql.NullFloat64) Optional[float64] {
+	return optionalIf(n.Float64, n.Valid)
+}
+
+// OptionalFromNullBool returns an Optional holding the value of n if it is valid.
+func OptionalFromNullBool(n sql.NullBool) Optional[bool] {
+	return optionalIf(n.Bool, n.Valid)
+}
+
+// OptionalFromNullTime returns an Optional holding the value of n if it is valid.
+func OptionalFromNullTime(n sql.NullTime) Optional[time.Time] {
+	return optionalIf(n.Time, n.Valid)
+}
+
+// optionalIf returns an Optional holding v if set is true.
+func optionalIf[T any](v T, set bool) Optional[T] {
+	if !set {
+		return Optional[T]{}
+	}
+
+	return OptionalOf(v)
+}
+
+// Set sets the value to v.
+func (o *Optional[T]) Set(v T) {
+	o.value, o.set = v, true
+}
+
+// Unset clears the value.
+func (o *Optional[T]) Unset() {
+	*o = Optional[T]{}
+}
+
+// Get returns the value and whether it is set. The value is the zero value of T if it is not set.
+func (o Optional[T]) Get() (T, bool) {
+	return o.value, o.set
+}
+
+// IsSet reports whether the value is set.
+func (o Optional[T]) IsSet() bool {
+	return o.set
+}
+
+// Or returns the value if it is set, or fallback otherwise.
+func (o Optional[T]) Or(fallback T) T {
+	if !o.set {
+		return fallback
+	}
+
+	return o.value
+}
+
+// Validate validates the value if it is set and T has a Validate method,
+// so Optional fields can be checked from the Validate method of a gorpc request or response.
+func (o Optional[T]) Validate() error {
+	if !o.set {
+		return nil
+	}
+
+	if validator, ok := any(&o.value).(interface{ Validate() error }); ok {
+		return validator.Validate()
+	}
+
+	return nil
+}
+
+// Null returns the value as an [sql.Null], which is valid if the value is set.
+func (o Optional[T]) Null() sql.Null[T] {
+	return sql.Null[T]{V: o.value, Valid: o.set}
+}
+
+// Scan implements [sql.Scanner], a NULL column unsets the value.
+func (o *Optional[T]) Scan(src any) error {
+	var n sql.Null[T]
+
+	if err := n.Scan(src); err != nil {
+		return err
+	}
+
+	*o = OptionalFromNull(n)
+
+	return nil
+}
+
+// Value implements [driver.Valuer], an unset value is NULL.
+func (o Optional[T]) Value() (driver.Value, error) {
+	return o.Null().Value()
+}
+
+// Package path of the unexported fields of [Optional].
+var optionalPkgPath = reflect.TypeFor[Optional[int]]().Field(0).PkgPath
+
+// isOptional reports whether t is an [Optional] type, or a type defined with it as its underlying type.
+// It checks the fields rather than the methods, as the methods of an embedded Optional are promoted.
+func isOptional(t reflect.Type) bool {
+	return t.Kind() == reflect.Struct && t.NumField() == 2 &&
+		t.Field(0).Name == "value" && t.Field(0).PkgPath == optionalPkgPath &&
+		t.Field(1).Name == "set" && t.Field(1).Type == reflect.TypeFor[bool]()
+}
+
+// optionalFields returns the value and the presence flag of [Optional] v.
+// They are settable if v is addressable, otherwise v is copied.
+func optionalFields(v reflect.Value) (value, set reflect.Value) {
+	ptr := addressable(v).UnsafePointer()
+	valueField, setField := v.Type().Field(0), v.Type().Field(1)
+
+	return reflect.NewAt(valueField.Type, ptr).Elem(), reflect.NewAt(setField.Type, unsafe.Add(ptr, setField.Offset)).Elem()
+}
+
+func compileOptionalEncoder(t reflect.Type, enc intEncoding) encodeFunc {
+	valueEncoder := encoderFor(t.Field(0).Type, enc)
+
+	return func(e *encodeState, v reflect.Value) error {
+		value, set := optionalFields(v)
+		if !set.Bool() {
+			e.buf = append(e.buf, absent)
+			return nil
+		}
+
+		e.buf = append(e.buf, present)
+
+		return valueEncoder(e, value)
+	}
+}
+
+func compileOptionalDecoder(t reflect.Type, enc intEncoding) decodeFunc {
+	valueDecoder := decoderFor(t.Field(0).Type, enc)
+
+	return func(d *decodeState, v reflect.Value) error {
+		ok, err := d.readPresence()
+		if err != nil {
+			return fmt.Errorf("decoding %s presence: %w", t.String(), err)
+		}
+
+		value, set := optionalFields(v)
+		set.SetBool(ok)
+
+		if !ok {
+			value.SetZero()
+			return nil
+		}
+
+		// Existing values are reused, see [DecodeInto].
+		return valueDecoder(d, value)
+	}
+}
+
+func compileOptionalSizer(t reflect.Type, enc intEncoding) sizeFunc {
+	valueSizer := sizerFor(t.Field(0).Type, enc)
+
+	return func(v reflect.Value) (int, error) {
+		value, set := optionalFields(v)
+		if !set.Bool() {
+			return 1, nil
+		}
+
+		size, err := valueSizer(value)
+
+		return 1 + size, err
+	}
+}
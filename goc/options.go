@@ -0,0 +1,91 @@
+package goc
+
+import "errors"
+
+// Option configures a single encode or decode call.
+type Option func(*config) error
+
+var ErrOptionDuplicate = errors.New("received duplicate options")
+
+// WithVarint encodes integers as LEB128 varints instead of fixed-width little-endian bytes.
+// Unsigned integers and all string, slice and map lengths are written as unsigned varints,
+// signed integers as zigzag varints. 8-bit integers, floats and complex numbers are not affected.
+// The decoder must be called with the same option.
+func WithVarint() Option {
+	return func(cfg *config) error {
+		if cfg.withVarint {
+			return ErrOptionDuplicate
+		}
+
+		cfg.intEncoding = intVarint
+		cfg.withVarint = true
+
+		return nil
+	}
+}
+
+// WithSchemaHeader prefixes the payload with an 8-byte fingerprint of the structure of the encoded type.
+// When decoding, the fingerprint is checked against the decoded type,
+// returning a [*SchemaMismatchError] if they differ.
+// The decoder must be called with the same option.
+func WithSchemaHeader() Option {
+	return func(cfg *config) error {
+		if cfg.schemaHeader {
+			return ErrOptionDuplicate
+		}
+
+		cfg.schemaHeader = true
+
+		return nil
+	}
+}
+
+// WithCanonical writes map entries sorted by their encoded keys instead of in iteration order,
+// so equal values always produce identical bytes, for example to hash or sign payloads.
+// Values with custom encoders are responsible for their own determinism.
+// Decoding is not affected.
+func WithCanonical() Option {
+	return func(cfg *config) error {
+		if cfg.canonical {
+			return ErrOptionDuplicate
+		}
+
+		cfg.canonical = true
+
+		return nil
+	}
+}
+
+type config struct {
+	intEncoding  intEncoding
+	withVarint   bool
+	schemaHeader bool
+	canonical    bool
+	strict       bool
+	references   bool
+	limits       decodeLimits
+}
+
+func newConfig(options []Option) (config, error) {
+	// Options take a pointer to the config, which then escapes to the heap.
+	if len(options) == 0 {
+		return config{}, nil
+	}
+
+	return applyOptions(options)
+}
+
+func applyOptions(options []Option) (config, error) {
+	cfg := config{}
+	for _, option := range options {
+		if err := option(&cfg); err != nil {
+			return cfg, err
+		}
+	}
+
+	if cfg.canonical && cfg.references {
+		return cfg, errors.New("canonical encoding does not support references")
+	}
+
+	return cfg, nil
+}
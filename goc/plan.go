@@ -0,0 +1,129 @@
+package goc
+
+import (
+	"reflect"
+	"sync"
+
+	isync "github.com/samborkent/gorpc/internal/sync"
+)
+
+// Encoding, decoding and size plans are compiled once per type and integer encoding,
+// and cached for the lifetime of the program.
+// A plan is a tree of closures specialized for the type, so repeat encodes skip type analysis.
+
+type (
+	encodeFunc func(e *encodeState, v reflect.Value) error
+	decodeFunc func(d *decodeState, v reflect.Value) error
+	sizeFunc   func(v reflect.Value) (int, error)
+)
+
+type planKey struct {
+	t   reflect.Type
+	enc intEncoding
+}
+
+var (
+	encodePlans isync.Map[planKey, encodeFunc]
+	decodePlans isync.Map[planKey, decodeFunc]
+	sizePlans   isync.Map[planKey, sizeFunc]
+)
+
+// encoderFor returns the cached encoding plan for type t, compiling it if necessary.
+func encoderFor(t reflect.Type, enc intEncoding) encodeFunc {
+	key := planKey{t: t, enc: enc}
+
+	if f, ok := encodePlans.Load(key); ok {
+		return f
+	}
+
+	// Recursive types refer to their own plan while it is being compiled.
+	// Store an indirect plan that waits for compilation to finish, adapted from encoding/json.
+	var (
+		wg sync.WaitGroup
+		f  encodeFunc
+	)
+
+	wg.Add(1)
+
+	indirect, loaded := encodePlans.LoadOrStore(key, func(e *encodeState, v reflect.Value) error {
+		wg.Wait()
+		return f(e, v)
+	})
+	if loaded {
+		return indirect
+	}
+
+	f = compileEncoder(t, enc)
+	wg.Done()
+	encodePlans.Store(key, f)
+
+	return f
+}
+
+// decoderFor returns the cached decoding plan for type t, compiling it if necessary.
+func decoderFor(t reflect.Type, enc intEncoding) decodeFunc {
+	key := planKey{t: t, enc: enc}
+
+	if f, ok := decodePlans.Load(key); ok {
+		return f
+	}
+
+	// See encoderFor.
+	var (
+		wg sync.WaitGroup
+		f  decodeFunc
+	)
+
+	wg.Add(1)
+
+	indirect, loaded := decodePlans.LoadOrStore(key, func(d *decodeState, v reflect.Value) error {
+		wg.Wait()
+		return f(d, v)
+	})
+	if loaded {
+		return indirect
+	}
+
+	f = compileDecoder(t, enc)
+
+	switch t.Kind() {
+	case reflect.Struct, reflect.Array, reflect.Slice, reflect.Map, reflect.Interface:
+		f = nested(f)
+	}
+
+	wg.Done()
+	decodePlans.Store(key, f)
+
+	return f
+}
+
+// sizerFor returns the cached size plan for type t, compiling it if necessary.
+func sizerFor(t reflect.Type, enc intEncoding) sizeFunc {
+	key := planKey{t: t, enc: enc}
+
+	if f, ok := sizePlans.Load(key); ok {
+		return f
+	}
+
+	// See encoderFor.
+	var (
+		wg sync.WaitGroup
+		f  sizeFunc
+	)
+
+	wg.Add(1)
+
+	indirect, loaded := sizePlans.LoadOrStore(key, func(v reflect.Value) (int, error) {
+		wg.Wait()
+		return f(v)
+	})
+	if loaded {
+		return indirect
+	}
+
+	f = compileSizer(t, enc)
+	wg.Done()
+	sizePlans.Store(key, f)
+
+	return f
+}
@@ -0,0 +1,51 @@
+package goc
+
+import "sync"
+
+// Buffers larger than this are not returned to their pool, so a single large value does not pin its memory.
+const maxPooledBufferSize = 64 << 10
+
+var (
+	// bufferPool holds scratch buffers of encode calls that write to an [io.Writer].
+	bufferPool = sync.Pool{
+		New: func() any {
+			return new([]byte)
+		},
+	}
+
+	encodeStatePool = sync.Pool{
+		New: func() any {
+			return new(encodeState)
+		},
+	}
+)
+
+func getBuffer() *[]byte {
+	return bufferPool.Get().(*[]byte)
+}
+
+func putBuffer(buf *[]byte) {
+	if cap(*buf) > maxPooledBufferSize {
+		return
+	}
+
+	*buf = (*buf)[:0]
+	bufferPool.Put(buf)
+}
+
+// getEncodeState returns a pooled encode state appending to b.
+func getEncodeState(b []byte, cfg config) *encodeState {
+	e := encodeStatePool.Get().(*encodeState)
+	e.buf = b
+	e.canonical = cfg.canonical
+	e.refs = newRefs(cfg)
+
+	return e
+}
+
+// putEncodeState returns e to its pool. The buffer of e is owned by the caller and is not retained.
+func putEncodeState(e *encodeState) {
+	e.buf = nil
+	e.refs = nil
+	encodeStatePool.Put(e)
+}
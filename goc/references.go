@@ -0,0 +1,147 @@
+package goc
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"reflect"
+	"unsafe"
+)
+
+var ErrInvalidReference = errors.New("invalid reference")
+
+// WithReferences preserves pointer identity, so shared and cyclic object graphs decode with the same sharing and cycles.
+// The first occurrence of a pointer encodes its value and assigns it the next ID,
+// later occurrences of the same pointer encode a reference to that ID instead.
+// The top-level value has ID 0, decode into a pointer to restore references to it.
+// Only pointers to the start of a value of the pointer's type are shared,
+// pointers to struct fields or slice elements decode as separate copies.
+// Values with custom encodings, including code generated by gocgen, do not take part.
+// The decoder must be called with the same option. It cannot be combined with [WithCanonical].
+func WithReferences() Option {
+	return func(cfg *config) error {
+		if cfg.references {
+			return ErrOptionDuplicate
+		}
+
+		cfg.references = true
+
+		return nil
+	}
+}
+
+// reference replaces the presence marker of a nested pointer that was encoded before,
+// and is followed by the uvarint ID of the pointer, see [WithReferences].
+const reference byte = 2
+
+// pointer identifies the value a pointer points to by its address and type.
+type pointer struct {
+	addr unsafe.Pointer
+	t    reflect.Type
+}
+
+func newRefs(cfg config) map[pointer]int {
+	if !cfg.references {
+		return nil
+	}
+
+	return make(map[pointer]int)
+}
+
+// rootPointer returns the pointer of the top-level value v.
+// Values that are not addressable cannot be referred to, but still take ID 0.
+func rootPointer(v reflect.Value) pointer {
+	if !v.CanAddr() {
+		return pointer{}
+	}
+
+	return pointer{addr: v.Addr().UnsafePointer(), t: v.Type()}
+}
+
+// trackRoot assigns ID 0 to the top-level value v if references are tracked.
+func (e *encodeState) trackRoot(v reflect.Value) {
+	if e.refs == nil {
+		return
+	}
+
+	clear(e.refs)
+	e.refs[rootPointer(v)] = 0
+}
+
+// appendPointer appends the marker of the non-nil nested pointer v, and reports whether its value must follow.
+func (e *encodeState) appendPointer(v reflect.Value) bool {
+	if e.refs != nil {
+		p := pointer{addr: v.UnsafePointer(), t: v.Type().Elem()}
+
+		if id, ok := e.refs[p]; ok {
+			e.buf = append(e.buf, reference)
+			e.buf = binary.AppendUvarint(e.buf, uint64(id))
+
+			return false
+		}
+
+		e.refs[p] = len(e.refs)
+	}
+
+	e.buf = append(e.buf, present)
+
+	return true
+}
+
+func (d *decodeState) references() bool {
+	return d.limits != nil && d.limits.references
+}
+
+// trackRoot assigns ID 0 to the top-level value v if references are tracked.
+func (d *decodeState) trackRoot(v reflect.Value) {
+	if !d.references() {
+		return
+	}
+
+	d.refs = append(d.refs[:0], rootPointer(v))
+}
+
+// trackPointer assigns the next ID to the value of the non-nil nested pointer v if references are tracked.
+func (d *decodeState) trackPointer(v reflect.Value) {
+	if d.references() {
+		d.refs = append(d.refs, pointer{addr: v.UnsafePointer(), t: v.Type().Elem()})
+	}
+}
+
+// readPointer reads the marker of a nested pointer, and the ID following a reference.
+func (d *decodeState) readPointer() (byte, uint64, error) {
+	b, err := d.read(1)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	switch marker := b[0]; {
+	case marker == absent || marker == present:
+		return marker, 0, nil
+	case marker == reference && d.references():
+		id, err := binary.ReadUvarint(d)
+		if err != nil {
+			return 0, 0, fmt.Errorf("reference: %w", err)
+		}
+
+		return marker, id, nil
+	default:
+		return 0, 0, fmt.Errorf("invalid presence marker %d", marker)
+	}
+}
+
+// setReference sets the nested pointer v to the pointer with the given ID.
+func (d *decodeState) setReference(v reflect.Value, id uint64) error {
+	if id >= uint64(len(d.refs)) {
+		return fmt.Errorf("%w: ID %d of %d decoded pointers", ErrInvalidReference, id, len(d.refs))
+	}
+
+	p := d.refs[id]
+	if p.t != v.Type().Elem() {
+		return fmt.Errorf("%w: ID %d does not refer to a %s", ErrInvalidReference, id, v.Type().Elem().String())
+	}
+
+	v.Set(reflect.NewAt(p.t, p.addr))
+
+	return nil
+}
@@ -0,0 +1,136 @@
+package goc
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"reflect"
+	"sync"
+)
+
+var ErrNotRegistered = errors.New("type not registered")
+
+// Values stored in interface-typed fields are prefixed with a 4-byte identifier of their concrete type,
+// derived from the name it was registered with. A nil interface is encoded as the zero identifier.
+const nilTypeID uint32 = 0
+
+type registry struct {
+	mu     sync.RWMutex
+	byID   map[uint32]reflect.Type
+	byType map[reflect.Type]uint32
+	names  map[uint32]string
+}
+
+var typeRegistry = registry{
+	byID:   make(map[uint32]reflect.Type),
+	byType: make(map[reflect.Type]uint32),
+	names:  make(map[uint32]string),
+}
+
+// Register records a concrete type under a name, so values of that type can be encoded in interface-typed fields.
+// Both the encoding and the decoding side must register the type under the same name.
+// Like [encoding/gob.Register], Register panics if the name or type is already registered differently,
+// or if the name collides with the identifier of another name.
+func Register[T any](name string) {
+	t := reflect.TypeFor[T]()
+
+	if t.Kind() == reflect.Interface {
+		panic(fmt.Sprintf("goc: cannot register interface type %s", t.String()))
+	}
+
+	if name == "" {
+		panic(fmt.Sprintf("goc: empty name registered for type %s", t.String()))
+	}
+
+	id := typeID(name)
+
+	typeRegistry.mu.Lock()
+	defer typeRegistry.mu.Unlock()
+
+	if registered, ok := typeRegistry.byType[t]; ok && registered != id {
+		panic(fmt.Sprintf("goc: type %s registered under both %q and %q", t.String(), typeRegistry.names[registered], name))
+	}
+
+	if registered, ok := typeRegistry.byID[id]; ok {
+		if registered != t {
+			panic(fmt.Sprintf("goc: name %q registered for both %s and %s", name, registered.String(), t.String()))
+		}
+
+		if typeRegistry.names[id] != name {
+			panic(fmt.Sprintf("goc: name %q collides with %q", name, typeRegistry.names[id]))
+		}
+
+		return
+	}
+
+	typeRegistry.byID[id] = t
+	typeRegistry.byType[t] = id
+	typeRegistry.names[id] = name
+}
+
+// isTopLevelInterface reports whether top-level values of type t are encoded like nested interface values,
+// with their type identifier or union variant index, so they can be decoded as t.
+// Top-level values of the empty interface are encoded as their dynamic type instead, so [Encode] accepts any value as an any.
+func isTopLevelInterface(t reflect.Type) bool {
+	if t.Kind() != reflect.Interface {
+		return false
+	}
+
+	_, ok := unionOf(t)
+
+	return ok || t.NumMethod() > 0
+}
+
+// errTopLevelAny is returned when decoding a top-level value into the empty interface,
+// which does not know the type of the value, see [isTopLevelInterface].
+var errTopLevelAny = fmt.Errorf("%w: top-level values of type any are encoded as their dynamic type, decode that type instead", ErrInvalidValue)
+
+// typeID hashes a registered name to its identifier on the wire.
+func typeID(name string) uint32 {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(name))
+
+	id := h.Sum32()
+	if id == nilTypeID {
+		// Keep the zero identifier reserved for nil.
+		id = 1
+	}
+
+	return id
+}
+
+func registeredID(t reflect.Type) (uint32, error) {
+	typeRegistry.mu.RLock()
+	defer typeRegistry.mu.RUnlock()
+
+	id, ok := typeRegistry.byType[t]
+	if !ok {
+		return 0, fmt.Errorf("%w: %s", ErrNotRegistered, t.String())
+	}
+
+	return id, nil
+}
+
+func registeredType(id uint32) (reflect.Type, error) {
+	typeRegistry.mu.RLock()
+	defer typeRegistry.mu.RUnlock()
+
+	t, ok := typeRegistry.byID[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown type identifier %#08x", ErrNotRegistered, id)
+	}
+
+	return t, nil
+}
+
+func registeredName(id uint32) (string, error) {
+	typeRegistry.mu.RLock()
+	defer typeRegistry.mu.RUnlock()
+
+	name, ok := typeRegistry.names[id]
+	if !ok {
+		return "", fmt.Errorf("%w: unknown type identifier %#08x", ErrNotRegistered, id)
+	}
+
+	return name, nil
+}
@@ -0,0 +1,243 @@
+package goc
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+
+	isync "github.com/samborkent/gorpc/internal/sync"
+)
+
+var ErrSchemaMismatch = errors.New("schema mismatch")
+
+// SchemaMismatchError is returned when the schema header of a payload does not match the decoded type.
+// It matches [ErrSchemaMismatch] with [errors.Is].
+type SchemaMismatchError struct {
+	// Description of the decoded type.
+	Want string
+	// Description of the encoded type, if it is known to this program.
+	// Otherwise it holds the received fingerprint.
+	Got string
+}
+
+func (e *SchemaMismatchError) Error() string {
+	return fmt.Sprintf("%s: got %s, want %s", ErrSchemaMismatch.Error(), e.Got, e.Want)
+}
+
+func (e *SchemaMismatchError) Is(target error) bool {
+	return target == ErrSchemaMismatch
+}
+
+// schema is the structural description of a type, and its fingerprint.
+type schema struct {
+	description string
+	fingerprint uint64
+}
+
+var (
+	schemas isync.Map[planKey, schema]
+	// Descriptions of all fingerprints computed by this program, used to describe mismatches.
+	knownFingerprints isync.Map[uint64, string]
+)
+
+// schemaFor returns the schema of type t encoded with enc.
+// Top-level pointers are not encoded, so they are not part of the schema either.
+func schemaFor(t reflect.Type, enc intEncoding) schema {
+	for t.Kind() == reflect.Pointer && t.Elem() != t {
+		t = t.Elem()
+	}
+
+	key := planKey{t: t, enc: enc}
+
+	if s, ok := schemas.Load(key); ok {
+		return s
+	}
+
+	var b strings.Builder
+
+	if enc == intVarint {
+		b.WriteString("varint ")
+	}
+
+	describe(&b, t, enc, nil)
+
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(b.String()))
+
+	s := schema{
+		description: b.String(),
+		fingerprint: h.Sum64(),
+	}
+
+	schemas.Store(key, s)
+	knownFingerprints.Store(s.fingerprint, s.description)
+
+	return s
+}
+
+// describe writes the structural description of type t: the kinds, order and nesting of everything that is encoded,
+// and changes of integer encoding. Type and field names are left out,
+// except for types with dedicated or custom encodings.
+// Recursive references to an enclosing struct or union are written as ^n, where n counts the enclosing structs and unions.
+func describe(b *strings.Builder, t reflect.Type, enc intEncoding, structs []reflect.Type) {
+	// Types with dedicated or custom encodings have no structure to describe, so they are described by name.
+	if _, ok := stdlibCodecs[t]; ok {
+		b.WriteString(t.String())
+		return
+	}
+
+	if customEncodingOf(t) != customNone {
+		b.WriteString("custom " + t.String())
+		return
+	}
+
+	if isOptional(t) {
+		b.WriteByte('?')
+		describe(b, t.Field(0).Type, enc, structs)
+
+		return
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		b.WriteByte('*')
+		describe(b, t.Elem(), enc, structs)
+	case reflect.Array:
+		// Byte arrays have no length prefix.
+		if isByteArray(t) {
+			b.WriteString("[" + strconv.Itoa(t.Len()) + "]byte")
+			return
+		}
+
+		b.WriteString("[" + strconv.Itoa(t.Len()) + "]")
+		describe(b, t.Elem(), enc, structs)
+	case reflect.Slice:
+		b.WriteString("[]")
+		describe(b, t.Elem(), enc, structs)
+	case reflect.Map:
+		b.WriteString("map[")
+		describe(b, t.Key(), enc, structs)
+		b.WriteByte(']')
+		describe(b, t.Elem(), enc, structs)
+	case reflect.Interface:
+		u, ok := unionOf(t)
+		if !ok {
+			// Concrete types are identified by the registry.
+			b.WriteString("interface")
+			return
+		}
+
+		// Variants can refer back to the union, like structs.
+		for i, enclosing := range structs {
+			if enclosing == t {
+				b.WriteString("^" + strconv.Itoa(len(structs)-i))
+				return
+			}
+		}
+
+		structs = append(structs, t)
+
+		b.WriteString("union{")
+
+		for i, variant := range u.variants {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+
+			describe(b, variant, enc, structs)
+		}
+
+		b.WriteByte('}')
+	case reflect.Struct:
+		for i, enclosing := range structs {
+			if enclosing == t {
+				b.WriteString("^" + strconv.Itoa(len(structs)-i))
+				return
+			}
+		}
+
+		structs = append(structs, t)
+		st := cachedStruct(t)
+
+		b.WriteString("struct{")
+
+		for i, field := range st.fields {
+			if i > 0 {
+				b.WriteString("; ")
+			}
+
+			// The fields promoted through an embedded pointer follow it.
+			if field.embedded {
+				b.WriteString("embedded")
+				continue
+			}
+
+			if field.number != 0 {
+				b.WriteString(strconv.FormatUint(field.number, 10) + ":")
+			}
+
+			if field.omitEmpty {
+				b.WriteString("omitempty ")
+			}
+
+			fieldEnc := field.intEncoding(enc)
+			if fieldEnc != enc {
+				if fieldEnc == intVarint {
+					b.WriteString("varint ")
+				} else {
+					b.WriteString("fixed ")
+				}
+			}
+
+			describe(b, field.typ, fieldEnc, structs)
+		}
+
+		b.WriteByte('}')
+	default:
+		b.WriteString(t.Kind().String())
+	}
+}
+
+// appendSchemaHeader appends the fingerprint of type t.
+func appendSchemaHeader(b []byte, t reflect.Type, enc intEncoding) []byte {
+	return binary.LittleEndian.AppendUint64(b, schemaFor(t, enc).fingerprint)
+}
+
+// writeSchemaHeader writes the fingerprint of type t.
+func writeSchemaHeader(w io.Writer, t reflect.Type, enc intEncoding) error {
+	if _, err := w.Write(appendSchemaHeader(nil, t, enc)); err != nil {
+		return fmt.Errorf("writing schema header: %w", err)
+	}
+
+	return nil
+}
+
+// readSchemaHeader reads a fingerprint and checks it against type t.
+func readSchemaHeader(r io.Reader, t reflect.Type, enc intEncoding) error {
+	var b [8]byte
+
+	if _, err := io.ReadFull(r, b[:]); err == io.EOF {
+		return fmt.Errorf("reading schema header: %w", io.ErrUnexpectedEOF)
+	} else if err != nil {
+		return fmt.Errorf("reading schema header: %w", err)
+	}
+
+	want := schemaFor(t, enc)
+
+	fingerprint := binary.LittleEndian.Uint64(b[:])
+	if fingerprint == want.fingerprint {
+		return nil
+	}
+
+	got, ok := knownFingerprints.Load(fingerprint)
+	if !ok {
+		got = fmt.Sprintf("unknown type with fingerprint %016x", fingerprint)
+	}
+
+	return &SchemaMismatchError{Want: want.description, Got: got}
+}
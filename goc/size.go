@@ -1,58 +1,466 @@
 package goc
 
-import "reflect"
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+)
+
+// Size of the schema header, see [WithSchemaHeader].
+const schemaHeaderSize = 8
+
+// Sizer is implemented by types with a custom encoding that can report its size without encoding.
+// Values with a custom encoding that do not implement Sizer are encoded to determine their size.
+type Sizer interface {
+	// EncodedSize returns the number of bytes written by the EncodeTo, Encode or MarshalBinary method of the type.
+	EncodedSize() int
+}
+
+// SizeOf returns the exact number of bytes [Encode] produces for val with the same options.
+func SizeOf[T any](val T, options ...Option) (int, error) {
+	cfg, err := newConfig(options)
+	if err != nil {
+		return 0, err
+	}
+
+	return sizeOf(val, cfg)
+}
+
+// sizeOf mirrors [appendEncoded].
+func sizeOf[T any](val T, cfg config) (int, error) {
+	size := 0
+
+	// Values of interface types other than any are encoded like nested ones, see [appendEncoded].
+	if isTopLevelInterface(reflect.TypeFor[T]()) {
+		if cfg.schemaHeader {
+			size += schemaHeaderSize
+		}
+
+		n, err := valueSize(reflect.ValueOf(&val).Elem(), cfg)
+
+		return size + n, err
+	}
+
+	boxed := any(val)
+
+	if cfg.schemaHeader {
+		if reflect.TypeOf(boxed) == nil {
+			return 0, ErrInvalidValue
+		}
+
+		size += schemaHeaderSize
+	}
+
+	if hasDedicatedEncoding(reflect.TypeOf(boxed)) {
+		n, err := valueSize(reflect.ValueOf(boxed), cfg)
+		return size + n, err
+	}
+
+	if hasEncodingMethod(boxed) {
+		n, err := unframedSize(boxed)
+		return size + n, err
+	}
+
+	switch reflect.TypeFor[T]().Kind() {
+	case reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if cfg.intEncoding == intVarint {
+			break
+		}
+
+		fallthrough
+	case reflect.Bool,
+		reflect.Int8,
+		reflect.Uint8,
+		reflect.Float32, reflect.Float64,
+		reflect.Complex64, reflect.Complex128:
+		return size + int(reflect.TypeFor[T]().Size()), nil
+	case reflect.String:
+		// Top-level strings are not length-prefixed.
+		return size + reflect.ValueOf(boxed).Len(), nil
+	}
+
+	n, err := valueSize(reflect.ValueOf(boxed), cfg)
+
+	return size + n, err
+}
+
+// Size returns the exact number of bytes [EncodeValue] produces for v with the same options, or 0 if v cannot be encoded.
+func Size(v reflect.Value, options ...Option) int {
+	cfg, err := newConfig(options)
+	if err != nil {
+		return 0
+	}
 
-// Size returns the encoded size in bytes of a [reflect.Value].
-func Size(v reflect.Value) int {
 	if !v.IsValid() {
 		return 0
 	}
 
-	indirections, err := numIndirections(v.Type())
+	size := 0
+	if cfg.schemaHeader {
+		size += schemaHeaderSize
+	}
+
+	var n int
+
+	if t := v.Type(); !hasDedicatedEncoding(t) && (t.Implements(reflectEncodeWriter) || t.Implements(reflectEncoder) || t.Implements(reflectBinaryMarshaller)) {
+		n, err = unframedSize(v.Interface())
+	} else {
+		n, err = valueSize(v, cfg)
+	}
+
 	if err != nil {
 		return 0
 	}
 
-	for range indirections {
-		v = reflect.Indirect(v)
+	return size + n
+}
+
+// valueSize mirrors [appendValue].
+func valueSize(v reflect.Value, cfg config) (int, error) {
+	if !v.IsValid() {
+		return 0, ErrInvalidValue
 	}
 
-	switch v.Kind() {
-	case reflect.Bool,
-		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
-		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
-		reflect.Float32, reflect.Float64,
-		reflect.Complex64, reflect.Complex128:
-		return int(v.Type().Size())
-	case reflect.Array, reflect.Slice:
-		size := 4
+	// Sizes depend on which pointers were encoded before, so values with references are encoded to size them.
+	if cfg.references {
+		b, err := appendValue(nil, v, cfg)
+		return len(b), err
+	}
+
+	v, err := indirectValue(v, false)
+	if err != nil {
+		return 0, err
+	}
+
+	return sizerFor(v.Type(), cfg.intEncoding)(v)
+}
+
+// hasEncodingMethod reports whether a top-level value is encoded through one of its own methods.
+func hasEncodingMethod(val any) bool {
+	switch val.(type) {
+	case EncodeWriter, Encoder, encoding.BinaryMarshaler:
+		return true
+	default:
+		return false
+	}
+}
+
+func isSizer(val any) bool {
+	_, ok := val.(Sizer)
+	return ok
+}
 
-		for i := range v.Len() {
-			size += Size(v.Index(i))
+// unframedSize returns the size of a value encoded through one of its own methods, without a length prefix.
+func unframedSize(val any) (int, error) {
+	if sizer, ok := val.(Sizer); ok {
+		return sizer.EncodedSize(), nil
+	}
+
+	switch encoder := val.(type) {
+	case EncodeWriter:
+		var w countingWriter
+
+		if err := encoder.EncodeTo(&w); err != nil {
+			return 0, fmt.Errorf("EncodeWriter: %w", err)
 		}
 
-		return size
-	case reflect.Map:
-		size := 4
-		iter := v.MapRange()
+		return int(w), nil
+	case Encoder:
+		encoded, err := encoder.Encode()
+		if err != nil {
+			return 0, fmt.Errorf("Encoder: %w", err)
+		}
+
+		return len(encoded), nil
+	case encoding.BinaryMarshaler:
+		encoded, err := encoder.MarshalBinary()
+		if err != nil {
+			return 0, fmt.Errorf("BinaryMarshaler: %w", err)
+		}
+
+		return len(encoded), nil
+	default:
+		return 0, fmt.Errorf("type %T has no encoding method", val)
+	}
+}
+
+// countingWriter counts the bytes written to it.
+type countingWriter int
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	*w += countingWriter(len(p))
+	return len(p), nil
+}
+
+// compileSizer mirrors [compileEncoder].
+func compileSizer(t reflect.Type, enc intEncoding) sizeFunc {
+	if _, ok := stdlibCodecs[t]; ok {
+		// Standard library values are small, they are sized by encoding them.
+		encoder := encoderFor(t, enc)
 
-		for iter.Next() {
-			size += Size(iter.Key())
-			size += Size(iter.Value())
+		return func(v reflect.Value) (int, error) {
+			e := getEncodeState(nil, config{})
+			defer putEncodeState(e)
+
+			if err := encoder(e, v); err != nil {
+				return 0, err
+			}
+
+			return len(e.buf), nil
 		}
+	}
+
+	if c, ok := codecOf(t); ok {
+		return compileCodecSizer(t, c, enc)
+	}
 
-		return size
+	if custom := customEncodingOf(t); custom != customNone {
+		return func(v reflect.Value) (int, error) {
+			size, err := unframedSize(addressable(v).Interface())
+			if err != nil || custom == customStream {
+				return size, err
+			}
+
+			return lenSize(size, enc) + size, nil
+		}
+	}
+
+	if isOptional(t) {
+		return compileOptionalSizer(t, enc)
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		if _, err := numIndirections(t); err != nil {
+			return func(reflect.Value) (int, error) {
+				return 0, err
+			}
+		}
+
+		elemSizer := sizerFor(t.Elem(), enc)
+
+		return func(v reflect.Value) (int, error) {
+			if v.IsNil() {
+				return 1, nil
+			}
+
+			size, err := elemSizer(v.Elem())
+
+			return 1 + size, err
+		}
+	case reflect.Bool, reflect.Int8, reflect.Uint8,
+		reflect.Float32, reflect.Float64, reflect.Complex64, reflect.Complex128:
+		return fixedSizer(int(t.Size()))
+	case reflect.Int, reflect.Int16, reflect.Int32, reflect.Int64:
+		if enc == intVarint {
+			return func(v reflect.Value) (int, error) {
+				return varintSize(v.Int()), nil
+			}
+		}
+
+		if t.Kind() == reflect.Int {
+			// Size header, see compileIntHeaderEncoder.
+			return fixedSizer(1 + int(t.Size()))
+		}
+
+		return fixedSizer(int(t.Size()))
+	case reflect.Uint, reflect.Uintptr, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if enc == intVarint {
+			return func(v reflect.Value) (int, error) {
+				return uvarintSize(v.Uint()), nil
+			}
+		}
+
+		if t.Kind() == reflect.Uint || t.Kind() == reflect.Uintptr {
+			return fixedSizer(1 + int(t.Size()))
+		}
+
+		return fixedSizer(int(t.Size()))
 	case reflect.String:
-		return 4 + v.Len()
+		return func(v reflect.Value) (int, error) {
+			return lenSize(v.Len(), enc) + v.Len(), nil
+		}
 	case reflect.Struct:
-		size := 0
+		return compileStructSizer(t, enc)
+	case reflect.Array, reflect.Slice:
+		if isByteArray(t) {
+			return func(v reflect.Value) (int, error) {
+				return v.Len(), nil
+			}
+		}
+
+		if size, ok := bulkElemSize(t.Elem(), enc); ok {
+			return func(v reflect.Value) (int, error) {
+				return lenSize(v.Len(), enc) + v.Len()*size, nil
+			}
+		}
+
+		elemSizer := sizerFor(t.Elem(), enc)
+
+		return func(v reflect.Value) (int, error) {
+			size := lenSize(v.Len(), enc)
+
+			for i := range v.Len() {
+				n, err := elemSizer(v.Index(i))
+				if err != nil {
+					return 0, fmt.Errorf("sizing %s index %d of type %s: %w", v.Kind().String(), i, t.Elem().String(), err)
+				}
+
+				size += n
+			}
+
+			return size, nil
+		}
+	case reflect.Interface:
+		return func(v reflect.Value) (int, error) {
+			if u, ok := unionOf(t); ok {
+				return u.size(t, v, enc)
+			}
+
+			// Registered type identifier.
+			if v.IsNil() {
+				return 4, nil
+			}
+
+			concrete := v.Elem()
+
+			if _, err := registeredID(concrete.Type()); err != nil {
+				return 0, fmt.Errorf("sizing %s: %w", t.String(), err)
+			}
+
+			size, err := sizerFor(concrete.Type(), enc)(concrete)
 
-		for i := range v.NumField() {
-			size += Size(v.Field(i))
+			return 4 + size, err
 		}
+	case reflect.Map:
+		keySizer := sizerFor(t.Key(), enc)
+		valueSizer := sizerFor(t.Elem(), enc)
+
+		return func(v reflect.Value) (int, error) {
+			size := lenSize(v.Len(), enc)
+
+			if v.Len() == 0 {
+				return size, nil
+			}
+
+			// Reuse key and value to avoid allocating on every iteration.
+			key := reflect.New(t.Key()).Elem()
+			value := reflect.New(t.Elem()).Elem()
+			iter := v.MapRange()
+
+			for iter.Next() {
+				key.SetIterKey(iter)
 
-		return size
+				n, err := keySizer(key)
+				if err != nil {
+					return 0, fmt.Errorf("sizing map key: %w", err)
+				}
+
+				size += n
+
+				value.SetIterValue(iter)
+
+				n, err = valueSizer(value)
+				if err != nil {
+					return 0, fmt.Errorf("sizing map value: %w", err)
+				}
+
+				size += n
+			}
+
+			return size, nil
+		}
 	default:
-		return 0
+		return func(reflect.Value) (int, error) {
+			return 0, fmt.Errorf("encoding of type %s is not supported", t.String())
+		}
+	}
+}
+
+func fixedSizer(size int) sizeFunc {
+	return func(reflect.Value) (int, error) {
+		return size, nil
+	}
+}
+
+type fieldSizer struct {
+	structField
+
+	sizer sizeFunc
+}
+
+// compileStructSizer mirrors [compileStructEncoder] and [compileNumberedStructEncoder].
+func compileStructSizer(t reflect.Type, enc intEncoding) sizeFunc {
+	st := cachedStruct(t)
+	if st.err != nil {
+		return func(reflect.Value) (int, error) {
+			return 0, st.err
+		}
+	}
+
+	sizers := make([]fieldSizer, len(st.fields))
+
+	for i, field := range st.fields {
+		sizers[i] = fieldSizer{
+			structField: field,
+			sizer:       sizerFor(field.typ, field.intEncoding(enc)),
+		}
+	}
+
+	return func(v reflect.Value) (int, error) {
+		size := 0
+
+		for i := 0; i < len(sizers); i++ {
+			field := sizers[i]
+
+			value, ok := fieldValue(v, field.index)
+			if !ok {
+				// Numbered fields promoted through a nil embedded pointer are left out.
+				continue
+			}
+
+			if field.embedded {
+				size++
+
+				if value.IsNil() {
+					i += field.promoted
+				}
+
+				continue
+			}
+
+			if field.omitEmpty {
+				empty := isEmptyValue(value)
+
+				// Numbered fields leave out empty values, other fields are preceded by a presence marker.
+				if !st.numbered {
+					size++
+				}
+
+				if empty {
+					continue
+				}
+			}
+
+			n, err := field.sizer(value)
+			if err != nil {
+				return 0, fmt.Errorf("sizing struct field %s of type %s: %w", field.name, field.typ.String(), err)
+			}
+
+			if st.numbered {
+				// Field number and length of the value.
+				n += uvarintSize(field.number) + uvarintSize(uint64(n))
+			}
+
+			size += n
+		}
+
+		if st.numbered {
+			// Terminating field number.
+			size++
+		}
+
+		return size, nil
 	}
 }
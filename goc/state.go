@@ -0,0 +1,267 @@
+package goc
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+)
+
+// encodeState accumulates the encoded bytes of a single value.
+type encodeState struct {
+	buf []byte
+	// Set to write map entries in canonical order, see [WithCanonical].
+	canonical bool
+	// IDs of the values of encoded pointers, nil unless references are tracked, see [WithReferences].
+	refs map[pointer]int
+}
+
+// Write implements [io.Writer] for values with custom encoders.
+func (e *encodeState) Write(p []byte) (int, error) {
+	e.buf = append(e.buf, p...)
+	return len(p), nil
+}
+
+// appendLen appends a string, slice, array or map length.
+func (e *encodeState) appendLen(length int, enc intEncoding) error {
+	if length > math.MaxInt32 {
+		return fmt.Errorf("maximum length of %d exceeded", math.MaxInt32)
+	}
+
+	if enc == intVarint {
+		e.buf = binary.AppendUvarint(e.buf, uint64(length))
+		return nil
+	}
+
+	e.buf = binary.LittleEndian.AppendUint32(e.buf, uint32(length))
+
+	return nil
+}
+
+// insertLen inserts the uvarint length of the bytes appended since start before them.
+func (e *encodeState) insertLen(start int) {
+	var scratch [binary.MaxVarintLen64]byte
+
+	e.buf = slices.Insert(e.buf, start, binary.AppendUvarint(scratch[:0], uint64(len(e.buf)-start))...)
+}
+
+// appendString appends a length-prefixed string.
+func (e *encodeState) appendString(s string, enc intEncoding) error {
+	if err := e.appendLen(len(s), enc); err != nil {
+		return err
+	}
+
+	e.buf = append(e.buf, s...)
+
+	return nil
+}
+
+// decodeState reads the encoded bytes of a single value.
+type decodeState struct {
+	r          io.Reader
+	byteReader io.ByteReader
+	// Number of bytes read.
+	offset int
+
+	// Scratch space for fixed-size values.
+	scratch [16]byte
+	// Reusable buffer for strings.
+	buf []byte
+
+	// Limits of the decode call, nil if there are none.
+	limits *limitReader
+	// Values of decoded pointers by ID, if references are tracked.
+	refs []pointer
+}
+
+func newDecodeState(r io.Reader) *decodeState {
+	d := &decodeState{r: r, limits: limitsOf(r)}
+
+	if byteReader, ok := r.(io.ByteReader); ok {
+		d.byteReader = byteReader
+	} else {
+		d.byteReader = singleByteReader{r}
+	}
+
+	return d
+}
+
+// Read implements [io.Reader] for values with custom decoders.
+func (d *decodeState) Read(p []byte) (int, error) {
+	n, err := d.r.Read(p)
+	d.offset += n
+
+	return n, err
+}
+
+// ReadByte implements [io.ByteReader] for varints.
+func (d *decodeState) ReadByte() (byte, error) {
+	b, err := d.byteReader.ReadByte()
+	if err == io.EOF {
+		return 0, io.ErrUnexpectedEOF
+	} else if err != nil {
+		return 0, err
+	}
+
+	d.offset++
+
+	return b, nil
+}
+
+// read reads exactly n bytes, n must not exceed the scratch space.
+// The returned slice is only valid until the next read.
+func (d *decodeState) read(n int) ([]byte, error) {
+	b := d.scratch[:n]
+
+	if err := d.readFull(b); err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
+
+// readBytes reads exactly n bytes into the reusable buffer.
+// The returned slice is only valid until the next call to readBytes.
+func (d *decodeState) readBytes(n int) ([]byte, error) {
+	if err := d.reserve(n); err != nil {
+		return nil, err
+	}
+
+	b, err := ReadAppend(d, d.buf[:0], n)
+	d.buf = b[:0]
+
+	if err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
+
+// readFull reads exactly len(b) bytes into b, see [ReadFull].
+func (d *decodeState) readFull(b []byte) error {
+	read, err := io.ReadFull(d.r, b)
+	d.offset += read
+
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+
+	return err
+}
+
+// ReadFull reads exactly len(b) bytes from r into b.
+// It is only called within a value, so running out of input is always an [io.ErrUnexpectedEOF].
+// It is used by code generated by gocgen.
+func ReadFull(r io.Reader, b []byte) error {
+	if _, err := io.ReadFull(r, b); err == io.EOF {
+		return io.ErrUnexpectedEOF
+	} else if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ReadAppend reads exactly n bytes from r and appends them to b.
+// b grows as the bytes are read, so a length prefix alone cannot force a large allocation.
+// It is used by code generated by gocgen.
+func ReadAppend(r io.Reader, b []byte, n int) ([]byte, error) {
+	for n > 0 {
+		chunk := min(n, max(len(b), maxPreallocBytes))
+		b = slices.Grow(b, chunk)
+
+		if err := ReadFull(r, b[len(b):len(b)+chunk]); err != nil {
+			return b, err
+		}
+
+		b = b[:len(b)+chunk]
+		n -= chunk
+	}
+
+	return b, nil
+}
+
+// skip discards exactly n bytes.
+func (d *decodeState) skip(n int) error {
+	skipped, err := io.CopyN(io.Discard, d.r, int64(n))
+	d.offset += int(skipped)
+
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+
+	return err
+}
+
+// readString reads a length-prefixed string.
+func (d *decodeState) readString(enc intEncoding) (string, error) {
+	length, err := d.readLen(enc)
+	if err != nil {
+		return "", fmt.Errorf("length: %w", err)
+	}
+
+	if err := d.checkStringLength(length); err != nil {
+		return "", err
+	}
+
+	if length == 0 {
+		return "", nil
+	}
+
+	b, err := d.readBytes(length)
+	if err != nil {
+		return "", err
+	}
+
+	if err := d.checkUTF8(b); err != nil {
+		return "", err
+	}
+
+	return string(b), nil
+}
+
+// readPresence reads a presence marker.
+func (d *decodeState) readPresence() (bool, error) {
+	b, err := d.read(1)
+	if err != nil {
+		return false, err
+	}
+
+	switch b[0] {
+	case absent:
+		return false, nil
+	case present:
+		return true, nil
+	default:
+		return false, fmt.Errorf("invalid presence marker %d", b[0])
+	}
+}
+
+// readLen reads a string, slice, array or map length.
+func (d *decodeState) readLen(enc intEncoding) (int, error) {
+	if enc == intVarint {
+		length, err := binary.ReadUvarint(d)
+		if err != nil {
+			return 0, err
+		}
+
+		if length > math.MaxInt32 {
+			return 0, fmt.Errorf("maximum length of %d exceeded", math.MaxInt32)
+		}
+
+		return int(length), nil
+	}
+
+	b, err := d.read(4)
+	if err != nil {
+		return 0, err
+	}
+
+	length := decodeUint32(b)
+	if length > math.MaxInt32 {
+		return 0, fmt.Errorf("maximum length of %d exceeded", math.MaxInt32)
+	}
+
+	return int(length), nil
+}
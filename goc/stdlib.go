@@ -0,0 +1,440 @@
+package goc
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"net/netip"
+	"net/url"
+	"reflect"
+	"sync/atomic"
+	"time"
+
+	isync "github.com/samborkent/gorpc/internal/sync"
+)
+
+// Standard library types with dedicated encodings, used both top-level and nested inside other values.
+// Their encodings do not depend on their internal representation, so they are stable across Go versions.
+// Other standard library types with exported fields, such as time.Duration, are encoded by their kind.
+var stdlibCodecs = map[reflect.Type]struct {
+	encode func(e *encodeState, v reflect.Value, enc intEncoding) error
+	decode func(d *decodeState, v reflect.Value, enc intEncoding) error
+}{
+	reflect.TypeFor[time.Time]():    {encodeTime, decodeTime},
+	reflect.TypeFor[big.Int]():      {encodeBigInt, decodeBigInt},
+	reflect.TypeFor[big.Rat]():      {encodeBigRat, decodeBigRat},
+	reflect.TypeFor[netip.Addr]():   {encodeAddr, decodeAddr},
+	reflect.TypeFor[netip.Prefix](): {encodePrefix, decodePrefix},
+	reflect.TypeFor[url.URL]():      {encodeURL, decodeURL},
+}
+
+// hasDedicatedEncoding reports whether t, after dereferencing top-level pointers,
+// has a dedicated encoding from the standard library or [RegisterCodec].
+func hasDedicatedEncoding(t reflect.Type) bool {
+	if t == nil {
+		return false
+	}
+
+	for t.Kind() == reflect.Pointer && t.Elem() != t {
+		t = t.Elem()
+	}
+
+	if _, ok := stdlibCodecs[t]; ok {
+		return true
+	}
+
+	_, ok := codecs.Load(t)
+
+	return ok
+}
+
+func compileStdlibEncoder(t reflect.Type, enc intEncoding) (encodeFunc, bool) {
+	codec, ok := stdlibCodecs[t]
+	if !ok {
+		return nil, false
+	}
+
+	return func(e *encodeState, v reflect.Value) error {
+		if err := codec.encode(e, v, enc); err != nil {
+			return fmt.Errorf("encoding %s: %w", t.String(), err)
+		}
+
+		return nil
+	}, true
+}
+
+func compileStdlibDecoder(t reflect.Type, enc intEncoding) (decodeFunc, bool) {
+	codec, ok := stdlibCodecs[t]
+	if !ok {
+		return nil, false
+	}
+
+	return func(d *decodeState, v reflect.Value) error {
+		if err := codec.decode(d, v, enc); err != nil {
+			return fmt.Errorf("decoding %s: %w", t.String(), err)
+		}
+
+		return nil
+	}, true
+}
+
+// time.Time is encoded as its Unix seconds (int64) and nanoseconds (uint32), followed by the name of its location
+// and its offset from UTC in seconds (int32). The monotonic clock reading is not encoded.
+// Locations are restored by name: UTC, Local, or any location known to [time.LoadLocation] with the same offset.
+// Other locations, including a Local location whose offset differs from that of the receiver,
+// are restored as a fixed zone with the encoded name and offset.
+func encodeTime(e *encodeState, v reflect.Value, enc intEncoding) error {
+	t, _ := reflect.TypeAssert[time.Time](v)
+	_, offset := t.Zone()
+
+	e.buf = binary.LittleEndian.AppendUint64(e.buf, uint64(t.Unix()))
+	e.buf = binary.LittleEndian.AppendUint32(e.buf, uint32(t.Nanosecond()))
+
+	if err := e.appendString(t.Location().String(), enc); err != nil {
+		return fmt.Errorf("location: %w", err)
+	}
+
+	e.buf = binary.LittleEndian.AppendUint32(e.buf, uint32(int32(offset)))
+
+	return nil
+}
+
+func decodeTime(d *decodeState, v reflect.Value, enc intEncoding) error {
+	b, err := d.read(12)
+	if err != nil {
+		return err
+	}
+
+	sec, nsec := int64(decodeUint64(b[:8])), decodeUint32(b[8:12])
+	if nsec >= uint32(time.Second) {
+		return fmt.Errorf("invalid nanoseconds %d", nsec)
+	}
+
+	name, err := d.readString(enc)
+	if err != nil {
+		return fmt.Errorf("location: %w", err)
+	}
+
+	b, err = d.read(4)
+	if err != nil {
+		return fmt.Errorf("offset: %w", err)
+	}
+
+	offset := int(int32(decodeUint32(b)))
+	t := time.Unix(sec, int64(nsec))
+
+	var loc *time.Location
+
+	switch name {
+	case "UTC":
+		loc = time.UTC
+	case "Local":
+		loc = time.Local
+	default:
+		loc = loadLocation(name)
+	}
+
+	if loc == nil {
+		loc = time.FixedZone(name, offset)
+	} else if _, locOffset := t.In(loc).Zone(); locOffset != offset {
+		loc = time.FixedZone(name, offset)
+	}
+
+	t = t.In(loc)
+
+	v.Set(reflect.ValueOf(t))
+
+	return nil
+}
+
+// maxUnknownLocations bounds the number of unknown location names cached by loadLocation,
+// since the names are chosen by the sender.
+const maxUnknownLocations = 1024
+
+var (
+	// Locations by name. The names are known to time.LoadLocation, so their number is bounded.
+	locations isync.Map[string, *time.Location]
+	// Names unknown to time.LoadLocation.
+	unknownLocations     isync.Map[string, struct{}]
+	unknownLocationCount atomic.Int32
+)
+
+// loadLocation returns the location with the given name, or nil if it is unknown to [time.LoadLocation].
+// Once the cache of unknown names is full, other unknown names are looked up every time,
+// so they cannot keep known locations from being loaded.
+func loadLocation(name string) *time.Location {
+	if loc, ok := locations.Load(name); ok {
+		return loc
+	}
+
+	if _, ok := unknownLocations.Load(name); ok {
+		return nil
+	}
+
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		if unknownLocationCount.Load() < maxUnknownLocations {
+			if _, loaded := unknownLocations.LoadOrStore(name, struct{}{}); !loaded {
+				unknownLocationCount.Add(1)
+			}
+		}
+
+		return nil
+	}
+
+	locations.Store(name, loc)
+
+	return loc
+}
+
+// Sign bytes of big.Int.
+const (
+	signPositive byte = 0
+	signNegative byte = 1
+)
+
+// big.Int is encoded as a sign byte and the length-prefixed big-endian bytes of its absolute value.
+func encodeBigInt(e *encodeState, v reflect.Value, enc intEncoding) error {
+	x, _ := reflect.TypeAssert[big.Int](v)
+
+	return e.appendBigInt(&x, enc)
+}
+
+func (e *encodeState) appendBigInt(x *big.Int, enc intEncoding) error {
+	if x.Sign() < 0 {
+		e.buf = append(e.buf, signNegative)
+	} else {
+		e.buf = append(e.buf, signPositive)
+	}
+
+	abs := x.Bytes()
+
+	if err := e.appendLen(len(abs), enc); err != nil {
+		return err
+	}
+
+	e.buf = append(e.buf, abs...)
+
+	return nil
+}
+
+func decodeBigInt(d *decodeState, v reflect.Value, enc intEncoding) error {
+	x, _ := reflect.TypeAssert[*big.Int](v.Addr())
+
+	return d.readBigInt(x, enc)
+}
+
+func (d *decodeState) readBigInt(x *big.Int, enc intEncoding) error {
+	b, err := d.read(1)
+	if err != nil {
+		return fmt.Errorf("sign: %w", err)
+	}
+
+	sign := b[0]
+	if sign != signPositive && sign != signNegative {
+		return fmt.Errorf("invalid sign byte %d", sign)
+	}
+
+	length, err := d.readLen(enc)
+	if err != nil {
+		return fmt.Errorf("length: %w", err)
+	}
+
+	abs, err := d.readBytes(length)
+	if err != nil {
+		return err
+	}
+
+	x.SetBytes(abs)
+
+	if sign == signNegative {
+		x.Neg(x)
+	}
+
+	return nil
+}
+
+// big.Rat is encoded as its numerator and denominator, both as big.Int.
+func encodeBigRat(e *encodeState, v reflect.Value, enc intEncoding) error {
+	x, _ := reflect.TypeAssert[big.Rat](v)
+
+	if err := e.appendBigInt(x.Num(), enc); err != nil {
+		return fmt.Errorf("numerator: %w", err)
+	}
+
+	if err := e.appendBigInt(x.Denom(), enc); err != nil {
+		return fmt.Errorf("denominator: %w", err)
+	}
+
+	return nil
+}
+
+func decodeBigRat(d *decodeState, v reflect.Value, enc intEncoding) error {
+	var num, denom big.Int
+
+	if err := d.readBigInt(&num, enc); err != nil {
+		return fmt.Errorf("numerator: %w", err)
+	}
+
+	if err := d.readBigInt(&denom, enc); err != nil {
+		return fmt.Errorf("denominator: %w", err)
+	}
+
+	if denom.Sign() == 0 {
+		return fmt.Errorf("zero denominator")
+	}
+
+	x, _ := reflect.TypeAssert[*big.Rat](v.Addr())
+	x.SetFrac(&num, &denom)
+
+	return nil
+}
+
+// Address families of netip.Addr.
+const (
+	addrInvalid byte = 0
+	addrIPv4    byte = 4
+	addrIPv6    byte = 6
+)
+
+// netip.Addr is encoded as its address family byte, followed by 4 bytes for IPv4,
+// or 16 bytes and the zone for IPv6. The zero Addr is encoded as a single byte.
+func encodeAddr(e *encodeState, v reflect.Value, enc intEncoding) error {
+	addr, _ := reflect.TypeAssert[netip.Addr](v)
+
+	return e.appendAddr(addr, enc)
+}
+
+func (e *encodeState) appendAddr(addr netip.Addr, enc intEncoding) error {
+	switch {
+	case !addr.IsValid():
+		e.buf = append(e.buf, addrInvalid)
+	case addr.Is4():
+		ip := addr.As4()
+		e.buf = append(e.buf, addrIPv4)
+		e.buf = append(e.buf, ip[:]...)
+	default:
+		ip := addr.As16()
+		e.buf = append(e.buf, addrIPv6)
+		e.buf = append(e.buf, ip[:]...)
+
+		if err := e.appendString(addr.Zone(), enc); err != nil {
+			return fmt.Errorf("zone: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func decodeAddr(d *decodeState, v reflect.Value, enc intEncoding) error {
+	addr, err := d.readAddr(enc)
+	if err != nil {
+		return err
+	}
+
+	v.Set(reflect.ValueOf(addr))
+
+	return nil
+}
+
+func (d *decodeState) readAddr(enc intEncoding) (netip.Addr, error) {
+	b, err := d.read(1)
+	if err != nil {
+		return netip.Addr{}, fmt.Errorf("address family: %w", err)
+	}
+
+	switch b[0] {
+	case addrInvalid:
+		return netip.Addr{}, nil
+	case addrIPv4:
+		b, err := d.read(4)
+		if err != nil {
+			return netip.Addr{}, err
+		}
+
+		return netip.AddrFrom4([4]byte(b)), nil
+	case addrIPv6:
+		b, err := d.read(16)
+		if err != nil {
+			return netip.Addr{}, err
+		}
+
+		addr := netip.AddrFrom16([16]byte(b))
+
+		zone, err := d.readString(enc)
+		if err != nil {
+			return netip.Addr{}, fmt.Errorf("zone: %w", err)
+		}
+
+		return addr.WithZone(zone), nil
+	default:
+		return netip.Addr{}, fmt.Errorf("invalid address family %d", b[0])
+	}
+}
+
+// netip.Prefix is encoded as its address and a byte holding the number of prefix bits.
+func encodePrefix(e *encodeState, v reflect.Value, enc intEncoding) error {
+	prefix, _ := reflect.TypeAssert[netip.Prefix](v)
+
+	if err := e.appendAddr(prefix.Addr(), enc); err != nil {
+		return err
+	}
+
+	// The zero Prefix has -1 bits.
+	e.buf = append(e.buf, byte(prefix.Bits()))
+
+	return nil
+}
+
+func decodePrefix(d *decodeState, v reflect.Value, enc intEncoding) error {
+	addr, err := d.readAddr(enc)
+	if err != nil {
+		return err
+	}
+
+	b, err := d.read(1)
+	if err != nil {
+		return fmt.Errorf("bits: %w", err)
+	}
+
+	bits := int(b[0])
+	if b[0] == 0xff {
+		bits = -1
+	}
+
+	var prefix netip.Prefix
+
+	if addr.IsValid() || bits != -1 {
+		prefix = netip.PrefixFrom(addr, bits)
+		if !prefix.IsValid() {
+			return fmt.Errorf("invalid prefix %s/%d", addr.String(), bits)
+		}
+	}
+
+	v.Set(reflect.ValueOf(prefix))
+
+	return nil
+}
+
+// url.URL is encoded as its string form.
+func encodeURL(e *encodeState, v reflect.Value, enc intEncoding) error {
+	u, _ := reflect.TypeAssert[url.URL](v)
+
+	return e.appendString(u.String(), enc)
+}
+
+func decodeURL(d *decodeState, v reflect.Value, enc intEncoding) error {
+	s, err := d.readString(enc)
+	if err != nil {
+		return err
+	}
+
+	u, err := url.Parse(s)
+	if err != nil {
+		return err
+	}
+
+	v.Set(reflect.ValueOf(*u))
+
+	return nil
+}
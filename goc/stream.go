@@ -0,0 +1,147 @@
+package goc
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"reflect"
+)
+
+// StreamEncoder writes a sequence of values to a stream, like [encoding/gob.Encoder].
+//
+// Every value in a stream is encoded like a struct field, so values are self-delimiting:
+// unlike with [Encode], top-level strings are prefixed with their length and custom encodings are framed.
+// Top-level pointers are dereferenced, as with [Encode].
+// A StreamEncoder is not safe for concurrent use.
+type StreamEncoder struct {
+	w   io.Writer
+	cfg config
+	e   encodeState
+
+	// Plan of the last encoded type, as streams usually repeat the same type.
+	t    reflect.Type
+	plan encodeFunc
+}
+
+// NewEncoder returns a [StreamEncoder] writing to w.
+// The same options must be passed to the [StreamDecoder] reading the stream.
+func NewEncoder(w io.Writer, options ...Option) (*StreamEncoder, error) {
+	cfg, err := newConfig(options)
+	if err != nil {
+		return nil, err
+	}
+
+	return &StreamEncoder{
+		w:   w,
+		cfg: cfg,
+		e:   encodeState{canonical: cfg.canonical, refs: newRefs(cfg)},
+	}, nil
+}
+
+// Encode writes the next value to the stream with a single write.
+func (enc *StreamEncoder) Encode(val any) error {
+	v := reflect.ValueOf(val)
+	if !v.IsValid() {
+		return ErrInvalidValue
+	}
+
+	v, err := indirectValue(v, false)
+	if err != nil {
+		return err
+	}
+
+	if v.Type() != enc.t {
+		enc.t = v.Type()
+		enc.plan = encoderFor(enc.t, enc.cfg.intEncoding)
+	}
+
+	enc.e.buf = enc.e.buf[:0]
+	enc.e.trackRoot(v)
+
+	if enc.cfg.schemaHeader {
+		enc.e.buf = appendSchemaHeader(enc.e.buf, enc.t, enc.cfg.intEncoding)
+	}
+
+	if err := enc.plan(&enc.e, v); err != nil {
+		return err
+	}
+
+	if _, err := enc.w.Write(enc.e.buf); err != nil {
+		return fmt.Errorf("writing %s: %w", enc.t.String(), err)
+	}
+
+	return nil
+}
+
+// StreamDecoder reads a sequence of values written by a [StreamEncoder], like [encoding/gob.Decoder].
+// It buffers its input, so it may read past the last value it decodes.
+// A StreamDecoder is not safe for concurrent use.
+type StreamDecoder struct {
+	r   *bufio.Reader
+	cfg config
+	d   *decodeState
+	// Limits of a single value, nil if there are none.
+	limits *limitReader
+
+	// Plan of the last decoded type, see [StreamEncoder].
+	t    reflect.Type
+	plan decodeFunc
+}
+
+// NewDecoder returns a [StreamDecoder] reading from r.
+func NewDecoder(r io.Reader, options ...Option) (*StreamDecoder, error) {
+	cfg, err := newConfig(options)
+	if err != nil {
+		return nil, err
+	}
+
+	br := bufio.NewReader(r)
+	d := newDecodeState(cfg.limit(br))
+
+	return &StreamDecoder{
+		r:      br,
+		cfg:    cfg,
+		d:      d,
+		limits: d.limits,
+	}, nil
+}
+
+// Decode reads the next value from the stream into the value pointed to by ptr.
+// It returns [io.EOF] at the end of the stream, and [io.ErrUnexpectedEOF] if the stream ends within a value.
+// Values that encode to no bytes, such as empty structs, cannot be decoded at the end of the stream.
+func (dec *StreamDecoder) Decode(ptr any) error {
+	v := reflect.ValueOf(ptr)
+	if v.Kind() != reflect.Pointer || v.IsNil() {
+		return ErrInvalidValue
+	}
+
+	// The stream ends cleanly if no bytes are left before the next value.
+	if _, err := dec.r.Peek(1); err != nil {
+		return err
+	}
+
+	v, err := indirectValue(v, true)
+	if err != nil {
+		return err
+	}
+
+	// Limits and references apply to each value in the stream.
+	if dec.limits != nil {
+		dec.limits.reset()
+	}
+
+	dec.d.trackRoot(v)
+
+	if v.Type() != dec.t {
+		dec.t = v.Type()
+		dec.plan = decoderFor(dec.t, dec.cfg.intEncoding)
+	}
+
+	if dec.cfg.schemaHeader {
+		if err := readSchemaHeader(dec.d, dec.t, dec.cfg.intEncoding); err != nil {
+			return err
+		}
+	}
+
+	return dec.plan(dec.d, v)
+}
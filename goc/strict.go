@@ -0,0 +1,129 @@
+package goc
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"unicode/utf8"
+)
+
+var (
+	ErrTrailingBytes   = errors.New("trailing bytes after decoded value")
+	ErrInvalidBool     = errors.New("invalid bool")
+	ErrInvalidUTF8     = errors.New("invalid UTF-8 string")
+	ErrDuplicateMapKey = errors.New("duplicate map key")
+	ErrInvalidIntSize  = errors.New("invalid int size")
+)
+
+// WithStrict rejects input that the encoder would not produce:
+// trailing bytes after the value, bools other than 0 and 1, strings that are not valid UTF-8 and duplicate map keys.
+// Int headers other than 4 and 8 are rejected in any mode.
+// A [StreamDecoder] does not check for trailing bytes, as they are the next value of the stream.
+// Encoding is not affected.
+func WithStrict() Option {
+	return func(cfg *config) error {
+		if cfg.strict {
+			return ErrOptionDuplicate
+		}
+
+		cfg.strict = true
+
+		return nil
+	}
+}
+
+// strictOf reports whether decoding from r is strict, see [WithStrict].
+func strictOf(r io.Reader) bool {
+	l := limitsOf(r)
+	return l != nil && l.strict
+}
+
+// CheckBool returns [ErrInvalidBool] if b is not 0 or 1 and decoding from r is strict.
+// It is used by code generated by gocgen.
+func CheckBool(r io.Reader, b byte) error {
+	if b > 1 && strictOf(r) {
+		return fmt.Errorf("%w: %d", ErrInvalidBool, b)
+	}
+
+	return nil
+}
+
+// CheckUTF8 returns [ErrInvalidUTF8] if b is not valid UTF-8 and decoding from r is strict.
+// It is used by code generated by gocgen.
+func CheckUTF8(r io.Reader, b []byte) error {
+	if strictOf(r) && !utf8.Valid(b) {
+		return ErrInvalidUTF8
+	}
+
+	return nil
+}
+
+// CheckDuplicateKey returns [ErrDuplicateMapKey] if a decoded map key already exists and decoding from r is strict.
+// It is used by code generated by gocgen.
+func CheckDuplicateKey(r io.Reader, exists bool) error {
+	if exists && strictOf(r) {
+		return ErrDuplicateMapKey
+	}
+
+	return nil
+}
+
+// checkBool checks a decoded bool, see [WithStrict].
+func (d *decodeState) checkBool(b byte) error {
+	if b > 1 && d.strict() {
+		return fmt.Errorf("%w: %d", ErrInvalidBool, b)
+	}
+
+	return nil
+}
+
+// checkBools checks a block of decoded bools, see [WithStrict].
+func (d *decodeState) checkBools(b []byte) error {
+	if !d.strict() {
+		return nil
+	}
+
+	for _, c := range b {
+		if c > 1 {
+			return fmt.Errorf("%w: %d", ErrInvalidBool, c)
+		}
+	}
+
+	return nil
+}
+
+// checkUTF8 checks a decoded string, see [WithStrict].
+func (d *decodeState) checkUTF8(b []byte) error {
+	if d.strict() && !utf8.Valid(b) {
+		return ErrInvalidUTF8
+	}
+
+	return nil
+}
+
+func (d *decodeState) strict() bool {
+	return d.limits != nil && d.limits.strict
+}
+
+// checkTrailing returns [ErrTrailingBytes] if r has input left after a strictly decoded value.
+func (cfg config) checkTrailing(r io.Reader) error {
+	if !cfg.strict {
+		return nil
+	}
+
+	// The byte limit does not apply past the value.
+	if l, ok := r.(*limitReader); ok {
+		r = l.r
+	}
+
+	var b [1]byte
+
+	n, err := io.ReadFull(r, b[:])
+	if n > 0 {
+		return ErrTrailingBytes
+	} else if err != io.EOF {
+		return err
+	}
+
+	return nil
+}
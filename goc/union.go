@@ -0,0 +1,179 @@
+package goc
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"reflect"
+
+	isync "github.com/samborkent/gorpc/internal/sync"
+)
+
+var ErrUnknownVariant = errors.New("unknown union variant")
+
+// union is the closed set of variant types of an interface type, see [RegisterUnion].
+type union struct {
+	variants []reflect.Type
+	// Indices of the variants on the wire, starting at 1.
+	indices map[reflect.Type]uint64
+}
+
+// Unions by interface type.
+var unions isync.Map[reflect.Type, *union]
+
+// RegisterUnion declares the variant types of interface type I, making it a tagged union.
+// Values of I are encoded as the uvarint index of their variant, starting at 1 in the order given,
+// followed by the value. A nil value is encoded as index 0.
+// Variants are given as values of their type, such as RegisterUnion[Result](Success{}, &NotFound{}),
+// and are encoded like any other value, including with their custom encoding.
+//
+// Unlike interfaces holding registered types, variants need no name, and the set is closed:
+// encoding a value of another type and decoding an unknown index return [ErrUnknownVariant].
+// The index is part of the wire format, so new variants must be appended and variants must not be reordered.
+// Unions must be registered before their first use, in the same order on both sides.
+//
+// Like [Register], RegisterUnion panics if I is not an interface, is already a union,
+// or if a variant is nil or given twice.
+func RegisterUnion[I any](variants ...I) {
+	t := reflect.TypeFor[I]()
+
+	if t.Kind() != reflect.Interface {
+		panic(fmt.Sprintf("goc: cannot register union of non-interface type %s", t.String()))
+	}
+
+	u := &union{
+		variants: make([]reflect.Type, len(variants)),
+		indices:  make(map[reflect.Type]uint64, len(variants)),
+	}
+
+	names := make(map[string]bool, len(variants))
+
+	for i, variant := range variants {
+		v := reflect.ValueOf(&variant).Elem()
+		if v.IsNil() {
+			panic(fmt.Sprintf("goc: nil variant %d of union %s", i+1, t.String()))
+		}
+
+		// Variants are told apart by their type name in JSON, see [ToJSON].
+		variantType := v.Elem().Type()
+		if names[variantType.String()] {
+			panic(fmt.Sprintf("goc: variant %s given twice for union %s", variantType.String(), t.String()))
+		}
+
+		names[variantType.String()] = true
+		u.variants[i] = variantType
+		u.indices[variantType] = uint64(i + 1)
+	}
+
+	if _, loaded := unions.LoadOrStore(t, u); loaded {
+		panic(fmt.Sprintf("goc: union %s registered twice", t.String()))
+	}
+}
+
+// Variants returns the variant types of union I in index order, or nil if I is not a union.
+// Tests can compare it with the cases of a type switch over I to keep the switch exhaustive.
+func Variants[I any]() []reflect.Type {
+	u, ok := unions.Load(reflect.TypeFor[I]())
+	if !ok {
+		return nil
+	}
+
+	return append([]reflect.Type(nil), u.variants...)
+}
+
+// unionOf returns the union of interface type t, if it is one.
+func unionOf(t reflect.Type) (*union, bool) {
+	return unions.Load(t)
+}
+
+// index returns the index of the variant type t.
+func (u *union) index(t reflect.Type) (uint64, error) {
+	index, ok := u.indices[t]
+	if !ok {
+		return 0, fmt.Errorf("%w: %s", ErrUnknownVariant, t.String())
+	}
+
+	return index, nil
+}
+
+// variant returns the variant type with the given non-zero index.
+func (u *union) variant(index uint64) (reflect.Type, error) {
+	if index > uint64(len(u.variants)) {
+		return nil, fmt.Errorf("%w: index %d of %d", ErrUnknownVariant, index, len(u.variants))
+	}
+
+	return u.variants[index-1], nil
+}
+
+// variantNamed returns the variant type with the given name and its index.
+func (u *union) variantNamed(name string) (reflect.Type, uint64, error) {
+	for i, variant := range u.variants {
+		if variant.String() == name {
+			return variant, uint64(i + 1), nil
+		}
+	}
+
+	return nil, 0, fmt.Errorf("%w: %s", ErrUnknownVariant, name)
+}
+
+func (u *union) encode(e *encodeState, t reflect.Type, v reflect.Value, enc intEncoding) error {
+	if v.IsNil() {
+		e.buf = append(e.buf, 0)
+		return nil
+	}
+
+	concrete := v.Elem()
+
+	index, err := u.index(concrete.Type())
+	if err != nil {
+		return fmt.Errorf("encoding %s: %w", t.String(), err)
+	}
+
+	e.buf = binary.AppendUvarint(e.buf, index)
+
+	return encoderFor(concrete.Type(), enc)(e, concrete)
+}
+
+func (u *union) decode(d *decodeState, t reflect.Type, v reflect.Value, enc intEncoding) error {
+	index, err := binary.ReadUvarint(d)
+	if err != nil {
+		return fmt.Errorf("decoding %s variant index: %w", t.String(), err)
+	}
+
+	if index == 0 {
+		v.SetZero()
+		return nil
+	}
+
+	variant, err := u.variant(index)
+	if err != nil {
+		return fmt.Errorf("decoding %s: %w", t.String(), err)
+	}
+
+	concrete := reflect.New(variant).Elem()
+
+	if err := decoderFor(variant, enc)(d, concrete); err != nil {
+		return fmt.Errorf("decoding %s variant %s: %w", t.String(), variant.String(), err)
+	}
+
+	v.Set(concrete)
+
+	return nil
+}
+
+func (u *union) size(t reflect.Type, v reflect.Value, enc intEncoding) (int, error) {
+	if v.IsNil() {
+		return 1, nil
+	}
+
+	concrete := v.Elem()
+
+	index, err := u.index(concrete.Type())
+	if err != nil {
+		return 0, fmt.Errorf("sizing %s: %w", t.String(), err)
+	}
+
+	size, err := sizerFor(concrete.Type(), enc)(concrete)
+
+	return uvarintSize(index) + size, err
+}
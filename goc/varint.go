@@ -0,0 +1,96 @@
+package goc
+
+import (
+	"encoding/binary"
+	"io"
+	"math/bits"
+)
+
+// intEncoding selects how integers and lengths are written on the wire.
+type intEncoding uint8
+
+const (
+	// Fixed-width little-endian integers, 4-byte lengths.
+	intFixed intEncoding = iota
+	// LEB128 varints, zigzag for signed integers.
+	intVarint
+)
+
+// ReadUvarint reads an unsigned varint as written with [WithVarint] from r.
+// It is used by code generated by gocgen.
+func ReadUvarint(r io.Reader) (uint64, error) {
+	v, err := decodeUvarint(r)
+	if err == io.EOF {
+		return 0, io.ErrUnexpectedEOF
+	}
+
+	return v, err
+}
+
+// ReadVarint reads a zigzag varint as written with [WithVarint] from r.
+// It is used by code generated by gocgen.
+func ReadVarint(r io.Reader) (int64, error) {
+	v, err := decodeVarint(r)
+	if err == io.EOF {
+		return 0, io.ErrUnexpectedEOF
+	}
+
+	return v, err
+}
+
+func decodeUvarint(r io.Reader) (uint64, error) {
+	byteReader, ok := r.(io.ByteReader)
+	if !ok {
+		byteReader = singleByteReader{r}
+	}
+
+	return binary.ReadUvarint(byteReader)
+}
+
+func decodeVarint(r io.Reader) (int64, error) {
+	byteReader, ok := r.(io.ByteReader)
+	if !ok {
+		byteReader = singleByteReader{r}
+	}
+
+	return binary.ReadVarint(byteReader)
+}
+
+// singleByteReader implements [io.ByteReader] for readers that do not, without reading ahead.
+type singleByteReader struct {
+	io.Reader
+}
+
+func (r singleByteReader) ReadByte() (byte, error) {
+	var b [1]byte
+
+	_, err := io.ReadFull(r.Reader, b[:])
+	if err != nil {
+		return 0, err
+	}
+
+	return b[0], nil
+}
+
+func uvarintSize(v uint64) int {
+	return (bits.Len64(v|1) + 6) / 7
+}
+
+func varintSize(v int64) int {
+	// Zigzag encoding, see [binary.PutVarint].
+	ux := uint64(v) << 1
+	if v < 0 {
+		ux = ^ux
+	}
+
+	return uvarintSize(ux)
+}
+
+// lenSize returns the encoded size of a string, slice, array or map length.
+func lenSize(length int, enc intEncoding) int {
+	if enc == intVarint {
+		return uvarintSize(uint64(length))
+	}
+
+	return 4
+}
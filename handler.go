@@ -6,6 +6,7 @@ import (
 	"hash/maphash"
 	"io"
 	"net/http"
+	"strconv"
 	"sync"
 	"unique"
 	"weak"
@@ -32,9 +33,48 @@ const (
 	httpErrResponse            = "Error encoding or writing response"
 )
 
-func handler[Request, Response any](h HandlerFunc[Request, Response], cacheResponse bool) http.HandlerFunc {
+// Decode limits of requests, protecting servers from hostile payloads.
+const (
+	maxRequestBytes        = 32 << 20
+	maxRequestLength       = 1 << 20
+	maxRequestStringLength = 16 << 20
+	maxRequestDepth        = 64
+)
+
+// requestDecodeOptions returns the goc options used to decode requests.
+func requestDecodeOptions(strict bool) []goc.Option {
+	options := []goc.Option{
+		goc.WithMaxBytes(maxRequestBytes),
+		goc.WithMaxLength(maxRequestLength),
+		goc.WithMaxStringLength(maxRequestStringLength),
+		goc.WithMaxDepth(maxRequestDepth),
+	}
+
+	if strict {
+		options = append(options, goc.WithStrict())
+	}
+
+	return options
+}
+
+// requestErrorStatus returns the HTTP status code of a request decoding error.
+func requestErrorStatus(err error) int {
+	var (
+		limitErr    *goc.LimitError
+		maxBytesErr *http.MaxBytesError
+	)
+
+	if errors.As(err, &limitErr) || errors.As(err, &maxBytesErr) {
+		return http.StatusRequestEntityTooLarge
+	}
+
+	return http.StatusBadRequest
+}
+
+func handler[Request, Response any](h HandlerFunc[Request, Response], cacheResponse, strict bool) http.HandlerFunc {
 	hsh := h.Hash()
 	hshHandle := unique.Make(hsh)
+	decodeOptions := requestDecodeOptions(strict)
 
 	var seed maphash.Seed
 	// TODO: use sync.Map?
@@ -90,11 +130,11 @@ func handler[Request, Response any](h HandlerFunc[Request, Response], cacheRespo
 		// Decode request.
 		if cacheResponse {
 			// TODO: read until content length
-			body, err := io.ReadAll(r.Body)
+			body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxRequestBytes))
 			_ = r.Body.Close()
 			if err != nil {
 				// TODO: revise error
-				http.Error(w, httpErrRequest, http.StatusBadRequest)
+				http.Error(w, httpErrRequest, requestErrorStatus(err))
 				return
 			}
 
@@ -105,17 +145,17 @@ func handler[Request, Response any](h HandlerFunc[Request, Response], cacheRespo
 			cacheLock.RUnlock()
 
 			if ok && res.Value() != nil {
-				req, err = goc.Decode[Request](body)
+				req, err = goc.Decode[Request](body, decodeOptions...)
 				if err != nil {
-					http.Error(w, httpErrRequest, http.StatusBadRequest)
+					http.Error(w, httpErrRequest, requestErrorStatus(err))
 					return
 				}
 			}
 		} else {
-			req, err = goc.DecodeFrom[Request](r.Body)
+			req, err = goc.DecodeFrom[Request](r.Body, decodeOptions...)
 			_ = r.Body.Close()
 			if err != nil {
-				http.Error(w, httpErrRequest, http.StatusBadRequest)
+				http.Error(w, httpErrRequest, requestErrorStatus(err))
 				return
 			}
 		}
@@ -135,34 +175,34 @@ func handler[Request, Response any](h HandlerFunc[Request, Response], cacheRespo
 			return
 		}
 
+		// Encode response before writing headers, so encoding errors can still be reported.
+		buf := getBuffer()
+		defer putBuffer(buf)
+
+		payload, err := encodePayload(buf, res)
+		if err != nil {
+			http.Error(w, httpErrResponse, http.StatusInternalServerError)
+			return
+		}
+
+		*buf = payload
+
 		w.Header().Set(HeaderContentType, MIMEType)
 		w.Header().Set(HeaderXContentTypeOptions, nosniff)
 		w.Header().Set(HeaderMethodHash, hsh)
+		w.Header().Set(HeaderContentLength, strconv.Itoa(len(payload)))
 
-		// Encode and return response.
 		if cacheResponse && payloadHash > 0 {
-			payload, err := goc.Encode(res)
-			if err != nil {
-				http.Error(w, httpErrResponse, http.StatusInternalServerError)
-				return
-			}
-
 			cacheLock.Lock()
 			// TODO: does it even make sense to use weak pointer cache for server?
 			cache[payloadHash] = weak.Make(res)
 			cacheLock.Unlock()
-
-			_, _ = w.Write(payload)
 		} else {
 			// TODO: define constants
 			w.Header().Set("Cache-Control", "no-store")
-
-			if err := goc.EncodeTo(w, res); err != nil {
-				http.Error(w, httpErrResponse, http.StatusInternalServerError)
-				return
-			}
 		}
 
 		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write(payload)
 	}
 }
@@ -18,6 +18,7 @@ type Server struct {
 	running                 atomic.Bool
 	port                    int
 	cacheResponse, validate bool
+	strict                  bool
 }
 
 const (
@@ -56,6 +57,7 @@ func NewServer(port int, options ...ServerOption) (*Server, error) {
 		port:          port,
 		cacheResponse: cfg.validate,
 		validate:      cfg.validate,
+		strict:        cfg.strict,
 	}, nil
 }
 
@@ -69,7 +71,7 @@ func Register[Request, Response any](s *Server, h HandlerFunc[Request, Response]
 		h = ValidationMiddleware(h)
 	}
 
-	s.mux.Handle("POST /"+h.Hash(), handler(h, s.cacheResponse))
+	s.mux.Handle("POST /"+h.Hash(), handler(h, s.cacheResponse, s.strict))
 }
 
 // Addr returns the server address.
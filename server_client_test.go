@@ -1,17 +1,23 @@
 package gorpc_test
 
 import (
+	"bytes"
 	"context"
 	cryptorand "crypto/rand"
+	"encoding/binary"
+	"errors"
 	"log/slog"
 	"math/rand/v2"
 	"net/http"
+	"net/http/httptest"
+	"runtime"
 	"strconv"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/samborkent/gorpc"
+	"github.com/samborkent/gorpc/goc"
 )
 
 func TestServerClient(t *testing.T) {
@@ -55,6 +61,16 @@ func TestServerClient(t *testing.T) {
 			t.Error("response should be nil")
 		}
 	})
+	t.Run("limits", func(t *testing.T) {
+		t.Parallel()
+
+		// Request ID followed by a password length prefix of 0x7fffffff without any content.
+		body := append(make([]byte, 8), 0xff, 0xff, 0xff, 0x7f)
+
+		if status := postRaw(t, server.Port(), body); status != http.StatusRequestEntityTooLarge {
+			t.Errorf("got status %d, want %d", status, http.StatusRequestEntityTooLarge)
+		}
+	})
 	t.Run("success", func(t *testing.T) {
 		t.Parallel()
 
@@ -76,6 +92,243 @@ func TestServerClient(t *testing.T) {
 	})
 }
 
+func TestServerStrictDecoding(t *testing.T) {
+	t.Parallel()
+
+	server, err := gorpc.NewServer(-1, gorpc.WithStrictDecoding())
+	if err != nil {
+		t.Fatal("got server error: " + err.Error())
+	}
+
+	gorpc.Register(server, testHandler)
+
+	go func() {
+		if err := server.Start(t.Context()); err != nil {
+			t.Errorf("server error: %s", err.Error())
+		}
+	}()
+
+	time.Sleep(100 * time.Millisecond)
+
+	client, err := gorpc.NewClient[request, response]("http://127.0.0.1:" + strconv.Itoa(server.Port()))
+	if err != nil {
+		t.Fatal("got client error: " + err.Error())
+	}
+
+	t.Run("success", func(t *testing.T) {
+		t.Parallel()
+
+		resp, err := client.Do(t.Context(), &request{
+			ID:       successResponse.ID,
+			Password: "password",
+		})
+		if err != nil {
+			t.Fatal("client error: " + err.Error())
+		}
+
+		if resp == nil || *resp != successResponse {
+			t.Errorf("wrong response: got %+v, want %+v", resp, successResponse)
+		}
+	})
+	t.Run("trailing", func(t *testing.T) {
+		t.Parallel()
+
+		// Request ID followed by an empty password and a trailing byte.
+		body := append(make([]byte, 12), 0)
+
+		if status := postRaw(t, server.Port(), body); status != http.StatusBadRequest {
+			t.Errorf("got status %d, want %d", status, http.StatusBadRequest)
+		}
+	})
+	t.Run("utf8", func(t *testing.T) {
+		t.Parallel()
+
+		// Request ID followed by a password that is not valid UTF-8.
+		body := append(make([]byte, 8), 1, 0, 0, 0, 0xff)
+
+		if status := postRaw(t, server.Port(), body); status != http.StatusBadRequest {
+			t.Errorf("got status %d, want %d", status, http.StatusBadRequest)
+		}
+	})
+}
+
+// TestClientRedirect checks that the client resends the request body when it follows a redirect.
+func TestClientRedirect(t *testing.T) {
+	t.Parallel()
+
+	server, err := gorpc.NewServer(-1)
+	if err != nil {
+		t.Fatal("got server error: " + err.Error())
+	}
+
+	gorpc.Register(server, testHandler)
+
+	go func() {
+		if err := server.Start(t.Context()); err != nil {
+			t.Errorf("server error: %s", err.Error())
+		}
+	}()
+
+	time.Sleep(100 * time.Millisecond)
+
+	target := "http://127.0.0.1:" + strconv.Itoa(server.Port())
+
+	redirect := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, target+r.URL.Path, http.StatusTemporaryRedirect)
+	}))
+	redirect.Config.Protocols = new(http.Protocols)
+	redirect.Config.Protocols.SetUnencryptedHTTP2(true)
+	redirect.Start()
+	t.Cleanup(redirect.Close)
+
+	client, err := gorpc.NewClient[request, response](redirect.URL)
+	if err != nil {
+		t.Fatal("got client error: " + err.Error())
+	}
+
+	for range 8 {
+		resp, err := client.Do(t.Context(), &request{ID: successResponse.ID, Password: cryptorand.Text()})
+		if err != nil {
+			t.Fatal("client error: " + err.Error())
+		}
+
+		if resp == nil || *resp != successResponse {
+			t.Errorf("wrong response: got %+v, want %+v", resp, successResponse)
+		}
+	}
+}
+
+// TestServerAllocation checks that a short request claiming a long slice does not allocate the whole slice.
+// It is not parallel, so the allocations of other tests are not counted.
+func TestServerAllocation(t *testing.T) {
+	server, err := gorpc.NewServer(-1)
+	if err != nil {
+		t.Fatal("got server error: " + err.Error())
+	}
+
+	arraysHandler := func(context.Context, *arraysRequest) (*response, error) { return &successResponse, nil }
+	structsHandler := func(context.Context, *structsRequest) (*response, error) { return &successResponse, nil }
+
+	gorpc.Register(server, arraysHandler)
+	gorpc.Register(server, structsHandler)
+
+	go func() {
+		if err := server.Start(t.Context()); err != nil {
+			t.Errorf("server error: %s", err.Error())
+		}
+	}()
+
+	time.Sleep(100 * time.Millisecond)
+
+	// A slice length prefix at the request length limit without any elements.
+	body := binary.LittleEndian.AppendUint32(nil, 1<<20)
+
+	for name, hash := range map[string]string{
+		"arrays":  gorpc.HandlerFunc[arraysRequest, response](arraysHandler).Hash(),
+		"structs": gorpc.HandlerFunc[structsRequest, response](structsHandler).Hash(),
+	} {
+		var before, after runtime.MemStats
+
+		runtime.ReadMemStats(&before)
+
+		if status := postRawTo(t, server.Port(), hash, body); status != http.StatusBadRequest {
+			t.Errorf("%s: got status %d, want %d", name, status, http.StatusBadRequest)
+		}
+
+		runtime.ReadMemStats(&after)
+
+		if allocated := after.TotalAlloc - before.TotalAlloc; allocated > 16<<20 {
+			t.Errorf("%s: decoding allocated %d bytes", name, allocated)
+		}
+	}
+}
+
+type arraysRequest struct {
+	S [][4096]byte
+}
+
+type structsRequest struct {
+	S []struct{ A, B, C, D [64]int64 }
+}
+
+func TestValidationOptional(t *testing.T) {
+	t.Parallel()
+
+	handler := gorpc.ValidationMiddleware(func(ctx context.Context, req *patchRequest) (*response, error) {
+		return &successResponse, nil
+	})
+
+	for _, test := range []struct {
+		req   patchRequest
+		valid bool
+	}{
+		{patchRequest{}, true},
+		{patchRequest{Email: goc.OptionalOf(email("a@example.com"))}, true},
+		{patchRequest{Email: goc.OptionalOf(email(""))}, false},
+	} {
+		_, err := handler(t.Context(), &test.req)
+		if valid := !errors.Is(err, gorpc.ErrRequestInvalid); valid != test.valid {
+			t.Errorf("%+v: got error %v, want valid %t", test.req, err, test.valid)
+		}
+	}
+}
+
+// patchRequest updates the fields that are set.
+type patchRequest struct {
+	Name  goc.Optional[string]
+	Email goc.Optional[email]
+}
+
+func (r *patchRequest) Validate() error {
+	return r.Email.Validate()
+}
+
+type email string
+
+func (e email) Validate() error {
+	if !strings.Contains(string(e), "@") {
+		return errors.New("invalid email address")
+	}
+
+	return nil
+}
+
+// postRaw posts an encoded request body to the test handler and returns the response status code.
+func postRaw(t *testing.T, port int, body []byte) int {
+	t.Helper()
+
+	return postRawTo(t, port, gorpc.HandlerFunc[request, response](testHandler).Hash(), body)
+}
+
+// postRawTo posts an encoded request body to the handler with the given hash and returns the response status code.
+func postRawTo(t *testing.T, port int, hash string, body []byte) int {
+	t.Helper()
+
+	httpReq, err := http.NewRequestWithContext(t.Context(), http.MethodPost,
+		"http://127.0.0.1:"+strconv.Itoa(port)+"/"+hash, bytes.NewReader(body))
+	if err != nil {
+		t.Fatal("request error: " + err.Error())
+	}
+
+	httpReq.Header.Set(gorpc.HeaderAccept, gorpc.MIMEType)
+	httpReq.Header.Set(gorpc.HeaderContentType, gorpc.MIMEType)
+	httpReq.Header.Set(gorpc.HeaderMethodHash, hash)
+
+	protocols := new(http.Protocols)
+	protocols.SetUnencryptedHTTP2(true)
+
+	httpClient := &http.Client{Transport: &http.Transport{Protocols: protocols}}
+
+	httpRes, err := httpClient.Do(httpReq)
+	if err != nil {
+		t.Fatal("http error: " + err.Error())
+	}
+
+	_ = httpRes.Body.Close()
+
+	return httpRes.StatusCode
+}
+
 type request struct {
 	ID       uint64
 	Password string
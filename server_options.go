@@ -47,10 +47,26 @@ func WithServerValidation() ServerOption {
 	}
 }
 
+// WithStrictDecoding rejects requests that are not encoded exactly as a goRPC client encodes them,
+// such as requests with trailing bytes, see [goc.WithStrict].
+func WithStrictDecoding() ServerOption {
+	return func(cfg *serverConfig) error {
+		if cfg.strict {
+			return ErrOptionDuplicate
+		}
+
+		cfg.strict = true
+
+		return nil
+	}
+}
+
 type serverConfig struct {
 	validate       bool
 	withValidation bool
 
+	strict bool
+
 	server         *http.Server
 	withHTTPServer bool
 }